@@ -2,44 +2,128 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/client"
+	"medical-supply/identity"
+	"medical-supply/metrics"
+	"medical-supply/profile"
+	"medical-supply/simulator"
 )
 
-const (
-	mspID         = "Org1MSP"
-	appUser       = "alice"
-	peerEndpoint  = "localhost:7051"
-	gatewayPeer   = "peer0.org1.example.com"
-	channelName   = "mychannel"
-	chaincodeName = "medicinecontract"
-)
+// Profile used when there is no configuration file, matching the test network. Settings missing in a profile
+// of the configuration file fall back to these.
+var defaultProfile = profile.Profile{
+	MSPID:             "Org1MSP",
+	User:              "alice",
+	PeerEndpoint:      "localhost:7051",
+	GatewayPeer:       "peer0.org1.example.com",
+	Channel:           "mychannel",
+	Chaincode:         "medicinecontract",
+	ConnectionProfile: filepath.Join("..", "configuration", "gateway", "connection-org1.yaml"),
+	Credentials: filepath.Join("..", "..", "..", "test-network", "organizations", "peerOrganizations", "org1.example.com",
+		"users", "User1@org1.example.com", "msp"),
+	Wallet:  "wallet",
+	TPMKeys: ".",
+	Offline: filepath.Join("..", "..", "offline"),
+	CA: profile.CA{
+		URL:         "https://localhost:7054",
+		Name:        "ca-org1",
+		TLSCert:     filepath.Join("..", "..", "..", "test-network", "organizations", "fabric-ca", "org1", "tls-cert.pem"),
+		Affiliation: "org1.department1",
+	},
+}
 
 func main() {
-	wallet := enrollUser()
-	contract := connectToNetwork(wallet)
+	configFile := flag.String("config", "medstore.yaml", "configuration file with the profiles of the networks")
+	profileName := flag.String("profile", "", "profile of the configuration file to use (default $MEDSTORE_PROFILE or the default of the file)")
+	user := flag.String("user", "", "identity of the wallet to act as (default the user of the profile)")
+	offline := flag.Bool("offline", false, "run the smart contracts in-process on a local ledger instead of the network")
+	metricsAddress := flag.String("metrics", "", "serve Prometheus metrics on /metrics and health checks on /healthz and /readyz at this address (e.g. :9100)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nWithout command the menu of functions is shown.\n%s\n", identityUsage)
+	}
+	flag.Parse()
+
+	// Only a configuration file which is set explicitly has to exist.
+	required := false
+	flag.Visit(func(f *flag.Flag) {
+		required = required || f.Name == "config"
+	})
+	settings, err := profile.Load(*configFile, *profileName, required, defaultProfile)
+	if err != nil {
+		log.Fatalf("\nFailed to load configuration: %v", err)
+	}
+	if *user == "" {
+		*user = settings.User
+	}
 
-	tpmkey, err := tpmKeyHandler(contract, "tpmkey.txt")
+	// Offline the smart contracts run in-process, identities are enrolled with the simulated CA.
+	var sim *simulator.Simulator
+	var ca certificateAuthority
+	if *offline {
+		settings = offlineProfile(settings)
+		sim = startSimulator(settings)
+		ca = sim.CA()
+		log.Printf("Using profile %s offline as %s on the ledger in %s", settings.Name, *user, settings.Offline)
+	} else {
+		log.Printf("Using profile %s as %s on channel %s (%s)", settings.Name, *user, settings.Channel, settings.PeerEndpoint)
+	}
+
+	wallet := openWallet(settings)
+	if flag.NArg() > 0 {
+		err := runIdentityCommand(wallet, settings, ca, *user, flag.Args())
+		if err != nil {
+			log.Fatalf("\n%v", err)
+		}
+		return
+	}
+
+	var medstore *client.Client
+	if *offline {
+		enrollOfflineUser(wallet, settings, *user)
+		medstore = connectToSimulator(sim, wallet, *user)
+	} else {
+		enrollUser(wallet, settings, *user)
+		medstore = connectToNetwork(wallet, settings, *user)
+	}
+	if *metricsAddress != "" {
+		medstore.SetObserver(serveMetrics(medstore, "customers", *metricsAddress))
+	}
+
+	tpmkey, err := tpmKeyHandler(medstore, tpmKeyFile(settings, *user))
 	if err != nil {
 		log.Fatalf("Failed to generate TPM key: %v", err)
 	}
 	log.Printf("TPM Key used is: %v", tpmkey)
+	medstore.SetTPMKey(tpmkey)
 
 	log.Println("Choose number to invoke function: \n" +
 		"1 - Request a medicine \n" +
 		"2 - Cancel request \n" +
 		"3 - Check User History \n" +
 		"4 - Search Medicine by name \n" +
-		"5 - Check available medicine")
+		"5 - Check available medicine \n" +
+		"6 - Issue a prescription (prescribers only) \n" +
+		"7 - Place an order of several medicines \n" +
+		"8 - Cancel an order \n" +
+		"9 - Check my orders \n" +
+		"10 - Return a medicine \n" +
+		"11 - Check my returns \n" +
+		"12 (scan) - Look up a medicine by scanning its GS1 DataMatrix \n" +
+		"13 (search) - Search available medicine by name or disease")
 
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Scan()
@@ -47,119 +131,124 @@ func main() {
 
 	switch strings.ToLower(input) {
 	case "1":
-		request(contract, scanner, tpmkey)
+		request(medstore, scanner)
 	case "2":
-		cancelrequest(contract, scanner, tpmkey)
+		cancelrequest(medstore, scanner)
 	case "3":
-		checkUserHistory(contract, tpmkey)
+		checkUserHistory(medstore)
 	case "4":
-		searchMedicineByName(contract, scanner)
+		searchMedicineByName(medstore, scanner)
 	case "5":
-		checkAvailableMedicine(contract)
+		checkAvailableMedicine(medstore)
+	case "6":
+		issuePrescription(medstore, scanner)
+	case "7":
+		placeOrder(medstore, scanner)
+	case "8":
+		cancelOrder(medstore, scanner)
+	case "9":
+		checkUserOrders(medstore)
+	case "10":
+		requestReturn(medstore, scanner)
+	case "11":
+		checkUserReturns(medstore)
+	case "12", "scan":
+		scan(medstore, scanner)
+	case "13", "search":
+		searchMedicine(medstore, scanner)
 	default:
 		log.Fatalf("\n Error: Function to invoke not found.")
 	}
 
 }
 
-// Enrolls user as peer to the network
-func enrollUser() *gateway.Wallet {
-	err := os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
-	if err != nil {
-		log.Fatalf("\nError setting DISCOVERY_AS_LOCALHOST environemnt variable: %v", err)
+// Opens the wallet holding the identities of the users.
+func openWallet(settings *profile.Profile) *gateway.Wallet {
+	// The peers of the test network run in Docker, networks with reachable peers set DISCOVERY_AS_LOCALHOST=false.
+	if _, ok := os.LookupEnv("DISCOVERY_AS_LOCALHOST"); !ok {
+		err := os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
+		if err != nil {
+			log.Fatalf("\nError setting DISCOVERY_AS_LOCALHOST environemnt variable: %v", err)
+		}
 	}
 
-	wallet, err := gateway.NewFileSystemWallet("wallet")
+	wallet, err := gateway.NewFileSystemWallet(settings.Wallet)
 	if err != nil {
 		log.Fatalf("\nFailed to create wallet: %v", err)
 	}
-
-	if !wallet.Exists(appUser) {
-		err = populateWallet(wallet)
-		if err != nil {
-			log.Fatalf("\nFailed to populate wallet contents: %v", err)
-		}
-	} else {
-		log.Println("============ Sucessfully populated wallet ============")
-	}
-
 	return wallet
 }
 
-// Connects to the network channel and gets the smart contract to invoke functions on.
-func connectToNetwork(wallet *gateway.Wallet) *gateway.Contract {
-	ccpPath := filepath.Join("..", "configuration", "gateway", "connection-org1.yaml")
-	gw, err := gateway.Connect(
-		gateway.WithConfig(config.FromFile(filepath.Clean(ccpPath))),
-		gateway.WithIdentity(wallet, appUser),
-	)
-	if err != nil {
-		log.Fatalf("\nFailed to connect to gateway: %v", err)
+// Enrolls user as peer to the network, only the user of the profile is imported from its credentials, other users are
+// enrolled with the CA or imported first.
+func enrollUser(wallet *gateway.Wallet, settings *profile.Profile, user string) {
+	if wallet.Exists(user) {
+		log.Println("============ Sucessfully populated wallet ============")
+		return
+	}
+	if user != settings.User || settings.Credentials == "" {
+		log.Fatalf("\nIdentity %s is not in the wallet, enroll it with the CA (ca enroll) or import it (wallet import).", user)
 	}
-	defer gw.Close()
 
-	network, err := gw.GetNetwork(channelName)
+	err := populateWallet(wallet, settings)
 	if err != nil {
-		log.Fatalf("\nFailed to get network: %v", err)
+		log.Fatalf("\nFailed to populate wallet contents: %v", err)
 	}
-
-	contract := network.GetContract(chaincodeName)
-	return contract
 }
 
-// Create wallet and keystore folder for user to use.
-func populateWallet(wallet *gateway.Wallet) error {
-	credPath := filepath.Join(
-		"..",
-		"..",
-		"..",
-		"test-network",
-		"organizations",
-		"peerOrganizations",
-		"org1.example.com",
-		"users",
-		"User1@org1.example.com",
-		"msp",
-	)
-
-	certPath := filepath.Join(credPath, "signcerts", "cert.pem")
-	// read the certificate pem
-	cert, err := ioutil.ReadFile(filepath.Clean(certPath))
+// Connects to the network channel and creates the client of the smart contracts to invoke functions on.
+func connectToNetwork(wallet *gateway.Wallet, settings *profile.Profile, user string) *client.Client {
+	id, err := identity.Get(wallet, user)
 	if err != nil {
-		return err
+		log.Fatalf("\n%v", err)
 	}
 
-	keyDir := filepath.Join(credPath, "keystore")
-	// there's a single file in this dir containing the private key
-	files, err := ioutil.ReadDir(keyDir)
+	medstore, err := client.Connect(config.FromFile(filepath.Clean(settings.ConnectionProfile)), settings.Channel,
+		settings.Chaincode, user, id)
 	if err != nil {
-		return err
+		log.Fatalf("\nFailed to connect to network: %v", err)
 	}
-	if len(files) < 1 {
-		return fmt.Errorf("Keystore folder should contain one file")
-	}
-	keyPath := filepath.Join(keyDir, files[0].Name())
-	key, err := ioutil.ReadFile(filepath.Clean(keyPath))
+	return medstore
+}
+
+// Serves the metrics of the transactions of the client while the application runs, the health checks ping the
+// chaincode through the gateway.
+func serveMetrics(medstore *client.Client, application string, address string) *metrics.Metrics {
+	m := metrics.New(application)
+	addr, err := m.Serve(address, medstore.Ping)
 	if err != nil {
-		return err
+		log.Fatalf("\n%v", err)
 	}
+	log.Printf("Serving metrics on http://%s/metrics and health checks on /healthz and /readyz", addr)
+	return m
+}
 
-	identity := gateway.NewX509Identity(mspID, string(cert), string(key))
+// Imports the user of the profile into the wallet, by default the user of the test network.
+func populateWallet(wallet *gateway.Wallet, settings *profile.Profile) error {
+	return identity.Import(wallet, settings.User, settings.MSPID, settings.Credentials)
+}
 
-	return wallet.Put(appUser, identity)
+// Returns the file the tpm key of the user is stored in, the user of the profile keeps using tpmkey.txt.
+func tpmKeyFile(settings *profile.Profile, user string) string {
+	if user == settings.User {
+		return filepath.Join(settings.TPMKeys, "tpmkey.txt")
+	}
+	return filepath.Join(settings.TPMKeys, "tpmkey-"+user+".txt")
 }
 
 // Reads tpm key from file, if no success then request for new key and store that.
-func tpmKeyHandler(contract *gateway.Contract, filepath string) (string, error) {
+func tpmKeyHandler(medstore *client.Client, filepath string) (string, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		// Request tpm key from smart contract
 		log.Println("--> Submit Transaction: TPMKeyGen, function requests for tpm generated key.")
-		result, err := contract.SubmitTransaction("TPMKeyGen", appUser)
+		tpmkey, err := medstore.TPMKeyGen(context.Background())
+		if client.ErrorCode(err) == client.CodeAlreadyExists {
+			log.Fatalf("\nUser %s already has a TPM key but %s is missing, restore it from a backup.", medstore.User(), filepath)
+		}
 		if err != nil {
-			log.Fatalf("\nFailed to Submit transaction: %v", err)
+			failTransaction(err)
 		}
-		tpmkey := string(result)
 
 		// Store tpmkey to file
 		file, err := os.Create(filepath)
@@ -183,29 +272,53 @@ func tpmKeyHandler(contract *gateway.Contract, filepath string) (string, error)
 }
 
 // Helper function for pretty printing results to the terminal.
-func prettyPrint(body []byte) {
-	var indentedFormat bytes.Buffer
-	error := json.Indent(&indentedFormat, body, "", "\t")
-	if error != nil {
-		log.Println("Error encountered Json parse error: ", error)
-		// Print bytes normally without the pretty printed format
-		log.Println(string(body))
+func prettyPrint(result interface{}) {
+	body, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		log.Println("Error encountered Json parse error: ", err)
+		// Print the result normally without the pretty printed format
+		log.Println(result)
 		return
 	}
-	log.Println(string(indentedFormat.Bytes()))
+	log.Println(string(body))
 }
 
 // Helper function for printing array results.
-func printArray(result []byte) {
-	if len(result) > 0 {
+func printArray(count int, result interface{}) {
+	if count > 0 {
 		prettyPrint(result)
 	} else {
 		log.Println("No transactions found on ledger.")
 	}
 }
 
+// Helper function for printing one or more orders with their status and line-level detail.
+func printOrders(orders ...*client.Order) {
+	if len(orders) == 0 {
+		log.Println("No orders found on ledger.")
+		return
+	}
+
+	for _, o := range orders {
+		log.Printf("Order %s (%s) placed %s by %s", o.OrderID, o.State, o.OrderDate, o.Customer)
+		for _, line := range o.Lines {
+			log.Printf("\t%dx %s: %s", line.Quantity, line.MedName, strings.Join(line.MedNumbers, ", "))
+		}
+	}
+}
+
+// Reads a number from the scanner, stopping the application if it is not a number.
+func readNumber(scanner *bufio.Scanner, name string) int {
+	scanner.Scan()
+	number, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Fatalf("\nInvalid %s: %v", name, err)
+	}
+	return number
+}
+
 // Invokes function that puts a request for a certain medicine.
-func request(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func request(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -214,15 +327,26 @@ func request(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string)
 	medNumber := scanner.Text()
 
 	log.Println("--> Submit Transaction: Request, function sends request for medicine.")
-	result, err := contract.SubmitTransaction("Request", medName, medNumber, appUser, tpmkey)
+	medicine, err := medstore.Request(context.Background(), medName, medNumber)
+	switch client.ErrorCode(err) {
+	case client.CodeAlreadyRequested, client.CodeNotAvailable:
+		// Suggest the packs of the same medicine which can still be requested.
+		log.Printf("\n%s %s can't be requested anymore, available packs of %s:", medName, medNumber, medName)
+		medicines, err := medstore.SearchMedicineByName(context.Background(), medName)
+		if err != nil {
+			failTransaction(err)
+		}
+		printArray(len(medicines), medicines)
+		return
+	}
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Invokes function that cancels request for a certain medicine.
-func cancelrequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func cancelrequest(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -231,43 +355,192 @@ func cancelrequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey st
 	medNumber := scanner.Text()
 
 	log.Println("--> Submit Transaction: CancelRequest, function sends request for medicine.")
-	result, err := contract.SubmitTransaction("CancelRequest", medName, medNumber, appUser, tpmkey)
+	medicine, err := medstore.CancelRequest(context.Background(), medName, medNumber)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Invokes function that returns an user's transaction history.
-func checkUserHistory(contract *gateway.Contract, tpmkey string) {
-	log.Println("--> Submit Transaction: CheckUserHistory, function shows history.")
-	result, err := contract.SubmitTransaction("CheckUserHistory", appUser, tpmkey)
+func checkUserHistory(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckUserHistory, function shows history.")
+	medicines, err := medstore.CheckUserHistory(context.Background())
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	printArray(result)
+	printArray(len(medicines), medicines)
 }
 
 // Invokes function that returns all available medicine matching the medicine name.
-func searchMedicineByName(contract *gateway.Contract, scanner *bufio.Scanner) {
+func searchMedicineByName(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
 
-	log.Println("--> Submit Transaction: SearchMedicineByName, function shows available medicine matching the medicine name.")
-	result, err := contract.SubmitTransaction("SearchMedicineByName", medName)
+	log.Println("--> Evaluate Transaction: SearchMedicineByName, function shows available medicine matching the medicine name.")
+	medicines, err := medstore.SearchMedicineByName(context.Background(), medName)
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(medicines), medicines)
+}
+
+// Invokes function that returns the available medicine best matching a name or disease, tolerating typos.
+func searchMedicine(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Search for medicine name or disease (e.g. asprin or fever):")
+	scanner.Scan()
+	query := scanner.Text()
+
+	log.Println("--> Evaluate Transaction: SearchMedicine, function shows the available medicine best matching the search.")
+	results, err := medstore.SearchMedicine(context.Background(), query)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	printArray(result)
+	printArray(len(results), results)
+}
+
+// Reads a GS1 element string from the scanner, keyboard wedge scanners which can't send the FNC1 (GS) character
+// may be configured to send <GS> instead.
+func readElementString(scanner *bufio.Scanner) string {
+	log.Println("Scan the GS1 DataMatrix of the pack (e.g. (01)09506000134352(17)240531(10)ABC123(21)00012):")
+	scanner.Scan()
+	return strings.ReplaceAll(scanner.Text(), "<GS>", "\x1d")
+}
+
+// Invokes function that returns the medicine matching the scanned pack, which fails for packs that are not genuine.
+func scan(medstore *client.Client, scanner *bufio.Scanner) {
+	elementString := readElementString(scanner)
+
+	log.Println("--> Evaluate Transaction: SearchMedicineByGS1, function shows the medicine matching the scanned pack.")
+	medicine, err := medstore.SearchMedicineByGS1(context.Background(), elementString)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(medicine)
 }
 
 // Invokes function that returns all available medicine.
-func checkAvailableMedicine(contract *gateway.Contract) {
-	log.Println("--> Submit Transaction: CheckAvailableMedicine, function shows all available medicine.")
-	result, err := contract.SubmitTransaction("CheckAvailableMedicine")
+func checkAvailableMedicine(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckAvailableMedicine, function shows all available medicine.")
+	medicines, err := medstore.CheckAvailableMedicine(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(medicines), medicines)
+}
+
+// Invokes function that issues a prescription for a patient (requires the prescriber role).
+func issuePrescription(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.PrescriptionInput
+	log.Println("Prescription id (e.g. RX0001):")
+	scanner.Scan()
+	input.PrescriptionID = scanner.Text()
+	log.Println("Patient name (e.g. Alice):")
+	scanner.Scan()
+	input.Patient = scanner.Text()
+	log.Println("Medicine name (e.g. Vicodin):")
+	scanner.Scan()
+	input.MedName = scanner.Text()
+	log.Println("Quantity per fill (e.g. 1):")
+	input.Quantity = readNumber(scanner, "quantity")
+	log.Println("Number of refills (e.g. 2):")
+	input.Refills = readNumber(scanner, "number of refills")
+	log.Println("Valid from (e.g. 2022.01.01):")
+	scanner.Scan()
+	input.ValidFrom = scanner.Text()
+	log.Println("Valid until (e.g. 2022.06.30):")
+	scanner.Scan()
+	input.ValidUntil = scanner.Text()
+
+	log.Println("--> Submit Transaction: IssuePrescription, function issues a prescription for a patient.")
+	prescription, err := medstore.IssuePrescription(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(prescription)
+}
+
+// Invokes function that reserves several medicines at once as a single order.
+func placeOrder(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Order id (e.g. ORD0001):")
+	scanner.Scan()
+	orderID := scanner.Text()
+
+	var lines []client.OrderLine
+	for {
+		log.Println("Medicine name (e.g. Aspirin), leave empty to finish the order:")
+		scanner.Scan()
+		medName := scanner.Text()
+		if medName == "" {
+			break
+		}
+		log.Println("Quantity (e.g. 2):")
+		lines = append(lines, client.OrderLine{MedName: medName, Quantity: readNumber(scanner, "quantity")})
+	}
+
+	log.Println("--> Submit Transaction: PlaceOrder, function reserves all medicine of the order.")
+	order, err := medstore.PlaceOrder(context.Background(), orderID, lines)
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(order)
+}
+
+// Invokes function that cancels a pending order.
+func cancelOrder(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Order id (e.g. ORD0001):")
+	scanner.Scan()
+	orderID := scanner.Text()
+
+	log.Println("--> Submit Transaction: CancelOrder, function cancels an order.")
+	order, err := medstore.CancelOrder(context.Background(), orderID)
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(order)
+}
+
+// Invokes function that returns all orders of the user.
+func checkUserOrders(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckUserOrders, function shows the orders of the user.")
+	orders, err := medstore.CheckUserOrders(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(orders...)
+}
+
+// Invokes function that sends back a medicine which has been send to the user.
+func requestReturn(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.ReturnInput
+	log.Println("Return id (e.g. RET0001):")
+	scanner.Scan()
+	input.ReturnID = scanner.Text()
+	log.Println("Medicine name (e.g. Aspirin):")
+	scanner.Scan()
+	input.MedName = scanner.Text()
+	log.Println("Medicine number (e.g. 00001):")
+	scanner.Scan()
+	input.MedNumber = scanner.Text()
+	log.Println("Reason for returning (e.g. Damaged package):")
+	scanner.Scan()
+	input.Reason = scanner.Text()
+
+	log.Println("--> Submit Transaction: RequestReturn, function files a return for medicine.")
+	medicineReturn, err := medstore.RequestReturn(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(medicineReturn)
+}
+
+// Invokes function that returns all returns of the user.
+func checkUserReturns(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckUserReturns, function shows the returns of the user.")
+	returns, err := medstore.CheckUserReturns(context.Background())
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	printArray(result)
+	printArray(len(returns), returns)
 }
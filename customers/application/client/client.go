@@ -0,0 +1,145 @@
+// Package client is a typed client of the medical-supply chaincode. Transactions which change the ledger are submitted,
+// queries are evaluated on a single peer without being ordered, and contract errors are returned as *ContractError.
+// The client runs against the contracts on a channel of a Fabric network or against the in-memory Fake.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Names of the contracts of the chaincode.
+const (
+	CustomerContract  = "org.medstore.customer"
+	RegulatorContract = "org.medstore.regulator"
+	AuthContract      = "org.medstore.auth"
+)
+
+// Transactor - Invokes the transactions of a single contract, implemented by the contracts of Connect and Fake.
+type Transactor interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// Client - Invokes the transactions of the medical-supply contracts on behalf of a single user.
+type Client struct {
+	customer  Transactor
+	regulator Transactor
+	auth      Transactor
+	system    Transactor
+	user      string
+	tpmkey    string
+	observer  Observer
+}
+
+// New - Creates a client of the customer, regulator and auth contracts for the user.
+func New(customer Transactor, regulator Transactor, auth Transactor, user string) *Client {
+	return &Client{customer: customer, regulator: regulator, auth: auth, user: user}
+}
+
+// User - Returns the name of the user the client invokes transactions for.
+func (c *Client) User() string {
+	return c.user
+}
+
+// SetTPMKey - Sets the TPM key the user authenticates with, as returned by TPMKeyGen.
+func (c *Client) SetTPMKey(tpmkey string) {
+	c.tpmkey = tpmkey
+}
+
+// credentials - Appends the user and TPM key, which are the last arguments of all authenticated transactions.
+func (c *Client) credentials(args ...string) []string {
+	return append(args, c.user, c.tpmkey)
+}
+
+// submit - Submits a transaction which changes the ledger, it is endorsed, ordered and committed.
+func (c *Client) submit(ctx context.Context, contract Transactor, name string, args ...string) ([]byte, error) {
+	return c.invoke(ctx, contract, true, name, args)
+}
+
+// evaluate - Evaluates a query on a single peer, its result is not committed to the ledger.
+func (c *Client) evaluate(ctx context.Context, contract Transactor, name string, args ...string) ([]byte, error) {
+	return c.invoke(ctx, contract, false, name, args)
+}
+
+// invoke - Invokes the transaction unless the context is done, contract errors are decoded and the outcome is passed
+// to the observer. The SDK can't cancel a transaction which has been sent, so the context is only checked before.
+func (c *Client) invoke(ctx context.Context, contract Transactor, submit bool, name string, args []string) ([]byte, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := Outcome{Function: name, Submitted: submit}
+	start := time.Now()
+	var result []byte
+	if committer, ok := contract.(Committer); ok && submit {
+		var commit Commit
+		result, commit, err = committer.SubmitAndCommit(name, args...)
+		outcome.TxID, outcome.ValidationCode, outcome.CommitDuration = commit.TxID, commit.ValidationCode, commit.Duration
+	} else if submit {
+		result, err = contract.SubmitTransaction(name, args...)
+	} else {
+		result, err = contract.EvaluateTransaction(name, args...)
+	}
+	outcome.Duration = time.Since(start)
+
+	if err != nil {
+		if ce, ok := DecodeError(err); ok {
+			err = ce
+		} else {
+			err = fmt.Errorf("transaction %s failed: %w", name, err)
+		}
+	}
+	if c.observer != nil {
+		outcome.Args = c.redact(args)
+		outcome.Err = err
+		c.observer.ObserveTransaction(outcome)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// transact - Submits a transaction and decodes its result into the value.
+func (c *Client) transact(ctx context.Context, contract Transactor, value interface{}, name string, args ...string) error {
+	result, err := c.submit(ctx, contract, name, args...)
+	if err != nil {
+		return err
+	}
+	return decode(name, result, value)
+}
+
+// query - Evaluates a query and decodes its result into the value.
+func (c *Client) query(ctx context.Context, contract Transactor, value interface{}, name string, args ...string) error {
+	result, err := c.evaluate(ctx, contract, name, args...)
+	if err != nil {
+		return err
+	}
+	return decode(name, result, value)
+}
+
+// submitMedicine - Submits an authenticated transaction on a single medicine, returning the changed medicine.
+func (c *Client) submitMedicine(ctx context.Context, contract Transactor, name string, args ...string) (*MedicalSupply, error) {
+	var medicine MedicalSupply
+	err := c.transact(ctx, contract, &medicine, name, c.credentials(args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &medicine, nil
+}
+
+// decode - Decodes the JSON result of a transaction, an empty result (e.g. an empty list) leaves the value as is.
+func decode(name string, result []byte, value interface{}) error {
+	if len(result) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(result, value)
+	if err != nil {
+		return fmt.Errorf("invalid result of transaction %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// PrescriptionInput - Arguments of IssuePrescription.
+type PrescriptionInput struct {
+	PrescriptionID string
+	Patient        string
+	MedName        string
+	Quantity       int
+	Refills        int
+	ValidFrom      string
+	ValidUntil     string
+}
+
+// ReturnInput - Arguments of RequestReturn.
+type ReturnInput struct {
+	ReturnID  string
+	MedName   string
+	MedNumber string
+	Reason    string
+}
+
+// TPMKeyGen - Registers the user and returns its TPM key, which the client uses from then on.
+// The key is only returned once, a registered user gets an ALREADY_EXISTS error.
+func (c *Client) TPMKeyGen(ctx context.Context) (string, error) {
+	result, err := c.submit(ctx, c.auth, "TPMKeyGen", c.user)
+	if err != nil {
+		return "", err
+	}
+	c.tpmkey = string(result)
+	return c.tpmkey, nil
+}
+
+// Request - Requests a medicine for the user.
+func (c *Client) Request(ctx context.Context, medName string, medNumber string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.customer, "Request", medName, medNumber)
+}
+
+// CancelRequest - Cancels a request of the user, the medicine becomes available again.
+func (c *Client) CancelRequest(ctx context.Context, medName string, medNumber string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.customer, "CancelRequest", medName, medNumber)
+}
+
+// SearchMedicineByName - Returns the available medicine with the name.
+func (c *Client) SearchMedicineByName(ctx context.Context, medName string) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.customer, &medicines, "SearchMedicineByName", medName)
+	return medicines, err
+}
+
+// SearchMedicine - Returns the available medicine best matching a name or disease, tolerating typos.
+func (c *Client) SearchMedicine(ctx context.Context, query string) ([]*SearchResult, error) {
+	var results []*SearchResult
+	err := c.query(ctx, c.customer, &results, "SearchMedicine", query)
+	return results, err
+}
+
+// SearchMedicineByGS1 - Returns the medicine matching the element string of a scanned pack.
+func (c *Client) SearchMedicineByGS1(ctx context.Context, elementString string) (*MedicalSupply, error) {
+	var medicine MedicalSupply
+	err := c.query(ctx, c.customer, &medicine, "SearchMedicineByGS1", elementString)
+	if err != nil {
+		return nil, err
+	}
+	return &medicine, nil
+}
+
+// CheckAvailableMedicine - Returns all available medicine.
+func (c *Client) CheckAvailableMedicine(ctx context.Context) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.customer, &medicines, "CheckAvailableMedicine")
+	return medicines, err
+}
+
+// CheckUserHistory - Returns the medicine held by the user.
+func (c *Client) CheckUserHistory(ctx context.Context) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.customer, &medicines, "CheckUserHistory", c.credentials()...)
+	return medicines, err
+}
+
+// IssuePrescription - Issues a prescription for a patient, the user needs the prescriber role.
+func (c *Client) IssuePrescription(ctx context.Context, input PrescriptionInput) (*Prescription, error) {
+	var prescription Prescription
+	args := c.credentials(input.PrescriptionID, input.Patient, input.MedName, strconv.Itoa(input.Quantity),
+		strconv.Itoa(input.Refills), input.ValidFrom, input.ValidUntil)
+	err := c.transact(ctx, c.customer, &prescription, "IssuePrescription", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+// PlaceOrder - Reserves the quantity of every line at once, the contract picks the medicine numbers.
+func (c *Client) PlaceOrder(ctx context.Context, orderID string, lines []OrderLine) (*Order, error) {
+	linesJSON, err := json.Marshal(lines)
+	if err != nil {
+		return nil, err
+	}
+	var order Order
+	err = c.transact(ctx, c.customer, &order, "PlaceOrder", c.credentials(orderID, string(linesJSON))...)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CancelOrder - Cancels a pending order of the user.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (*Order, error) {
+	var order Order
+	err := c.transact(ctx, c.customer, &order, "CancelOrder", c.credentials(orderID)...)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CheckUserOrders - Returns the orders of the user.
+func (c *Client) CheckUserOrders(ctx context.Context) ([]*Order, error) {
+	var orders []*Order
+	err := c.query(ctx, c.customer, &orders, "CheckUserOrders", c.credentials()...)
+	return orders, err
+}
+
+// RequestReturn - Sends back a medicine which has been sent to the user.
+func (c *Client) RequestReturn(ctx context.Context, input ReturnInput) (*MedicineReturn, error) {
+	var medicineReturn MedicineReturn
+	args := c.credentials(input.ReturnID, input.MedName, input.MedNumber, input.Reason)
+	err := c.transact(ctx, c.customer, &medicineReturn, "RequestReturn", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &medicineReturn, nil
+}
+
+// CheckUserReturns - Returns the returns of the user.
+func (c *Client) CheckUserReturns(ctx context.Context) ([]*MedicineReturn, error) {
+	var returns []*MedicineReturn
+	err := c.query(ctx, c.customer, &returns, "CheckUserReturns", c.credentials()...)
+	return returns, err
+}
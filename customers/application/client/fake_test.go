@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeRequestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	regulator := New(fake, fake, fake, "bob")
+	customer := New(fake, fake, fake, "Alice")
+
+	_, err := regulator.TPMKeyGen(ctx)
+	assert.Nil(t, err, "should register the regulator")
+	_, err = customer.TPMKeyGen(ctx)
+	assert.Nil(t, err, "should register the customer")
+	_, err = customer.TPMKeyGen(ctx)
+	assert.Equal(t, CodeAlreadyExists, ErrorCode(err), "should not register a user twice")
+
+	medicine, err := regulator.Issue(ctx, IssueInput{MedName: "Aspirin", MedNumber: "00001", Disease: "Pain", Expiration: "2022.05.09", Price: "$10"})
+	assert.Nil(t, err, "should issue medicine")
+	assert.Equal(t, &MedicalSupply{MedName: "aspirin", MedNumber: "00001", Disease: "pain", Expiration: "2022.05.09", Price: "$10", Holder: "MedStore", State: Available}, medicine, "should lowercase the name and disease")
+	_, err = regulator.Issue(ctx, IssueInput{MedName: "aspirin", MedNumber: "00001", Disease: "pain", Expiration: "2022.05.09", Price: "$10"})
+	assert.Equal(t, CodeAlreadyExists, ErrorCode(err), "should not issue medicine twice")
+
+	medicine, err = customer.Request(ctx, "aspirin", "00001")
+	assert.Nil(t, err, "should request the medicine")
+	assert.Equal(t, Requested, medicine.State, "should set the medicine requested")
+	assert.Equal(t, "alice", medicine.Holder, "should make the customer the holder")
+	_, err = customer.Request(ctx, "aspirin", "00001")
+	assert.Equal(t, CodeAlreadyRequested, ErrorCode(err), "should not request medicine twice")
+	_, err = customer.Request(ctx, "aspirin", "00002")
+	assert.Equal(t, CodeNotFound, ErrorCode(err), "should report missing medicine")
+
+	requested, err := regulator.CheckRequestedMedicine(ctx)
+	assert.Nil(t, err, "should list requested medicine")
+	assert.Len(t, requested, 1, "should list the requested medicine")
+	assert.Equal(t, CodeInvalidState, ErrorCode(regulator.Delete(ctx, "aspirin", "00001")), "should not delete requested medicine")
+	medicine, err = regulator.ApproveRequest(ctx, "aspirin", "00001")
+	assert.Nil(t, err, "should approve the request")
+	assert.Equal(t, Send, medicine.State, "should send unscheduled medicine at once")
+
+	history, err := customer.CheckUserHistory(ctx)
+	assert.Nil(t, err, "should list the medicine of the customer")
+	assert.Equal(t, []*MedicalSupply{medicine}, history, "should return the medicine as stored")
+}
+
+func TestFakeSecondApproval(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	regulator := New(fake, fake, fake, "bob")
+	regulator.TPMKeyGen(ctx)
+
+	assert.Nil(t, regulator.InitLedger(ctx), "should initialise the ledger")
+	fake.state.medicines["vicodin:00002"].State = Requested
+
+	medicine, err := regulator.ApproveRequest(ctx, "vicodin", "00002")
+	assert.Nil(t, err, "should approve the scheduled medicine")
+	assert.Equal(t, PendingSecondApproval, medicine.State, "should wait for a second approval")
+	_, err = regulator.ApproveRequest(ctx, "vicodin", "00002")
+	assert.Equal(t, CodeSecondApproval, ErrorCode(err), "should require a different regulator")
+	assert.Equal(t, "PENDING_SECOND_APPROVAL", err.(*ContractError).Details["state"], "should add the state to the details")
+}
+
+func TestFakeAuthentication(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	c := New(fake, fake, fake, "alice")
+
+	_, err := c.CheckUserHistory(ctx)
+	assert.Equal(t, CodeUnauthenticated, ErrorCode(err), "should reject unregistered users")
+	c.TPMKeyGen(ctx)
+	c.SetTPMKey("wrong")
+	_, err = c.CheckUserHistory(ctx)
+	assert.Equal(t, CodeUnauthenticated, ErrorCode(err), "should reject a wrong tpm key")
+
+	available, err := c.CheckAvailableMedicine(ctx)
+	assert.Nil(t, err, "should not authenticate public queries")
+	assert.Empty(t, available, "should start with an empty ledger")
+
+	_, err = c.QueryMedicines(ctx, "{}")
+	assert.Equal(t, CodeInternal, ErrorCode(err), "should fail transactions without implementation or handler")
+}
+
+func TestFakeEvaluateDiscardsChanges(t *testing.T) {
+	fake := NewFake()
+	_, err := fake.EvaluateTransaction("org.medstore.auth:TPMKeyGen", "alice")
+	assert.Nil(t, err, "should evaluate transactions with the contract name")
+	_, err = fake.SubmitTransaction("TPMKeyGen", "alice")
+	assert.Nil(t, err, "should not keep changes of evaluated transactions")
+	_, err = fake.SubmitTransaction("TPMKeyGen", "alice")
+	assert.Equal(t, CodeAlreadyExists, ErrorCode(err), "should keep changes of submitted transactions")
+}
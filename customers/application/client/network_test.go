@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventService - Event service of a channel, delivering the commit events the orderer queues.
+type eventService struct {
+	fab.EventService
+	events     chan *fab.TxStatusEvent
+	registered string
+}
+
+func (es *eventService) RegisterTxStatusEvent(txID string) (fab.Registration, <-chan *fab.TxStatusEvent, error) {
+	es.registered = txID
+	return nil, es.events, nil
+}
+
+func (es *eventService) Unregister(registration fab.Registration) {}
+
+// orderer - Transactor committing every transaction sent to it after the delay, with the validation code.
+type orderer struct {
+	fab.Transactor
+	events chan *fab.TxStatusEvent
+	delay  time.Duration
+	code   peer.TxValidationCode
+}
+
+func (o *orderer) CreateTransaction(request fab.TransactionRequest) (*fab.Transaction, error) {
+	return &fab.Transaction{Proposal: request.Proposal}, nil
+}
+
+func (o *orderer) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+	go func() {
+		time.Sleep(o.delay)
+		o.events <- &fab.TxStatusEvent{TxID: string(tx.Proposal.TxnID), TxValidationCode: o.code}
+	}()
+	return &fab.TransactionResponse{}, nil
+}
+
+func TestCommitHandler(t *testing.T) {
+	events := make(chan *fab.TxStatusEvent, 1)
+	service := &eventService{events: events}
+	clientContext := &invoke.ClientContext{EventService: service,
+		Transactor: &orderer{events: events, delay: 20 * time.Millisecond, code: peer.TxValidationCode_VALID}}
+	endorsed := func() *invoke.RequestContext {
+		return &invoke.RequestContext{Ctx: context.Background(), Response: invoke.Response{
+			TransactionID: "tx1", Proposal: &fab.TransactionProposal{TxnID: "tx1"}}}
+	}
+
+	start := time.Now()
+	endorsement := 30 * time.Millisecond
+	time.Sleep(endorsement)
+	handler := &commitHandler{}
+	requestContext := endorsed()
+	handler.Handle(requestContext, clientContext)
+	assert.Nil(t, requestContext.Error, "should commit the transaction")
+	assert.Equal(t, "tx1", service.registered, "should wait for the commit event of the transaction")
+	assert.Equal(t, "tx1", handler.commit.TxID, "should return the ID of the transaction")
+	assert.Equal(t, ValidationValid, handler.commit.ValidationCode, "should return the validation code")
+	assert.True(t, handler.commit.Duration >= 20*time.Millisecond, "should time the commit until the event arrived")
+	assert.True(t, handler.commit.Duration <= time.Since(start)-endorsement, "should not time the endorsement")
+
+	clientContext.Transactor = &orderer{events: events, code: peer.TxValidationCode_MVCC_READ_CONFLICT}
+	handler = &commitHandler{}
+	requestContext = endorsed()
+	handler.Handle(requestContext, clientContext)
+	assert.Equal(t, ValidationMVCCConflict, handler.commit.ValidationCode, "should return the validation code of invalid transactions")
+	s, ok := status.FromError(requestContext.Error)
+	assert.True(t, ok, "should fail with a status, so the channel client retries the transaction")
+	assert.Equal(t, status.EventServerStatus, s.Group, "should fail with the status of the commit event")
+	assert.Equal(t, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), s.Code, "should fail with the validation code")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler = &commitHandler{}
+	requestContext = endorsed()
+	requestContext.Ctx = ctx
+	clientContext.EventService = &eventService{events: make(chan *fab.TxStatusEvent)}
+	handler.Handle(requestContext, clientContext)
+	assert.NotNil(t, requestContext.Error, "should fail when the commit event does not arrive in time")
+	assert.Equal(t, Commit{}, handler.commit, "should not return a commit")
+}
+
+// newTestProfile - Returns a connection profile without channels as in the test network, the peer trusts the CA
+// certificate. The SDK creates its key store in the directory.
+func newTestProfile(caCert string, dir string) []byte {
+	return []byte(`
+name: test-network-org1
+version: 1.0.0
+client:
+  organization: Org1
+  credentialStore:
+    cryptoStore:
+      path: ` + dir + `
+organizations:
+  Org1:
+    mspid: Org1MSP
+    peers:
+    - peer0.org1.example.com
+peers:
+  peer0.org1.example.com:
+    url: grpcs://localhost:7051
+    tlsCACerts:
+      pem: |
+        ` + strings.ReplaceAll(strings.TrimSpace(caCert), "\n", "\n        ") + `
+`)
+}
+
+// newTestIdentity - Returns a self-signed identity as enrolled by a CA.
+func newTestIdentity(t *testing.T) *gateway.X509Identity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "should generate a key")
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "alice"},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err, "should create a certificate")
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err, "should marshal the key")
+	return gateway.NewX509Identity("Org1MSP", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+}
+
+func TestProfileConfig(t *testing.T) {
+	os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
+	defer os.Unsetenv("DISCOVERY_AS_LOCALHOST")
+	id := newTestIdentity(t)
+	profile := newTestProfile(id.Certificate(), t.TempDir())
+	backends, err := profileConfig(config.FromRaw(profile, "yaml"), "Alice", id)()
+	assert.Nil(t, err, "should read the profile")
+
+	channels, ok := backends[0].Lookup("channels")
+	assert.True(t, ok, "should add the default channel")
+	assert.Contains(t, channels.(map[string]interface{})["_default"], "peers", "should serve channels by the peers of the organisation")
+	matchers, ok := backends[0].Lookup("entityMatchers")
+	assert.True(t, ok, "should map discovered peers and orderers to localhost")
+	assert.Equal(t, "localhost:${2}", matchers.(map[string][]map[string]string)["orderer"][0]["urlSubstitutionExp"], "should keep the port")
+
+	sdk, err := fabsdk.New(profileConfig(config.FromRaw(profile, "yaml"), "Alice", id))
+	assert.Nil(t, err, "should create the SDK")
+	defer sdk.Close()
+	ctx, err := sdk.Context(fabsdk.WithUser("Alice"))()
+	assert.Nil(t, err, "should find the user in the organisation of the client")
+	assert.Equal(t, id.Certificate(), string(ctx.EnrollmentCertificate()), "should sign with the identity of the user")
+	assert.Equal(t, "Org1MSP", ctx.Identifier().MSPID, "should sign for the organisation of the client")
+	assert.Len(t, ctx.EndpointConfig().ChannelPeers("mychannel"), 1, "should find the peers of any channel")
+}
@@ -0,0 +1,233 @@
+package client
+
+import "strconv"
+
+// State - State of a medicine, as numbered by the smart contract.
+type State int
+
+// States of a medicine.
+const (
+	Available State = iota + 1
+	Requested
+	Send
+	Returned
+	Destroyed
+	Quarantined
+	PendingSecondApproval
+)
+
+var stateNames = []string{"AVAILABLE", "REQUESTED", "SEND", "RETURNED", "DESTROYED", "QUARANTINED", "PENDING_SECOND_APPROVAL"}
+
+// String - Returns the name of the state as used by the smart contract.
+func (state State) String() string {
+	return enumName(stateNames, int(state))
+}
+
+// OrderState - State of an order, as numbered by the smart contract.
+type OrderState int
+
+// States of an order.
+const (
+	OrderPending OrderState = iota + 1
+	OrderApproved
+	OrderRejected
+	OrderCancelled
+)
+
+var orderStateNames = []string{"PENDING", "APPROVED", "REJECTED", "CANCELLED"}
+
+// String - Returns the name of the state as used by the smart contract.
+func (state OrderState) String() string {
+	return enumName(orderStateNames, int(state))
+}
+
+// ReturnState - State of a return, as numbered by the smart contract.
+type ReturnState int
+
+// States of a return.
+const (
+	ReturnFiled ReturnState = iota + 1
+	ReturnRestocked
+	ReturnDiscarded
+)
+
+var returnStateNames = []string{"FILED", "RESTOCKED", "DISCARDED"}
+
+// String - Returns the name of the state as used by the smart contract.
+func (state ReturnState) String() string {
+	return enumName(returnStateNames, int(state))
+}
+
+// enumName - Returns the name of a state numbered from 1, or UNKNOWN.
+func enumName(names []string, value int) string {
+	if value < 1 || value > len(names) {
+		return "UNKNOWN(" + strconv.Itoa(value) + ")"
+	}
+	return names[value-1]
+}
+
+// MedicalSupply - Medicine on the ledger.
+type MedicalSupply struct {
+	CheckSum       string `json:"checkSum"`
+	MedName        string `json:"medName"`
+	MedNumber      string `json:"medNumber"`
+	Disease        string `json:"disease"`
+	Expiration     string `json:"expiration"`
+	Price          string `json:"price"`
+	Holder         string `json:"holder"`
+	RxOnly         bool   `json:"rxOnly,omitempty"`
+	Schedule       string `json:"schedule,omitempty"`
+	FirstApprover  string `json:"firstApprover,omitempty"`
+	PrescriptionID string `json:"prescriptionID,omitempty"`
+	OrderID        string `json:"orderID,omitempty"`
+	QuarantineNote string `json:"quarantineNote,omitempty"`
+	RequestDate    string `json:"requestDate,omitempty"`
+	GTIN           string `json:"gtin,omitempty"`
+	SerialNumber   string `json:"serialNumber,omitempty"`
+	LotNumber      string `json:"lotNumber,omitempty"`
+	State          State  `json:"currentState"`
+}
+
+// SearchResult - Medicine matching a search, with the best match scoring highest.
+type SearchResult struct {
+	Score    int            `json:"score"`
+	Matched  []string       `json:"matched"`
+	Medicine *MedicalSupply `json:"medicine"`
+}
+
+// Prescription - Prescription of a medicine for a patient.
+type Prescription struct {
+	PrescriptionID string `json:"prescriptionID"`
+	Prescriber     string `json:"prescriber"`
+	Patient        string `json:"patient"`
+	MedName        string `json:"medName"`
+	Quantity       int    `json:"quantity"`
+	Refills        int    `json:"refills"`
+	ValidFrom      string `json:"validFrom"`
+	ValidUntil     string `json:"validUntil"`
+	Dispensed      int    `json:"dispensed"`
+}
+
+// OrderLine - Line of an order, the medicine numbers are set by the smart contract.
+type OrderLine struct {
+	MedName    string   `json:"medName"`
+	Quantity   int      `json:"quantity"`
+	MedNumbers []string `json:"medNumbers"`
+}
+
+// Order - Several medicines reserved at once.
+type Order struct {
+	OrderID       string      `json:"orderID"`
+	Customer      string      `json:"customer"`
+	OrderDate     string      `json:"orderDate"`
+	Lines         []OrderLine `json:"lines"`
+	FirstApprover string      `json:"firstApprover,omitempty"`
+	State         OrderState  `json:"currentState"`
+}
+
+// ReturnEvent - Step in the audit trail of a return.
+type ReturnEvent struct {
+	Step  string `json:"step"`
+	Actor string `json:"actor"`
+	Date  string `json:"date"`
+	TxID  string `json:"txID"`
+	Notes string `json:"notes"`
+}
+
+// MedicineReturn - Medicine sent back by a customer.
+type MedicineReturn struct {
+	ReturnID     string        `json:"returnID"`
+	MedName      string        `json:"medName"`
+	MedNumber    string        `json:"medNumber"`
+	Customer     string        `json:"customer"`
+	Reason       string        `json:"reason"`
+	PricePaid    string        `json:"pricePaid"`
+	RefundAmount string        `json:"refundAmount"`
+	Events       []ReturnEvent `json:"events"`
+	State        ReturnState   `json:"currentState"`
+}
+
+// DestructionCertificate - Record of the destruction of a medicine.
+type DestructionCertificate struct {
+	CertificateID   string   `json:"certificateID"`
+	MedName         string   `json:"medName"`
+	MedNumber       string   `json:"medNumber"`
+	CheckSum        string   `json:"checkSum"`
+	Reason          string   `json:"reason"`
+	Method          string   `json:"method"`
+	Witnesses       []string `json:"witnesses"`
+	DestructionDate string   `json:"destructionDate"`
+	DocumentHash    string   `json:"documentHash"`
+	RecordedBy      string   `json:"recordedBy"`
+	RecordedAt      string   `json:"recordedAt"`
+}
+
+// QuotaRule - Limit on how much of a medicine, category or schedule a single customer may request in a period.
+type QuotaRule struct {
+	RuleID     string `json:"ruleID"`
+	Scope      string `json:"scope"`
+	Target     string `json:"target"`
+	MaxUnits   int    `json:"maxUnits"`
+	PeriodDays int    `json:"periodDays"`
+}
+
+// QuotaUsage - Units of a quota rule used by a customer.
+type QuotaUsage struct {
+	RuleID   string `json:"ruleID"`
+	Customer string `json:"customer"`
+	Used     int    `json:"used"`
+	MaxUnits int    `json:"maxUnits"`
+}
+
+// InventoryLine - Stock of a medicine name and state, or a total of several.
+type InventoryLine struct {
+	MedName         string `json:"medName,omitempty"`
+	State           string `json:"state,omitempty"`
+	Count           int    `json:"count"`
+	TotalValue      string `json:"totalValue"`
+	Unpriced        int    `json:"unpriced,omitempty"`
+	DistinctHolders int    `json:"distinctHolders"`
+	EarliestExpiry  string `json:"earliestExpiry,omitempty"`
+	LatestExpiry    string `json:"latestExpiry,omitempty"`
+}
+
+// InventoryReport - Stock by medicine name and state, with totals per state and a grand total.
+type InventoryReport struct {
+	GeneratedAt string           `json:"generatedAt"`
+	Lines       []*InventoryLine `json:"lines"`
+	StateTotals []*InventoryLine `json:"stateTotals"`
+	Total       *InventoryLine   `json:"total"`
+}
+
+// ExpiringMedicine - Available medicine which expires soon.
+type ExpiringMedicine struct {
+	MedNumber  string `json:"medNumber"`
+	Expiration string `json:"expiration"`
+	DaysLeft   int    `json:"daysLeft"`
+}
+
+// ExpiryGroup - Available medicine of a single name which expires soon.
+type ExpiryGroup struct {
+	MedName        string              `json:"medName"`
+	Count          int                 `json:"count"`
+	EarliestExpiry string              `json:"earliestExpiry"`
+	Medicines      []*ExpiringMedicine `json:"medicines,omitempty"`
+}
+
+// ExpiryAlert - Alert raised when the amount of stock expiring soon crosses a threshold.
+type ExpiryAlert struct {
+	MedName        string `json:"medName"`
+	Count          int    `json:"count"`
+	Threshold      int    `json:"threshold"`
+	Days           int    `json:"days"`
+	EarliestExpiry string `json:"earliestExpiry"`
+}
+
+// MigrationProgress - Progress of migrating a page of ledger records to the latest schema.
+type MigrationProgress struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	Skipped  int    `json:"skipped"`
+	Bookmark string `json:"bookmark"`
+	Done     bool   `json:"done"`
+}
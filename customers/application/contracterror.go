@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"medical-supply/client"
+)
+
+// Hints shown to the user for every error code.
+var errorHints = map[string]string{
+	client.CodeInvalidArgument:      "Check the values you entered and try again.",
+	client.CodeUnauthenticated:      "Your TPM key is not registered or does not match, check tpmkey.txt.",
+	client.CodeUnauthorizedOrg:      "This function is not available to your organisation.",
+	client.CodeMissingRole:          "Your identity lacks the role this function requires, ask your CA administrator.",
+	client.CodeNotFound:             "Nothing on the ledger matches what you entered, check the names and numbers.",
+	client.CodeAlreadyExists:        "Use another id or number, this one is already in use.",
+	client.CodeAlreadyRequested:     "Somebody else requested this medicine first, pick another one.",
+	client.CodeNotAvailable:         "This medicine can't be requested right now, pick another one.",
+	client.CodeInvalidState:         "The current state does not allow this, check the state in the details.",
+	client.CodeChecksumMismatch:     "The record failed its integrity check and may have been tampered with, report it to MedStore.",
+	client.CodeLotMismatch:          "The scanned pack may be counterfeit, report it to MedStore.",
+	client.CodePrescriptionRequired: "Ask your prescriber for a prescription of this medicine.",
+	client.CodeQuotaExceeded:        "You reached the limit of this medicine for now, try again later.",
+	client.CodeInsufficientStock:    "Order fewer units or try again later.",
+	client.CodeSecondApproval:       "A second regulator has to approve this.",
+	client.CodeLedger:               "The ledger could not be read or written, try again later.",
+	client.CodeInternal:             "Something went wrong in the smart contract, try again later.",
+}
+
+// Describes the error for the user, including the invalid fields and a hint on what to do.
+func describe(ce *client.ContractError) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)", ce.Message, ce.Code)
+	for _, field := range ce.Fields {
+		fmt.Fprintf(&sb, "\n  - %s %s", field.Field, field.Message)
+	}
+	if hint, ok := errorHints[ce.Code]; ok {
+		fmt.Fprintf(&sb, "\n%s", hint)
+	}
+	return sb.String()
+}
+
+// Stops the application after a failed transaction, describing contract errors.
+func failTransaction(err error) {
+	if ce, ok := client.DecodeError(err); ok {
+		log.Fatalf("\nFailed to Submit transaction: %s", describe(ce))
+	}
+	log.Fatalf("\nFailed to Submit transaction: %v", err)
+}
@@ -2,4 +2,20 @@ module medical-supply
 
 go 1.13
 
-require github.com/hyperledger/fabric-sdk-go v1.0.0
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20210718160520-38d29fabecb9
+	github.com/hyperledger/fabric-contract-api-go v1.1.1
+	github.com/hyperledger/fabric-protos-go v0.0.0-20211118165945-23d738fc3553
+	github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode v0.0.0
+	github.com/hyperledger/fabric-sdk-go v1.0.0
+	github.com/prometheus/client_golang v1.1.0
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+// The offline mode runs the chaincode of the organisation in-process.
+replace github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode => ../chaincode
+
+// The chaincode works with the protos of the SDK, newer ones no longer build with the SDK.
+replace github.com/hyperledger/fabric-protos-go => github.com/hyperledger/fabric-protos-go v0.0.0-20200707132912-fee30f3ccd23
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/identity"
+	"medical-supply/profile"
+)
+
+// Usage of the identity commands, which are run instead of the menu when passed as arguments.
+const identityUsage = `Identity commands:
+  wallet list                               List the identities in the wallet and their attributes
+  wallet import <label> <msp folder>        Import an identity from an MSP folder (signcerts and keystore)
+  wallet export <label> <msp folder>        Export an identity to an MSP folder
+  wallet remove <label>                     Remove an identity from the wallet
+  ca enroll <name> <secret> [attribute...]  Enroll an identity with the CA and store it in the wallet,
+                                            requiring the attributes (e.g. role) in its certificate
+  ca register <name> [attribute=value...]   Register an identity on behalf of the -user identity (a registrar
+                                            such as the CA admin) and print its secret, e.g. role=prescriber`
+
+// CA identities are enrolled and registered with, implemented by the CA client and the simulated CA.
+type certificateAuthority interface {
+	Enroll(mspID string, name string, secret string, attributes ...string) (*gateway.X509Identity, error)
+	Register(registrar *gateway.X509Identity, registration identity.Registration) (string, error)
+}
+
+// Runs an identity command on the wallet of the profile, acting as user where the CA requires an enrolled identity.
+// The CA of the profile is used unless another authority is given, e.g. the simulated CA in offline mode.
+func runIdentityCommand(wallet *gateway.Wallet, settings *profile.Profile, authority certificateAuthority, user string, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("unknown command %s\n%s", strings.Join(args, " "), identityUsage)
+	}
+	command, args := args[0]+" "+args[1], args[2:]
+
+	switch {
+	case command == "wallet list" && len(args) == 0:
+		return listIdentities(wallet)
+	case command == "wallet import" && len(args) == 2:
+		err := identity.Import(wallet, args[0], settings.MSPID, args[1])
+		if err == nil {
+			log.Printf("Imported %s from %s", args[0], args[1])
+		}
+		return err
+	case command == "wallet export" && len(args) == 2:
+		err := identity.Export(wallet, args[0], args[1])
+		if err == nil {
+			log.Printf("Exported %s to %s", args[0], args[1])
+		}
+		return err
+	case command == "wallet remove" && len(args) == 1:
+		_, err := identity.Get(wallet, args[0])
+		if err != nil {
+			return err
+		}
+		err = wallet.Remove(args[0])
+		if err == nil {
+			log.Printf("Removed %s from the wallet", args[0])
+		}
+		return err
+	case command == "ca enroll" && len(args) >= 2:
+		ca, err := newCA(settings, authority)
+		if err != nil {
+			return err
+		}
+		enrolled, err := ca.Enroll(settings.MSPID, args[0], args[1], args[2:]...)
+		if err != nil {
+			return err
+		}
+		err = wallet.Put(args[0], enrolled)
+		if err == nil {
+			log.Printf("Enrolled %s, act as this identity with -user %s", args[0], args[0])
+		}
+		return err
+	case command == "ca register" && len(args) >= 1:
+		registration := identity.Registration{Name: args[0], Affiliation: settings.CA.Affiliation}
+		for _, attribute := range args[1:] {
+			pair := strings.SplitN(attribute, "=", 2)
+			if len(pair) != 2 {
+				return fmt.Errorf("invalid attribute %s, expected name=value (e.g. role=prescriber)", attribute)
+			}
+			registration.Attributes = append(registration.Attributes, identity.Attribute{Name: pair[0], Value: pair[1], ECert: true})
+		}
+		registrar, err := identity.Get(wallet, user)
+		if err != nil {
+			return err
+		}
+		ca, err := newCA(settings, authority)
+		if err != nil {
+			return err
+		}
+		secret, err := ca.Register(registrar, registration)
+		if err == nil {
+			log.Printf("Registered %s, enroll it with: ca enroll %s %s", args[0], args[0], secret)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown command %s or wrong number of arguments\n%s", command, identityUsage)
+}
+
+// Returns the authority when given, otherwise creates the client of the CA of the profile.
+func newCA(settings *profile.Profile, authority certificateAuthority) (certificateAuthority, error) {
+	if authority != nil {
+		return authority, nil
+	}
+	if settings.CA.URL == "" {
+		return nil, fmt.Errorf("profile %s has no CA, set ca.url or %sCA_URL", settings.Name, profile.EnvPrefix)
+	}
+	ca, err := identity.NewCA(settings.CA.URL, settings.CA.Name, settings.CA.TLSCert)
+	if err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// Lists the identities in the wallet with their MSP and the attributes of their certificate.
+func listIdentities(wallet *gateway.Wallet) error {
+	labels, err := wallet.List()
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		log.Println("No identities found in wallet.")
+		return nil
+	}
+
+	sort.Strings(labels)
+	for _, label := range labels {
+		id, err := identity.Get(wallet, label)
+		if err != nil {
+			return err
+		}
+		attributes, err := identity.Attributes(id)
+		if err != nil {
+			return fmt.Errorf("identity %s: %w", label, err)
+		}
+		var pairs []string
+		for name, value := range attributes {
+			pairs = append(pairs, name+"="+value)
+		}
+		sort.Strings(pairs)
+		log.Printf("%s (%s) %s", label, id.MspID, strings.Join(pairs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// Package identity enrolls and registers identities with a Fabric CA and manages them in a gateway wallet.
+// It speaks the REST API of the Fabric CA server, so keys are generated locally and never leave the wallet.
+package identity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// CA - Client of a Fabric CA server.
+type CA struct {
+	URL        string
+	Name       string
+	HTTPClient *http.Client
+}
+
+// Attribute - Attribute of a registered identity, e.g. role=prescriber. Attributes with ECert are added to
+// the enrollment certificate by default, which is where the chaincode reads them from.
+type Attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	ECert bool   `json:"ecert,omitempty"`
+}
+
+// Registration - Identity to register, the CA generates a secret when none is set.
+type Registration struct {
+	Name           string
+	Secret         string
+	Type           string
+	Affiliation    string
+	MaxEnrollments int
+	Attributes     []Attribute
+}
+
+// caResponse - Envelope of all responses of the Fabric CA server.
+type caResponse struct {
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Errors  []caMessage     `json:"errors"`
+}
+
+type caMessage struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type enrollmentRequest struct {
+	CertificateRequest string             `json:"certificate_request"`
+	CAName             string             `json:"caname,omitempty"`
+	AttrReqs           []attributeRequest `json:"attr_reqs,omitempty"`
+}
+
+type attributeRequest struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type enrollmentResult struct {
+	Cert string `json:"Cert"`
+}
+
+type registrationRequest struct {
+	Name           string      `json:"id"`
+	Type           string      `json:"type,omitempty"`
+	Secret         string      `json:"secret,omitempty"`
+	MaxEnrollments int         `json:"max_enrollments,omitempty"`
+	Affiliation    string      `json:"affiliation"`
+	Attributes     []Attribute `json:"attrs,omitempty"`
+	CAName         string      `json:"caname,omitempty"`
+}
+
+type registrationResult struct {
+	Secret string `json:"secret"`
+}
+
+// NewCA - Creates a client of the CA at the URL, trusting the TLS certificate in the PEM file when set
+// (e.g. organizations/fabric-ca/org1/tls-cert.pem of the test network).
+func NewCA(url string, name string, tlsCertFile string) (*CA, error) {
+	transport := http.DefaultTransport
+	if tlsCertFile != "" {
+		cert, err := ioutil.ReadFile(filepath.Clean(tlsCertFile))
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cert) {
+			return nil, fmt.Errorf("no certificates found in %s", tlsCertFile)
+		}
+		transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return &CA{URL: strings.TrimSuffix(url, "/"), Name: name, HTTPClient: &http.Client{Transport: transport, Timeout: 30 * time.Second}}, nil
+}
+
+// Enroll - Generates a key pair and enrolls the identity with its secret, returning it as identity of the MSP.
+// The requested attributes are required to be in the certificate.
+func (ca *CA) Enroll(mspID string, name string, secret string, attributes ...string) (*gateway.X509Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: name}}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	request := enrollmentRequest{
+		CertificateRequest: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+		CAName:             ca.Name,
+	}
+	for _, attribute := range attributes {
+		request.AttrReqs = append(request.AttrReqs, attributeRequest{Name: attribute})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result enrollmentResult
+	err = ca.post("enroll", body, func(r *http.Request) error {
+		r.SetBasicAuth(name, secret)
+		return nil
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("could not enroll %s: %w", name, err)
+	}
+	cert, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("could not enroll %s: invalid certificate: %w", name, err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.NewX509Identity(mspID, string(cert), string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))), nil
+}
+
+// Register - Registers a new identity on behalf of the registrar (e.g. the CA admin), returning its enrollment secret.
+func (ca *CA) Register(registrar *gateway.X509Identity, registration Registration) (string, error) {
+	if registration.Type == "" {
+		registration.Type = "client"
+	}
+	body, err := json.Marshal(registrationRequest{
+		Name:           registration.Name,
+		Type:           registration.Type,
+		Secret:         registration.Secret,
+		MaxEnrollments: registration.MaxEnrollments,
+		Affiliation:    registration.Affiliation,
+		Attributes:     registration.Attributes,
+		CAName:         ca.Name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var result registrationResult
+	err = ca.post("register", body, func(r *http.Request) error {
+		token, err := authToken(registrar, r.Method, r.URL.RequestURI(), body)
+		if err != nil {
+			return err
+		}
+		r.Header.Set("Authorization", token)
+		return nil
+	}, &result)
+	if err != nil {
+		return "", fmt.Errorf("could not register %s: %w", registration.Name, err)
+	}
+	return result.Secret, nil
+}
+
+// post - Posts the body to an endpoint of the CA and decodes the result of the response envelope.
+func (ca *CA) post(endpoint string, body []byte, authorize func(*http.Request) error, result interface{}) error {
+	request, err := http.NewRequest(http.MethodPost, ca.URL+"/api/v1/"+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	err = authorize(request)
+	if err != nil {
+		return err
+	}
+
+	response, err := ca.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var envelope caResponse
+	err = json.NewDecoder(response.Body).Decode(&envelope)
+	if err != nil {
+		return fmt.Errorf("invalid response of CA (%s): %w", response.Status, err)
+	}
+	if !envelope.Success {
+		var messages []string
+		for _, message := range envelope.Errors {
+			messages = append(messages, fmt.Sprintf("%s (code %d)", message.Message, message.Code))
+		}
+		if len(messages) == 0 {
+			messages = append(messages, response.Status)
+		}
+		return errors.New(strings.Join(messages, ", "))
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+// authToken - Creates the token the CA authenticates requests of enrolled identities with: the certificate and the
+// signature of the method, URI, body and certificate, all base64 encoded.
+func authToken(identity *gateway.X509Identity, method string, uri string, body []byte) (string, error) {
+	block, _ := pem.Decode([]byte(identity.Key()))
+	if block == nil {
+		return "", errors.New("no private key found in identity")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		// Keys of older wallets are stored as EC private key.
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("invalid private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", errors.New("only ECDSA keys are supported")
+	}
+
+	cert := base64.StdEncoding.EncodeToString([]byte(identity.Certificate()))
+	payload := method + "." + base64.StdEncoding.EncodeToString([]byte(uri)) + "." + base64.StdEncoding.EncodeToString(body) + "." + cert
+	digest := sha256.Sum256([]byte(payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// Fabric only accepts signatures with a low S value.
+	halfOrder := new(big.Int).Rsh(key.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(key.Params().N, s)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return "", err
+	}
+	return cert + "." + base64.StdEncoding.EncodeToString(signature), nil
+}
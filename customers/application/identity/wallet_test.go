@@ -0,0 +1,34 @@
+package identity
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletImportExport(t *testing.T) {
+	_, ca := newStandInCA(t)
+	admin, err := ca.Enroll("Org1MSP", "admin", "adminpw")
+	assert.Nil(t, err, "should enroll the admin")
+
+	wallet := gateway.NewInMemoryWallet()
+	assert.Nil(t, wallet.Put("admin", admin), "should store the admin")
+	mspDir := filepath.Join(t.TempDir(), "msp")
+	assert.Nil(t, Export(wallet, "admin", mspDir), "should export the admin")
+
+	other := gateway.NewInMemoryWallet()
+	assert.Nil(t, Import(other, "ca-admin", "Org1MSP", mspDir), "should import the exported identity")
+	imported, err := Get(other, "ca-admin")
+	assert.Nil(t, err, "should return the imported identity")
+	assert.Equal(t, admin, imported, "should import the certificate and key as exported")
+
+	_, err = Get(other, "admin")
+	assert.EqualError(t, err, "identity admin is not in the wallet, enroll or import it first", "should report missing identities")
+
+	ioutil.WriteFile(filepath.Join(mspDir, "keystore", "other_sk"), []byte("key"), 0600)
+	assert.EqualError(t, Import(other, "ca-admin", "Org1MSP", mspDir), "keystore folder should contain one file",
+		"should not guess which key belongs to the certificate")
+}
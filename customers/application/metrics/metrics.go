@@ -0,0 +1,225 @@
+// Package metrics exports the transactions of the client as Prometheus metrics and serves the health of the
+// application. It serves /metrics, /healthz and /readyz, the health endpoints check that the gateway answers.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"medical-supply/client"
+)
+
+// Health checks.
+const (
+	// CheckTimeout - Time the gateway has to answer a health check.
+	CheckTimeout = 5 * time.Second
+	// GracePeriod - Time the gateway may fail the health checks before the application is reported unhealthy,
+	// until then it's only not ready.
+	GracePeriod = time.Minute
+)
+
+// Latencies of Fabric transactions, from queries of a few milliseconds to commits waiting for a block.
+var buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics - Observer of the transactions of a client, exporting them with the metrics of the Go runtime.
+type Metrics struct {
+	registry            *prometheus.Registry
+	transactions        *prometheus.CounterVec
+	submitDuration      *prometheus.HistogramVec
+	commitDuration      *prometheus.HistogramVec
+	evaluateDuration    *prometheus.HistogramVec
+	endorsementFailures *prometheus.CounterVec
+	mvccConflicts       *prometheus.CounterVec
+	invalid             *prometheus.CounterVec
+	gatewayUp           prometheus.Gauge
+}
+
+// New - Creates the metrics of the application, labelled with the name of the application (e.g. regulators).
+func New(application string) *Metrics {
+	labels := prometheus.Labels{"application": application}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "transactions_total", ConstLabels: labels,
+			Help: "Transactions invoked by the client by function, type (submit or evaluate) and result (success or failure).",
+		}, []string{"function", "type", "result"}),
+		submitDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "submit_duration_seconds", ConstLabels: labels, Buckets: buckets,
+			Help: "Time until submitted transactions returned, whether they were committed or failed.",
+		}, []string{"function"}),
+		commitDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "commit_duration_seconds", ConstLabels: labels, Buckets: buckets,
+			Help: "Time from sending endorsed transactions to the orderer until they were committed to the ledger as valid.",
+		}, []string{"function"}),
+		evaluateDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "evaluate_duration_seconds", ConstLabels: labels, Buckets: buckets,
+			Help: "Time until evaluated queries returned.",
+		}, []string{"function"}),
+		endorsementFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "endorsement_failures_total", ConstLabels: labels,
+			Help: "Submitted transactions which failed before reaching the ledger, by the code of the contract error (OTHER for errors of the network).",
+		}, []string{"function", "code"}),
+		mvccConflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "mvcc_conflicts_total", ConstLabels: labels,
+			Help: "Submitted transactions invalidated by an MVCC read conflict, as another transaction changed the keys they read.",
+		}, []string{"function"}),
+		invalid: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "invalid_transactions_total", ConstLabels: labels,
+			Help: "Submitted transactions which reached the ledger but failed validation, by validation code.",
+		}, []string{"function", "code"}),
+		gatewayUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "medstore", Subsystem: "client", Name: "gateway_up", ConstLabels: labels,
+			Help: "Whether the gateway answered the last health check.",
+		}),
+	}
+	m.registry.MustRegister(m.transactions, m.submitDuration, m.commitDuration, m.evaluateDuration,
+		m.endorsementFailures, m.mvccConflicts, m.invalid, m.gatewayUp,
+		prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	return m
+}
+
+// ObserveTransaction - Counts the transaction and records its latency.
+func (m *Metrics) ObserveTransaction(outcome client.Outcome) {
+	result := "success"
+	if outcome.Err != nil {
+		result = "failure"
+	}
+	seconds := outcome.Duration.Seconds()
+
+	if !outcome.Submitted {
+		m.transactions.WithLabelValues(outcome.Function, "evaluate", result).Inc()
+		m.evaluateDuration.WithLabelValues(outcome.Function).Observe(seconds)
+		return
+	}
+	m.transactions.WithLabelValues(outcome.Function, "submit", result).Inc()
+	m.submitDuration.WithLabelValues(outcome.Function).Observe(seconds)
+
+	switch {
+	case outcome.Err == nil:
+		// The commit is only timed when the transactor reports it, apart from the endorsement.
+		if outcome.ValidationCode == client.ValidationValid {
+			m.commitDuration.WithLabelValues(outcome.Function).Observe(outcome.CommitDuration.Seconds())
+		}
+	case isMVCCConflict(outcome):
+		m.mvccConflicts.WithLabelValues(outcome.Function).Inc()
+		m.invalid.WithLabelValues(outcome.Function, client.ValidationMVCCConflict).Inc()
+	case outcome.ValidationCode != "" && outcome.ValidationCode != client.ValidationValid:
+		m.invalid.WithLabelValues(outcome.Function, outcome.ValidationCode).Inc()
+	default:
+		code := client.ErrorCode(outcome.Err)
+		if code == "" {
+			code = "OTHER"
+		}
+		m.endorsementFailures.WithLabelValues(outcome.Function, code).Inc()
+	}
+}
+
+// isMVCCConflict - Returns whether the transaction was invalidated by a read conflict, according to its validation code
+// or, when the transactor doesn't report commits, its error.
+func isMVCCConflict(outcome client.Outcome) bool {
+	if outcome.ValidationCode != "" {
+		return outcome.ValidationCode == client.ValidationMVCCConflict
+	}
+	return strings.Contains(outcome.Err.Error(), client.ValidationMVCCConflict)
+}
+
+// health - Health of the application, as far as the gateway answers the checks.
+type health struct {
+	check       func(ctx context.Context) error
+	gatewayUp   prometheus.Gauge
+	mu          sync.Mutex
+	lastSuccess time.Time
+}
+
+// probe - Checks the gateway and returns since when it fails, zero when it answered.
+func (h *health) probe(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	err := checkWithin(ctx, h.check)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.lastSuccess = time.Now()
+		h.gatewayUp.Set(1)
+		return 0, nil
+	}
+	h.gatewayUp.Set(0)
+	return time.Since(h.lastSuccess), err
+}
+
+// checkWithin - Runs the check until the context is done, as the gateway can't cancel a query which has been sent.
+func checkWithin(ctx context.Context, check func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("gateway did not answer within %s", CheckTimeout)
+	}
+}
+
+// readyz - Ready while the gateway answers, so no transactions are sent while it's unreachable.
+func (h *health) readyz(w http.ResponseWriter, r *http.Request) {
+	_, err := h.probe(r.Context())
+	if err != nil {
+		http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ready")
+}
+
+// healthz - Healthy unless the gateway failed the checks for longer than the grace period, then restarting the
+// application may help, e.g. when its connection is stuck.
+func (h *health) healthz(w http.ResponseWriter, r *http.Request) {
+	failing, err := h.probe(r.Context())
+	if err != nil && failing > GracePeriod {
+		http.Error(w, fmt.Sprintf("unhealthy: gateway failing for %s: %v", failing.Round(time.Second), err),
+			http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(w, "ok, gateway failing for %s: %v\n", failing.Round(time.Second), err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// Handler - Returns the handler of /metrics, /healthz and /readyz, the health endpoints use the check to find out
+// whether the gateway answers (e.g. Ping of the client).
+func (m *Metrics) Handler(check func(ctx context.Context) error) http.Handler {
+	h := &health{check: check, gatewayUp: m.gatewayUp, lastSuccess: time.Now()}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", h.healthz)
+	mux.HandleFunc("/readyz", h.readyz)
+	return mux
+}
+
+// Serve - Listens on the address and serves the handler in the background, returning the address listened on.
+func (m *Metrics) Serve(address string, check func(ctx context.Context) error) (net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("could not serve metrics on %s: %w", address, err)
+	}
+	server := &http.Server{Handler: m.Handler(check), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Stopped serving metrics: %v", err)
+		}
+	}()
+	return listener.Addr(), nil
+}
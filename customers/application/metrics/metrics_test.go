@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	assert.Nil(t, err, "should read the response")
+	return recorder.Code, string(body)
+}
+
+func TestObserveTransaction(t *testing.T) {
+	m := New("regulators")
+	m.ObserveTransaction(client.Outcome{Function: "Issue", Submitted: true, Duration: 2 * time.Second,
+		TxID: "tx0", ValidationCode: client.ValidationValid, CommitDuration: 500 * time.Millisecond})
+	m.ObserveTransaction(client.Outcome{Function: "TPMKeyGen", Submitted: true, Duration: time.Second})
+	m.ObserveTransaction(client.Outcome{Function: "Request", Submitted: true, Duration: time.Second,
+		Err: &client.ContractError{Code: client.CodeNotAvailable}})
+	m.ObserveTransaction(client.Outcome{Function: "Request", Submitted: true, Duration: 3 * time.Second,
+		TxID: "tx1", ValidationCode: client.ValidationMVCCConflict, Err: errors.New("received invalid transaction")})
+	m.ObserveTransaction(client.Outcome{Function: "ApproveRequest", Submitted: true, Duration: time.Second,
+		TxID: "tx2", ValidationCode: "ENDORSEMENT_POLICY_FAILURE", Err: errors.New("received invalid transaction")})
+	m.ObserveTransaction(client.Outcome{Function: "CheckHistory", Duration: time.Millisecond})
+
+	code, body := get(t, m.Handler(func(ctx context.Context) error { return nil }), "/metrics")
+	assert.Equal(t, http.StatusOK, code, "should serve the metrics")
+	for _, line := range []string{
+		`medstore_client_transactions_total{application="regulators",function="Issue",result="success",type="submit"} 1`,
+		`medstore_client_transactions_total{application="regulators",function="Request",result="failure",type="submit"} 2`,
+		`medstore_client_transactions_total{application="regulators",function="CheckHistory",result="success",type="evaluate"} 1`,
+		`medstore_client_submit_duration_seconds_count{application="regulators",function="Request"} 2`,
+		`medstore_client_submit_duration_seconds_sum{application="regulators",function="Issue"} 2`,
+		`medstore_client_commit_duration_seconds_sum{application="regulators",function="Issue"} 0.5`,
+		`medstore_client_commit_duration_seconds_count{application="regulators",function="Issue"} 1`,
+		`medstore_client_evaluate_duration_seconds_sum{application="regulators",function="CheckHistory"} 0.001`,
+		`medstore_client_endorsement_failures_total{application="regulators",code="NOT_AVAILABLE",function="Request"} 1`,
+		`medstore_client_mvcc_conflicts_total{application="regulators",function="Request"} 1`,
+		`medstore_client_invalid_transactions_total{application="regulators",code="ENDORSEMENT_POLICY_FAILURE",function="ApproveRequest"} 1`,
+		"go_goroutines",
+	} {
+		assert.Contains(t, body, line, "should export the transactions")
+	}
+	assert.NotContains(t, body, `commit_duration_seconds_count{application="regulators",function="Request"}`,
+		"should only record the commit latency of committed transactions")
+	assert.NotContains(t, body, `commit_duration_seconds_count{application="regulators",function="TPMKeyGen"}`,
+		"should not record the commit latency when the transactor does not report the commit")
+}
+
+func TestHealth(t *testing.T) {
+	m := New("regulators")
+	var failure error
+	handler := m.Handler(func(ctx context.Context) error { return failure })
+
+	code, body := get(t, handler, "/readyz")
+	assert.Equal(t, http.StatusOK, code, "should be ready when the gateway answers")
+	assert.Equal(t, "ready\n", body, "should report ready")
+	_, body = get(t, handler, "/metrics")
+	assert.Contains(t, body, `medstore_client_gateway_up{application="regulators"} 1`, "should export the check")
+
+	failure = errors.New("connection refused")
+	code, body = get(t, handler, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code, "should not be ready when the gateway fails")
+	assert.Equal(t, "not ready: connection refused\n", body, "should report the error")
+	code, _ = get(t, handler, "/healthz")
+	assert.Equal(t, http.StatusOK, code, "should stay healthy during the grace period")
+
+	h := &health{check: func(ctx context.Context) error { return failure }, gatewayUp: prometheus.NewGauge(prometheus.GaugeOpts{Name: "up"}),
+		lastSuccess: time.Now().Add(-2 * GracePeriod)}
+	recorder := httptest.NewRecorder()
+	h.healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, "should be unhealthy after the grace period")
+
+	h.check = func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := h.probe(ctx)
+	assert.NotNil(t, err, "should time out when the gateway does not answer")
+}
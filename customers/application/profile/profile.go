@@ -0,0 +1,230 @@
+// Package profile loads the network settings of the applications from a YAML file with named profiles,
+// so the same binary can target development, staging and production networks.
+//
+// Settings are taken from, in order of precedence: environment variables (e.g. MEDSTORE_CHANNEL), the selected
+// profile of the file and the defaults of the application. Relative paths in the file are relative to the file.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix - Prefix of the environment variables overriding settings, MEDSTORE_PROFILE selects the profile.
+const EnvPrefix = "MEDSTORE_"
+
+// CA - Fabric CA of the organisation, identities are enrolled and registered with.
+type CA struct {
+	URL         string `yaml:"url" env:"URL"`
+	Name        string `yaml:"name" env:"NAME"`
+	TLSCert     string `yaml:"tlsCert" env:"TLS_CERT" path:"any"`
+	Affiliation string `yaml:"affiliation" env:"AFFILIATION"`
+}
+
+// Profile - Settings of a network the application connects to. Paths are either required to exist (file) or only
+// needed by some commands (any), e.g. the credentials of the default user which are imported once. Offline is the
+// folder of the simulated ledger the applications share in offline mode. AuditLog is the file the regulators
+// application logs the transactions of its operators in.
+type Profile struct {
+	Name              string `yaml:"-"`
+	MSPID             string `yaml:"mspID" env:"MSP_ID" required:"true"`
+	User              string `yaml:"user" env:"USER" required:"true"`
+	PeerEndpoint      string `yaml:"peerEndpoint" env:"PEER_ENDPOINT"`
+	GatewayPeer       string `yaml:"gatewayPeer" env:"GATEWAY_PEER"`
+	Channel           string `yaml:"channel" env:"CHANNEL" required:"true"`
+	Chaincode         string `yaml:"chaincode" env:"CHAINCODE" required:"true"`
+	ConnectionProfile string `yaml:"connectionProfile" env:"CONNECTION_PROFILE" required:"true" path:"file"`
+	Credentials       string `yaml:"credentials" env:"CREDENTIALS" path:"any"`
+	Wallet            string `yaml:"wallet" env:"WALLET" required:"true" path:"any"`
+	TPMKeys           string `yaml:"tpmKeys" env:"TPM_KEYS" required:"true" path:"any"`
+	Offline           string `yaml:"offline" env:"OFFLINE" path:"any"`
+	AuditLog          string `yaml:"auditLog" env:"AUDIT_LOG" path:"any"`
+	CA                CA     `yaml:"ca" env:"CA_"`
+}
+
+// file - Layout of the configuration file, the profiles are decoded once selected.
+type file struct {
+	Default  string                   `yaml:"default"`
+	Profiles map[string]yaml.MapSlice `yaml:"profiles"`
+}
+
+// setting - A single setting of a profile, found by walking the struct tags.
+type setting struct {
+	name     string
+	env      string
+	path     string
+	required bool
+	value    *string
+}
+
+// Load - Loads the named profile from the file, or the profile selected by MEDSTORE_PROFILE or the default of the file
+// when the name is empty. Without file the defaults are used, unless the file is required (e.g. set by a flag).
+func Load(filename string, name string, required bool, defaults Profile) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv(EnvPrefix + "PROFILE")
+	}
+
+	var loaded *Profile
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	switch {
+	case os.IsNotExist(err) && !required:
+		if name != "" {
+			return nil, fmt.Errorf("profile %s not found, configuration file %s does not exist", name, filename)
+		}
+		defaults.Name = "default"
+		loaded = &defaults
+	case err != nil:
+		return nil, err
+	default:
+		loaded, err = parse(data, name, filepath.Dir(filename), defaults)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %w", filename, err)
+		}
+	}
+
+	for _, s := range settings(loaded) {
+		if value, ok := os.LookupEnv(s.env); ok {
+			*s.value = value
+		}
+	}
+	err = loaded.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// parse - Returns the selected profile of the file, settings missing in the profile fall back to the defaults.
+// Paths in the file are made relative to its folder.
+func parse(data []byte, name string, dir string, defaults Profile) (*Profile, error) {
+	var f file
+	err := yaml.UnmarshalStrict(data, &f)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Profiles) == 0 {
+		return nil, errors.New("no profiles defined")
+	}
+
+	if name == "" {
+		name = f.Default
+	}
+	if name == "" && len(f.Profiles) == 1 {
+		for only := range f.Profiles {
+			name = only
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no profile selected and no default set, choose one of %s", strings.Join(names(f.Profiles), ", "))
+	}
+	node, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found, choose one of %s", name, strings.Join(names(f.Profiles), ", "))
+	}
+
+	// Decoding the profile on top of the defaults only changes the settings of the profile, an empty value included.
+	data, err = yaml.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	var fromFile Profile
+	err = yaml.UnmarshalStrict(data, &fromFile)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	p := defaults
+	err = yaml.UnmarshalStrict(data, &p)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	p.Name = name
+	resolved := settings(&p)
+	for i, s := range settings(&fromFile) {
+		if s.path != "" && *s.value != "" && !filepath.IsAbs(*s.value) {
+			*resolved[i].value = filepath.Join(dir, *s.value)
+		}
+	}
+	return &p, nil
+}
+
+// Validate - Checks that all required settings are set and the files of the profile exist.
+func (p *Profile) Validate() error {
+	var problems []string
+	for _, s := range settings(p) {
+		if *s.value == "" {
+			if s.required {
+				problems = append(problems, fmt.Sprintf("%s is required (%s)", s.name, s.env))
+			}
+			continue
+		}
+
+		if s.path == "file" {
+			info, err := os.Stat(*s.value)
+			if err != nil || info.IsDir() {
+				problems = append(problems, fmt.Sprintf("%s %s does not exist", s.name, *s.value))
+			}
+		}
+	}
+
+	if p.CA.URL != "" {
+		caURL, err := url.Parse(p.CA.URL)
+		if err != nil || (caURL.Scheme != "http" && caURL.Scheme != "https") || caURL.Host == "" {
+			problems = append(problems, fmt.Sprintf("ca.url %s should be an http or https URL", p.CA.URL))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid profile %s: %s", p.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// settings - Returns the settings of the profile in field order, named as in the file.
+func settings(p *Profile) []setting {
+	return walk(reflect.ValueOf(p).Elem(), "", EnvPrefix)
+}
+
+// walk - Returns the settings of the struct, nested structs prefix the names and environment variables of their settings.
+func walk(v reflect.Value, prefix string, envPrefix string) []setting {
+	var result []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		env, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, walk(v.Field(i), prefix+name+".", envPrefix+env)...)
+			continue
+		}
+		result = append(result, setting{
+			name:     prefix + name,
+			env:      envPrefix + env,
+			path:     field.Tag.Get("path"),
+			required: field.Tag.Get("required") == "true",
+			value:    v.Field(i).Addr().Interface().(*string),
+		})
+	}
+	return result
+}
+
+// names - Returns the sorted names of the profiles.
+func names(profiles map[string]yaml.MapSlice) []string {
+	var result []string
+	for name := range profiles {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,169 @@
+package simulator
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+	"medical-supply/identity"
+)
+
+// testContract - Contract reading and writing plain keys, to test the simulator apart from the medical-supply chaincode.
+type testContract struct {
+	contractapi.Contract
+}
+
+func (c *testContract) Put(ctx contractapi.TransactionContextInterface, key string, value string) error {
+	return ctx.GetStub().PutState(key, []byte(value))
+}
+
+func (c *testContract) Get(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	value, err := ctx.GetStub().GetState(key)
+	return string(value), err
+}
+
+func (c *testContract) Delete(ctx contractapi.TransactionContextInterface, key string) error {
+	return ctx.GetStub().DelState(key)
+}
+
+func (c *testContract) PutAndFail(ctx contractapi.TransactionContextInterface, key string, value string) error {
+	err := ctx.GetStub().PutState(key, []byte(value))
+	if err != nil {
+		return err
+	}
+	return errors.New(`{"code":"INVALID_STATE","message":"failed after writing"}`)
+}
+
+func (c *testContract) History(ctx contractapi.TransactionContextInterface, key string) (string, error) {
+	iterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return "", err
+	}
+	defer iterator.Close()
+
+	var versions []string
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return "", err
+		}
+		if modification.IsDelete {
+			versions = append(versions, "deleted")
+		} else {
+			versions = append(versions, string(modification.Value))
+		}
+	}
+	return strings.Join(versions, ","), nil
+}
+
+func (c *testContract) Creator(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", err
+	}
+	role, _, err := ctx.GetClientIdentity().GetAttributeValue("role")
+	return mspID + " " + role, err
+}
+
+func newTestSimulator(t *testing.T, filename string) *Simulator {
+	contract := new(testContract)
+	contract.Name = "test"
+	chaincode, err := contractapi.NewChaincode(contract)
+	assert.Nil(t, err, "should create the chaincode")
+	s, err := New(chaincode, filename)
+	assert.Nil(t, err, "should create the simulator")
+	return s
+}
+
+func newTestContract(t *testing.T, s *Simulator, id *gateway.X509Identity) *Contract {
+	contract, err := s.Contract("test", id)
+	assert.Nil(t, err, "should return the contract")
+	return contract
+}
+
+func TestTransactions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "offline", "ledger.json")
+	id, err := Issue("Org1MSP", "alice", nil)
+	assert.Nil(t, err, "should issue an identity")
+	contract := newTestContract(t, newTestSimulator(t, filename), id)
+
+	_, err = contract.SubmitTransaction("Put", "aspirin", "1")
+	assert.Nil(t, err, "should submit a transaction")
+	_, err = contract.EvaluateTransaction("Put", "aspirin", "2")
+	assert.Nil(t, err, "should evaluate a transaction")
+	value, _ := contract.EvaluateTransaction("Get", "aspirin")
+	assert.Equal(t, "1", string(value), "should discard the writes of evaluated transactions")
+
+	_, err = contract.SubmitTransaction("PutAndFail", "aspirin", "3")
+	assert.EqualError(t, err, `{"code":"INVALID_STATE","message":"failed after writing"}`, "should return the error of the contract")
+	value, _ = contract.EvaluateTransaction("Get", "aspirin")
+	assert.Equal(t, "1", string(value), "should discard the writes of failed transactions")
+
+	_, err = contract.SubmitTransaction("Unknown")
+	assert.Contains(t, err.Error(), "Function Unknown not found in contract test", "should fail unknown transactions")
+
+	// Another simulator on the same file, as of an application of another organisation.
+	other := newTestContract(t, newTestSimulator(t, filename), id)
+	value, _ = other.EvaluateTransaction("Get", "aspirin")
+	assert.Equal(t, "1", string(value), "should store the ledger in the file")
+	_, err = other.SubmitTransaction("Put", "aspirin", "4")
+	assert.Nil(t, err, "should submit a transaction")
+	value, _ = contract.EvaluateTransaction("Get", "aspirin")
+	assert.Equal(t, "4", string(value), "should read the file before every transaction")
+
+	_, commit, err := contract.SubmitAndCommit("Put", "aspirin", "5")
+	assert.Nil(t, err, "should submit a transaction")
+	assert.Len(t, commit.TxID, 64, "should return the ID of the transaction")
+	assert.Equal(t, "VALID", commit.ValidationCode, "should commit the transaction")
+	assert.True(t, commit.Duration > 0, "should time the commit")
+}
+
+func TestHistory(t *testing.T) {
+	id, _ := Issue("Org1MSP", "alice", nil)
+	contract := newTestContract(t, newTestSimulator(t, ""), id)
+
+	contract.SubmitTransaction("Put", "aspirin", "1")
+	contract.SubmitTransaction("Put", "aspirin", "2")
+	contract.SubmitTransaction("Delete", "aspirin")
+	contract.SubmitTransaction("Put", "aspirin", "3")
+	contract.SubmitTransaction("PutAndFail", "aspirin", "4")
+	history, err := contract.EvaluateTransaction("History", "aspirin")
+	assert.Nil(t, err, "should return the history")
+	assert.Equal(t, "3,deleted,2,1", string(history), "should return the committed versions newest first")
+}
+
+func TestCA(t *testing.T) {
+	s := newTestSimulator(t, filepath.Join(t.TempDir(), "ledger.json"))
+	ca := s.CA()
+
+	_, err := ca.Enroll("Org1MSP", "admin", "wrong")
+	assert.EqualError(t, err, "could not enroll admin: authentication failure", "should check the secret")
+	admin, err := ca.Enroll("Org1MSP", "admin", "adminpw")
+	assert.Nil(t, err, "should enroll the bootstrap identity")
+
+	secret, err := ca.Register(admin, identity.Registration{Name: "carol", Attributes: []identity.Attribute{
+		{Name: "role", Value: "prescriber", ECert: true}, {Name: "department", Value: "cardiology"}}})
+	assert.Nil(t, err, "should register an identity")
+	_, err = ca.Register(admin, identity.Registration{Name: "carol"})
+	assert.EqualError(t, err, "could not register carol: identity is already registered", "should reject registered identities")
+
+	_, err = ca.Enroll("Org1MSP", "carol", secret, "ward")
+	assert.EqualError(t, err, "could not enroll carol: attribute ward is not registered", "should require the requested attributes")
+	_, err = ca.Enroll("Org2MSP", "carol", secret)
+	assert.NotNil(t, err, "should register identities in the organisation of the registrar")
+
+	carol, err := ca.Enroll("Org1MSP", "carol", secret, "department")
+	assert.Nil(t, err, "should enroll the registered identity")
+	attributes, err := identity.Attributes(carol)
+	assert.Nil(t, err, "should issue a certificate with attributes")
+	assert.Equal(t, map[string]string{"role": "prescriber", "department": "cardiology"}, attributes,
+		"should add the ECert and requested attributes to the certificate")
+
+	creator, err := newTestContract(t, s, carol).EvaluateTransaction("Creator")
+	assert.Nil(t, err, "should invoke the contract with the identity")
+	assert.Equal(t, "Org1MSP prescriber", string(creator), "should pass the identity to the contract")
+}
@@ -0,0 +1,123 @@
+package simulator
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// stub - World state of a single transaction. The mock stub of the shim is extended with the arguments of the
+// transaction, which it only sets when invoking the chaincode itself, and the history of the keys.
+type stub struct {
+	*shimtest.MockStub
+	args    [][]byte
+	history map[string][]modification
+	written []string
+}
+
+// newStub - Creates the stub of a transaction invoking the function on a copy of the world state of the ledger.
+func newStub(l *ledger, creator []byte, function string, args []string) *stub {
+	s := &stub{MockStub: shimtest.NewMockStub("simulator", nil), args: [][]byte{[]byte(function)}, history: l.History}
+	for _, arg := range args {
+		s.args = append(s.args, []byte(arg))
+	}
+	s.Creator = creator
+
+	// The mock stub keeps the keys sorted for range queries, inserting them one by one is quadratic.
+	keys := make([]string, 0, len(l.State))
+	for key, value := range l.State {
+		s.State[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s.Keys.PushBack(key)
+	}
+	return s
+}
+
+// GetArgs - Returns the function and arguments of the transaction.
+func (s *stub) GetArgs() [][]byte {
+	return s.args
+}
+
+// GetStringArgs - Returns the function and arguments of the transaction as strings.
+func (s *stub) GetStringArgs() []string {
+	var args []string
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+// GetFunctionAndParameters - Returns the function of the transaction and its arguments.
+func (s *stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	return args[0], args[1:]
+}
+
+// PutState - Writes the key, which is added to the history when the transaction is committed.
+func (s *stub) PutState(key string, value []byte) error {
+	err := s.MockStub.PutState(key, value)
+	if err == nil {
+		s.write(key)
+	}
+	return err
+}
+
+// DelState - Deletes the key, which is added to the history when the transaction is committed.
+func (s *stub) DelState(key string) error {
+	err := s.MockStub.DelState(key)
+	if err == nil {
+		s.write(key)
+	}
+	return err
+}
+
+func (s *stub) write(key string) {
+	for _, written := range s.written {
+		if written == key {
+			return
+		}
+	}
+	s.written = append(s.written, key)
+}
+
+// GetQueryResult - Fails like peers using LevelDB, which the chaincode falls back from to reading all keys.
+func (s *stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("ExecuteQuery not supported for leveldb")
+}
+
+// GetHistoryForKey - Returns the committed versions of the key, newest first like Fabric does.
+func (s *stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: s.history[key], next: len(s.history[key]) - 1}, nil
+}
+
+// historyIterator - Iterates over the versions of a key from the last one.
+type historyIterator struct {
+	modifications []modification
+	next          int
+}
+
+func (it *historyIterator) HasNext() bool {
+	return it.next >= 0
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	m := it.modifications[it.next]
+	it.next--
+	return &queryresult.KeyModification{
+		TxId:      m.TxID,
+		Value:     m.Value,
+		Timestamp: &timestamp.Timestamp{Seconds: m.Timestamp.Unix(), Nanos: int32(m.Timestamp.Nanosecond())},
+		IsDelete:  m.IsDelete,
+	}, nil
+}
+
+func (it *historyIterator) Close() error {
+	return nil
+}
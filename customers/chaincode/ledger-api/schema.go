@@ -0,0 +1,73 @@
+package ledgerapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Upcaster - Rewrites the JSON fields of a state from one schema version to the next.
+type Upcaster func(map[string]interface{}) error
+
+// Schema - Defines how the JSON of a state class evolved, Upcasters[i] rewrites version i+1 to version i+2.
+// States written before schema versions were stored are version 1.
+type Schema struct {
+	Upcasters []Upcaster
+}
+
+// Version - Returns the latest schema version, which is the version states are written as.
+func (schema Schema) Version() int {
+	return len(schema.Upcasters) + 1
+}
+
+// SchemaVersionOf - Returns the schema version stored in the JSON of a state.
+func SchemaVersionOf(data []byte) (int, error) {
+	var versioned struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	err := json.Unmarshal(data, &versioned)
+	if err != nil {
+		return 0, err
+	}
+	if versioned.SchemaVersion == 0 {
+		return 1, nil
+	}
+	return versioned.SchemaVersion, nil
+}
+
+// Upcast - Rewrites the JSON of a state to the latest schema version, JSON of the latest version is returned as is.
+// Returns the schema version the state was stored as.
+func (schema Schema) Upcast(data []byte) ([]byte, int, error) {
+	version, err := SchemaVersionOf(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version == schema.Version() {
+		return data, version, nil
+	} else if version > schema.Version() {
+		return nil, version, fmt.Errorf("schema version %d is newer than the supported version %d", version, schema.Version())
+	}
+
+	// Keep numbers as written, floats would lose the precision of large integers.
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&fields)
+	if err != nil {
+		return nil, version, err
+	}
+
+	for v := version; v < schema.Version(); v++ {
+		err = schema.Upcasters[v-1](fields)
+		if err != nil {
+			return nil, version, fmt.Errorf("could not upcast schema version %d: %s", v, err)
+		}
+	}
+	fields["schemaVersion"] = schema.Version()
+
+	upcasted, err := json.Marshal(fields)
+	if err != nil {
+		return nil, version, err
+	}
+	return upcasted, version, nil
+}
@@ -1,33 +1,90 @@
 package ledgerapi
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
-	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 )
 
-// StateListInterface functions that a state list should have.
-type StateListInterface interface {
-	AddState(StateInterface) error
-	GetState(string, StateInterface, string) error
-	GetAllStatesByPartialKey(string) (shim.StateQueryIteratorInterface, error)
-	GetAllStates() (shim.StateQueryIteratorInterface, error)
-	UpdateState(StateInterface) error
+// ErrStateNotFound - Returned when no state is stored under the requested key.
+var ErrStateNotFound = errors.New("No state found")
+
+// StatePagerInterface functions for reading and rewriting the serialized states of a list regardless of their class.
+type StatePagerInterface interface {
+	GetStatesPage(string, int) ([]*queryresult.KV, string, error)
+	PutStateData(string, []byte) error
+}
+
+// StateListInterface functions that a state list of states of type T should have.
+type StateListInterface[T StateInterface] interface {
+	StatePagerInterface
+	AddState(T) error
+	GetState(string) (T, error)
+	ExistsState(string) (bool, error)
+	GetStatesByKeyParts(...string) ([]T, error)
+	QueryStates(string) ([]T, error)
+	GetStateHistory(string) ([]*StateRecord[T], error)
+	UpdateState(T) error
 	DeleteState(string) error
 }
 
+// Factory - Creates a state of type T from its JSON.
+type Factory[T StateInterface] func([]byte) (T, error)
+
+// Factories registered per class, holding a Factory of the type of the class.
+var factories = make(map[string]interface{})
+
+// RegisterFactory - Registers the factory creating the states of a class.
+func RegisterFactory[T StateInterface](class string, factory Factory[T]) {
+	factories[class] = factory
+}
+
+// GetFactory - Returns the factory registered for the class, which has to create states of type T.
+func GetFactory[T StateInterface](class string) (Factory[T], error) {
+	registered, ok := factories[class]
+	if !ok {
+		return nil, fmt.Errorf("no factory registered for class %s", class)
+	}
+	factory, ok := registered.(Factory[T])
+	if !ok {
+		return nil, fmt.Errorf("factory registered for class %s does not create %T", class, *new(T))
+	}
+	return factory, nil
+}
+
+// StateRecord - Defines a version of a state written to the ledger, State is the zero value for a deletion.
+type StateRecord[T StateInterface] struct {
+	TxID      string
+	Timestamp time.Time
+	IsDelete  bool
+	State     T
+}
+
 // StateList useful for managing putting data in and out of the ledger.
-// Implementation of StateListInterface.
-type StateList struct {
-	Ctx             contractapi.TransactionContextInterface
-	Name            string
-	DeserializeJSON func([]byte, StateInterface) error
-	DeserializeTPM  func([]byte, StateInterface) error
+// Implementation of StateListInterface for the states of a single class, lists of several classes can share a Name.
+type StateList[T StateInterface] struct {
+	Ctx     contractapi.TransactionContextInterface
+	Name    string
+	Class   string
+	Factory Factory[T]
+}
+
+// NewStateList - Creates a state list for the class using its registered factory.
+// Panics if no factory creating states of type T has been registered, as that is a programming error.
+func NewStateList[T StateInterface](ctx contractapi.TransactionContextInterface, name string, class string) *StateList[T] {
+	factory, err := GetFactory[T](class)
+	if err != nil {
+		panic(err)
+	}
+	return &StateList[T]{Ctx: ctx, Name: name, Class: class, Factory: factory}
 }
 
 // AddState - Puts state into world state.
-func (sl *StateList) AddState(state StateInterface) error {
+func (sl *StateList[T]) AddState(state T) error {
 	key, _ := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, state.GetSplitKey())
 	data, err := state.Serialize()
 
@@ -39,53 +96,161 @@ func (sl *StateList) AddState(state StateInterface) error {
 }
 
 // GetState - Returns state from world state.
-// Unmarshalls the JSON into passed state.
 // Key is the split key value used in Add/Update joined using a colon
-func (sl *StateList) GetState(key string, state StateInterface, objecttype string) error {
+func (sl *StateList[T]) GetState(key string) (T, error) {
 	ledgerKey, _ := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
 	data, err := sl.Ctx.GetStub().GetState(ledgerKey)
 
 	if err != nil {
-		return err
+		return *new(T), err
 	} else if data == nil {
-		return fmt.Errorf("No state found for %s", key)
+		return *new(T), fmt.Errorf("%w for %s", ErrStateNotFound, key)
 	}
-	if objecttype == "tpmauth" {
-		return sl.DeserializeTPM(data, state)
+	return sl.Factory(data)
+}
+
+// ExistsState - Returns true if a state is stored under the key, without deserializing it.
+func (sl *StateList[T]) ExistsState(key string) (bool, error) {
+	ledgerKey, _ := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
+	data, err := sl.Ctx.GetStub().GetState(ledgerKey)
+	if err != nil {
+		return false, err
 	}
-	return sl.DeserializeJSON(data, state)
+	return data != nil, nil
 }
 
-// GetAllStates - Returns all states matching the partial key from world state.
-func (sl *StateList) GetAllStatesByPartialKey(partialkey string) (shim.StateQueryIteratorInterface, error) {
+// GetStatesByKeyParts - Returns all states whose key starts with the given key parts (e.g. "Prescription", patient).
+func (sl *StateList[T]) GetStatesByKeyParts(keyParts ...string) ([]T, error) {
 	// As composite keys have been used, getStateByRange method won't work because of the \u0000 delimiter hyperledger uses.
-	// Therefore for this implementation GetStateByPartialCompositeKey has been used and for each key "MedStore" string
-	// has been attached for easier retrieval.
-	resultsIterator, err := sl.Ctx.GetStub().GetStateByPartialCompositeKey(sl.Name, []string{"MedStore", partialkey})
+	// Therefore GetStateByPartialCompositeKey is used, which is why every key starts with the kind of state (e.g. "MedStore").
+	resultsIterator, err := sl.Ctx.GetStub().GetStateByPartialCompositeKey(sl.Name, keyParts)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	// Use iterator to loop and return an array of all states.
+	var states []T
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		state, err := sl.Factory(queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// QueryStates - Returns all states matching the rich query, only supported by peers using CouchDB as state database.
+func (sl *StateList[T]) QueryStates(query string) ([]T, error) {
+	resultsIterator, err := sl.Ctx.GetStub().GetQueryResult(query)
 	if err != nil {
 		return nil, err
 	}
+	defer resultsIterator.Close()
+
+	// Use iterator to loop and return an array of all states.
+	var states []T
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
 
-	return resultsIterator, nil
+		state, err := sl.Factory(queryResponse.Value)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
 }
 
-// GetAllStates - Returns all states from world state.
-func (sl *StateList) GetAllStates() (shim.StateQueryIteratorInterface, error) {
-	resultsIterator, err := sl.Ctx.GetStub().GetStateByPartialCompositeKey(sl.Name, []string{"MedStore"})
+// GetStateHistory - Returns every version of the state written to the ledger including deletions, oldest first.
+func (sl *StateList[T]) GetStateHistory(key string) ([]*StateRecord[T], error) {
+	ledgerKey, _ := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
+	resultsIterator, err := sl.Ctx.GetStub().GetHistoryForKey(ledgerKey)
 	if err != nil {
 		return nil, err
 	}
+	defer resultsIterator.Close()
+
+	// Use iterator to loop and return an array of all versions of the state.
+	var records []*StateRecord[T]
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		record := &StateRecord[T]{TxID: modification.TxId, IsDelete: modification.IsDelete}
+		if modification.Timestamp != nil {
+			record.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos)).UTC()
+		}
+		if !modification.IsDelete {
+			record.State, err = sl.Factory(modification.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		records = append(records, record)
+	}
+
+	// The order in which the ledger returns the history is not guaranteed.
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// GetStatesPage - Returns up to pageSize states of the list following the bookmark (a ledger key) in key order,
+// and the bookmark of the next page which is empty after the last page. States of all classes sharing the Name are returned.
+// Pagination queries can't be used by transactions that write, so the states up to the bookmark are skipped instead.
+func (sl *StateList[T]) GetStatesPage(bookmark string, pageSize int) ([]*queryresult.KV, string, error) {
+	if pageSize < 1 {
+		return nil, "", fmt.Errorf("page size should be at least 1, got %d", pageSize)
+	}
+
+	resultsIterator, err := sl.Ctx.GetStub().GetStateByPartialCompositeKey(sl.Name, []string{})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resultsIterator.Close()
+
+	var page []*queryresult.KV
+	for resultsIterator.HasNext() {
+		if len(page) == pageSize {
+			return page, page[len(page)-1].Key, nil
+		}
+
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		if kv.Key > bookmark {
+			page = append(page, kv)
+		}
+	}
+	return page, "", nil
+}
 
-	return resultsIterator, nil
+// PutStateData - Puts already serialized state into world state under its ledger key, e.g. when rewriting states.
+func (sl *StateList[T]) PutStateData(ledgerKey string, data []byte) error {
+	return sl.Ctx.GetStub().PutState(ledgerKey, data)
 }
 
 // UpdateState - Puts state into world state.
-func (sl *StateList) UpdateState(state StateInterface) error {
+func (sl *StateList[T]) UpdateState(state T) error {
 	return sl.AddState(state)
 }
 
 // DeleteState - Deletes state from world state.
-func (sl *StateList) DeleteState(key string) error {
+func (sl *StateList[T]) DeleteState(key string) error {
 	ledgerKey, _ := sl.Ctx.GetStub().CreateCompositeKey(sl.Name, SplitKey(key))
 	return sl.Ctx.GetStub().DelState(ledgerKey)
 }
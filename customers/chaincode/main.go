@@ -10,12 +10,15 @@ import (
 // Main method of the Chaincode (Smart contract).
 // Initialises all important information for when chaincode is packaged and installed on a channel.
 func main() {
-	contract := new(medicalsupply.Contract)
-	contract.TransactionContextHandler = new(medicalsupply.TransactionContext)
-	contract.Name = "org.medstore.medicalsupply"
-	contract.Info.Version = "0.0.1"
-
-	chaincode, err := contractapi.NewChaincode(contract)
+	// Each contract checks the role of the invoker before every transaction.
+	customer := medicalsupply.NewCustomerContract()
+	customer.Info.Version = "0.0.1"
+	regulator := medicalsupply.NewRegulatorContract()
+	regulator.Info.Version = "0.0.1"
+	auth := medicalsupply.NewAuthContract()
+	auth.Info.Version = "0.0.1"
+
+	chaincode, err := contractapi.NewChaincode(customer, regulator, auth)
 
 	if err != nil {
 		panic(fmt.Sprintf("Error creating chaincode. %s", err.Error()))
@@ -0,0 +1,74 @@
+package medicalsupply
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AuthContract - Contract for registering the TPM authentication of users of both organisations.
+type AuthContract struct {
+	contractapi.Contract
+}
+
+// NewAuthContract - Creates the auth contract, which only accepts invokers of the customer and regulator organisations.
+func NewAuthContract() *AuthContract {
+	c := new(AuthContract)
+	c.Name = AuthContractName
+	c.TransactionContextHandler = new(TransactionContext)
+	c.BeforeTransaction = c.beforeTransaction
+	return c
+}
+
+// beforeTransaction - Hook called before every transaction of the contract, rejects invokers of other organisations.
+func (c *AuthContract) beforeTransaction(ctx TransactionContextInterface) error {
+	transaction, params := transactionName(ctx)
+	return c.authorize(ctx, transaction, params)
+}
+
+// authorize - Checks that the invoker may call the transaction with the given arguments.
+func (c *AuthContract) authorize(ctx TransactionContextInterface, transaction string, params []string) error {
+	if _, ok := ruleSets[transaction]; !ok {
+		return newError(CodeInternal, "no rule set for %s", transaction)
+	}
+
+	ciMsp, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return wrapError(CodeUnauthenticated, err, "could not retrieve organisation of the user")
+	}
+	if ciMsp != CustomerMSP && ciMsp != RegulatorMSP {
+		return newError(CodeUnauthorizedOrg, "user from organisation %s, does not have acces to this function", ciMsp).with("mspID", ciMsp)
+	}
+	return nil
+}
+
+// TPMKeyGen - Helper function for generating tpm key to be used for authentication.
+// Only returns key at first creation as calling this function repeatedly would otherwise be exploitable.
+func (c *AuthContract) TPMKeyGen(ctx TransactionContextInterface, user string) (string, error) {
+	// Validate the arguments
+	err := validate("TPMKeyGen", user)
+	if err != nil {
+		return "", err
+	}
+
+	bool := ctx.GetMedicineList().ExistsTPMAuth(user)
+	if bool {
+
+		tpmkey, err := tpmKey()
+		if err != nil {
+			return "", wrapError(CodeInternal, err, "could not generate tpm key")
+		}
+
+		user, err = tpmHash(user)
+		if err != nil {
+			return "", wrapError(CodeInternal, err, "could hash user name")
+		}
+
+		// Create MedicalSupply object.
+		tpmAuth := TPMAuth{Holder: user, TPMKey: tpmkey}
+		err = ctx.GetMedicineList().AddTPMAuth(&tpmAuth)
+		if err != nil {
+			return "", ledgerError(err, "could not add tpm authentication to ledger")
+		}
+		return tpmAuth.TPMKey, nil
+	}
+	return "", newError(CodeAlreadyExists, "user %s has already created a TPM authentication", user)
+}
@@ -0,0 +1,707 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CustomerContract - Contract for the functions of customers and prescribers.
+type CustomerContract struct {
+	contractapi.Contract
+}
+
+// publicTransactions - Transactions of the customer contract which anybody may call without authentication.
+var publicTransactions = map[string]bool{
+	"SearchMedicineByName":   true,
+	"SearchMedicine":         true,
+	"SearchMedicineByGS1":    true,
+	"CheckAvailableMedicine": true,
+}
+
+// NewCustomerContract - Creates the customer contract, whose transactions require an authenticated user unless public.
+func NewCustomerContract() *CustomerContract {
+	c := new(CustomerContract)
+	c.Name = CustomerContractName
+	c.TransactionContextHandler = new(TransactionContext)
+	c.BeforeTransaction = c.beforeTransaction
+	return c
+}
+
+// beforeTransaction - Hook called before every transaction of the contract, authenticates the user.
+func (c *CustomerContract) beforeTransaction(ctx TransactionContextInterface) error {
+	transaction, params := transactionName(ctx)
+	return c.authorize(ctx, transaction, params)
+}
+
+// authorize - Checks that the invoker may call the transaction with the given arguments.
+// IssuePrescription additionally requires the prescriber role.
+func (c *CustomerContract) authorize(ctx TransactionContextInterface, transaction string, params []string) error {
+	if publicTransactions[transaction] {
+		return nil
+	}
+
+	user, tpmkey, err := credentials(transaction, params)
+	if err != nil {
+		return err
+	}
+
+	user, err = tpmHash(user)
+	if err != nil {
+		return wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	if transaction == "IssuePrescription" {
+		// Check prescriber role
+		return isPrescriber(ctx, user, tpmkey)
+	}
+
+	// Checks authentication
+	return tpmCheck(ctx, user, tpmkey)
+}
+
+// Request - Function for handling requested medicine. [Customers]
+func (c *CustomerContract) Request(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("Request", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify that the current holder is MedStore, if that is not the case than the medicine has already been transferred to a different holder.
+	if medicine.Holder != "MedStore" {
+		return nil, newError(CodeAlreadyRequested, "medicine %s:%s has already been bought", medName, medNumber).withMedicine(medicine)
+	}
+
+	// Verify that the current state is AVAILABLE, if so set to REQUESTED.
+	if medicine.IsAvailable() {
+		medicine.SetRequested()
+	} else {
+		return nil, newError(CodeNotAvailable, "medicine %s:%s is currently not available at MedStore", medName, medNumber).withMedicine(medicine)
+	}
+
+	// Verify that change to REQUESTED state has succeeded.
+	if !medicine.IsRequested() {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is not requested. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	// Prescription-only medicine consumes a unit of a matching prescription of the customer.
+	if medicine.RxOnly {
+		prescriptionIDs, err := consumePrescriptions(ctx, user, medicine.MedName, 1)
+		if err != nil {
+			return nil, err
+		}
+		medicine.PrescriptionID = prescriptionIDs[0]
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the customer stays within the quotas.
+	err = checkQuotas(ctx, user, now, medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update medicine holder to be the customer instead of MedStore.
+	medicine.Holder = user
+	medicine.RequestDate = now.Format(time.RFC3339)
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// CancelRequest - Function for handling cancelled requested medicine. [Customers]
+func (c *CustomerContract) CancelRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("CancelRequest", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Medicine reserved for an order can only be cancelled together with the order.
+	if medicine.OrderID != "" {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is part of order %s, cancel the order instead", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+
+	// Check if medicine state is REQUESTED, if so set it to AVAILABLE and reset to holder to be MedStore.
+	if medicine.IsRequested() && medicine.Holder == user {
+		err = restorePrescriptions(ctx, medicine)
+		if err != nil {
+			return nil, err
+		}
+		medicine.SetAvailable()
+		medicine.Holder = "MedStore"
+		medicine.RequestDate = ""
+	} else {
+		return nil, newError(CodeInvalidState, "cannot cancel because medicine has not been requested").withMedicine(medicine)
+	}
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// SearchMedicineByName - Function for getting information on available medicine given the medicine name. [Customers]
+func (c *CustomerContract) SearchMedicineByName(ctx TransactionContextInterface, medName string) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("SearchMedicineByName", medName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicineByName(medName)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+	// Loop through the list and check for AVAILABLE state.
+	var resultlist []*MedicalSupply
+	for _, med := range medicinelist {
+		// skips to next iteration if checksum fails
+		err = med.VerifyChecksum()
+		if err != nil {
+			continue
+		}
+
+		if med.IsAvailable() {
+			resultlist = append(resultlist, med)
+		}
+	}
+	return resultlist, nil
+}
+
+// SearchMedicine - Function for searching available medicine by (the start of) its name or disease, tolerating typos. [Customers]
+// Results are ranked with the best match first.
+func (c *CustomerContract) SearchMedicine(ctx TransactionContextInterface, query string) ([]*SearchResult, error) {
+	// Validate the arguments
+	err := validate("SearchMedicine", query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(Tokenize(query)) == 0 {
+		return nil, fieldError(CodeInvalidArgument, "SearchMedicine", "query", "should contain at least one letter or digit")
+	}
+
+	// Look the query up in the search index.
+	results, err := searchMedicine(ctx.GetMedicineList(), query)
+	if err != nil {
+		return nil, ledgerError(err, "could not search medicine")
+	}
+	return results, nil
+}
+
+// SearchMedicineByGS1 - Function for getting information on a medicine given the scanned GS1 DataMatrix of the pack. [Customers]
+func (c *CustomerContract) SearchMedicineByGS1(ctx TransactionContextInterface, elementString string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("SearchMedicineByGS1", elementString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the element strings of the DataMatrix.
+	data, err := ParseGS1(elementString)
+	if err != nil {
+		return nil, wrapError(CodeInvalidArgument, err, "could not parse GS1 element string")
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := findByGS1(ctx, data.GTIN, data.Serial)
+	if err != nil {
+		return nil, err
+	}
+	if medicine == nil {
+		return nil, newError(CodeNotFound, "no medicine with GTIN %s and serial %s on the ledger", data.GTIN, data.Serial).with("gtin", data.GTIN).with("serial", data.Serial)
+	}
+
+	// A pack whose checksum fails or whose lot differs from the ledger is not genuine.
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+	if data.Lot != "" && data.Lot != medicine.LotNumber {
+		return nil, newError(CodeLotMismatch, "lot %s of the scanned pack does not match lot %s on the ledger", data.Lot, medicine.LotNumber).withMedicine(medicine)
+	}
+	return medicine, nil
+}
+
+// CheckAvailableMedicine - Function for getting an overview of all available medicine. [Customers]
+func (c *CustomerContract) CheckAvailableMedicine(ctx TransactionContextInterface) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("CheckAvailableMedicine")
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all medicine from the ledger (There is currently no efficienter way to retrieve assets from the Ledger for certain fields).
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	// Loop through the list and check for AVAILABLE state.
+	var resultlist []*MedicalSupply
+	for _, med := range medicinelist {
+		// skips to next iteration if checksum fails
+		err = med.VerifyChecksum()
+		if err != nil {
+			continue
+		}
+
+		if med.IsAvailable() {
+			resultlist = append(resultlist, med)
+		}
+	}
+	return resultlist, nil
+}
+
+// CheckUserHistory - Function for getting an overview of all medicine an user has ordered. [Customers]
+func (c *CustomerContract) CheckUserHistory(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("CheckUserHistory", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	// Loop through the list and check for the user (holder).
+	var resultlist []*MedicalSupply
+	for _, med := range medicinelist {
+		// skips to next iteration if checksum fails
+		err = med.VerifyChecksum()
+		if err != nil {
+			continue
+		}
+
+		if med.Holder == user {
+			resultlist = append(resultlist, med)
+		}
+	}
+	return resultlist, nil
+}
+
+// IssuePrescription - Function for issuing a prescription of a medicine to a patient. [Prescribers]
+func (c *CustomerContract) IssuePrescription(ctx TransactionContextInterface, prescriptionID string, patient string, medName string,
+	quantity int, refills int, validFrom string, validUntil string, user string, tpmkey string) (*Prescription, error) {
+	// Validate the arguments
+	err := validate("IssuePrescription", prescriptionID, patient, medName, quantity, refills, validFrom, validUntil, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	from, err := time.Parse(DateLayout, validFrom)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "invalid start date %s, expected format %s", validFrom, DateLayout)
+	}
+	until, err := time.Parse(DateLayout, validUntil)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "invalid end date %s, expected format %s", validUntil, DateLayout)
+	}
+	if until.Before(from) {
+		return nil, newError(CodeInvalidArgument, "prescription cannot end before it starts")
+	}
+
+	// Hash patient name the same way holders are stored.
+	patient, err = tpmHash(patient)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash patient string")
+	}
+
+	// Verify the prescription id is not in use yet.
+	exists, err := ctx.GetMedicineList().ExistsPrescription(patient, medName, prescriptionID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve prescription from ledger")
+	}
+	if exists {
+		return nil, fieldError(CodeAlreadyExists, "IssuePrescription", "prescriptionID", fmt.Sprintf("is already in use by prescription %s", prescriptionID))
+	}
+
+	// Create Prescription object.
+	prescription := Prescription{
+		PrescriptionID: prescriptionID,
+		Prescriber:     user,
+		Patient:        patient,
+		MedName:        strings.ToLower(medName),
+		Quantity:       quantity,
+		Refills:        refills,
+		ValidFrom:      validFrom,
+		ValidUntil:     validUntil,
+	}
+
+	// Add the prescription to the ledger.
+	err = ctx.GetMedicineList().AddPrescription(&prescription)
+	if err != nil {
+		return nil, ledgerError(err, "could not add prescription to the ledger")
+	}
+
+	return &prescription, nil
+}
+
+// PlaceOrder - Function for reserving several medicines at once, either all lines are reserved or none. [Customers]
+// Lines are passed as JSON, e.g. [{"medName":"aspirin","quantity":2},{"medName":"amoxil","quantity":1}].
+func (c *CustomerContract) PlaceOrder(ctx TransactionContextInterface, orderID string, lines string, user string, tpmkey string) (*Order, error) {
+	// Validate the arguments
+	err := validate("PlaceOrder", orderID, lines, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Verify the order id is not in use yet.
+	exists, err := ctx.GetMedicineList().ExistsOrder(orderID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve order from ledger")
+	}
+	if exists {
+		return nil, fieldError(CodeAlreadyExists, "PlaceOrder", "orderID", fmt.Sprintf("is already in use by order %s", orderID))
+	}
+
+	var orderLines []OrderLine
+	err = json.Unmarshal([]byte(lines), &orderLines)
+	if err != nil {
+		return nil, wrapError(CodeInvalidArgument, err, "could not read order lines")
+	}
+	if len(orderLines) == 0 {
+		return nil, newError(CodeInvalidArgument, "order %s has no lines", orderID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Order object.
+	order := Order{OrderID: orderID, Customer: user, OrderDate: now.Format(DateLayout)}
+	order.SetPending()
+
+	// Keep track of ordered medicine names, as writes within a transaction can't be read back.
+	ordered := make(map[string]bool)
+	var requested []*MedicalSupply
+	for _, line := range orderLines {
+		line.MedName = strings.ToLower(line.MedName)
+		if line.Quantity <= 0 {
+			return nil, newError(CodeInvalidArgument, "order line for %s needs a positive quantity", line.MedName)
+		}
+		if ordered[line.MedName] {
+			return nil, newError(CodeInvalidArgument, "order lists %s more than once, combine it into a single line", line.MedName)
+		}
+		ordered[line.MedName] = true
+
+		medicinelist, err := ctx.GetMedicineList().GetAllMedicineByName(line.MedName)
+		if err != nil {
+			return nil, ledgerError(err, "could not retrieve medicine from ledger")
+		}
+		// Reserve the medicine which expires first.
+		sort.Slice(medicinelist, func(i, j int) bool {
+			return medicinelist[i].Expiration < medicinelist[j].Expiration
+		})
+
+		var selected []*MedicalSupply
+		for _, med := range medicinelist {
+			if len(selected) == line.Quantity {
+				break
+			}
+			if !med.IsAvailable() || med.Holder != "MedStore" || med.VerifyChecksum() != nil {
+				continue
+			}
+			selected = append(selected, med)
+		}
+		if len(selected) < line.Quantity {
+			return nil, newError(CodeInsufficientStock, "only %d of %d %s available at MedStore", len(selected), line.Quantity, line.MedName).with("medName", line.MedName).with("available", strconv.Itoa(len(selected)))
+		}
+
+		// Prescription-only medicine consumes units of matching prescriptions of the customer.
+		var rxOnly []*MedicalSupply
+		for _, med := range selected {
+			if med.RxOnly {
+				rxOnly = append(rxOnly, med)
+			}
+		}
+		if len(rxOnly) > 0 {
+			prescriptionIDs, err := consumePrescriptions(ctx, user, line.MedName, len(rxOnly))
+			if err != nil {
+				return nil, err
+			}
+			for i, med := range rxOnly {
+				med.PrescriptionID = prescriptionIDs[i]
+			}
+		}
+
+		line.MedNumbers = nil
+		for _, med := range selected {
+			med.SetRequested()
+			med.Holder = user
+			med.RequestDate = now.Format(time.RFC3339)
+			med.OrderID = orderID
+			err = ctx.GetMedicineList().UpdateMedicine(med)
+			if err != nil {
+				return nil, ledgerError(err, "could not update medicine on the ledger")
+			}
+			line.MedNumbers = append(line.MedNumbers, med.MedNumber)
+		}
+		order.Lines = append(order.Lines, line)
+		requested = append(requested, selected...)
+	}
+
+	// Verify the customer stays within the quotas for the order as a whole.
+	err = checkQuotas(ctx, user, now, requested...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the order to the ledger.
+	err = ctx.GetMedicineList().AddOrder(&order)
+	if err != nil {
+		return nil, ledgerError(err, "could not add order to the ledger")
+	}
+
+	return &order, nil
+}
+
+// CancelOrder - Function for cancelling a pending order, all its medicine becomes available again. [Customers]
+func (c *CustomerContract) CancelOrder(ctx TransactionContextInterface, orderID string, user string, tpmkey string) (*Order, error) {
+	// Validate the arguments
+	err := validate("CancelOrder", orderID, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Retrieve the order from the ledger.
+	order, err := ctx.GetMedicineList().GetOrder(orderID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve order from ledger")
+	}
+
+	if !order.IsPending() || order.Customer != user {
+		return nil, newError(CodeInvalidState, "cannot cancel order %s, current state = %s", orderID, order.GetState()).with("orderID", orderID).with("state", order.GetState().String())
+	}
+	if order.FirstApprover != "" {
+		return nil, newError(CodeInvalidState, "cannot cancel order %s, it has already been approved once", orderID).with("orderID", orderID).with("state", order.GetState().String())
+	}
+
+	err = releaseOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update order on the ledger
+	order.SetCancelled()
+	err = ctx.GetMedicineList().UpdateOrder(order)
+	if err != nil {
+		return nil, ledgerError(err, "could not update order on the ledger")
+	}
+
+	return order, nil
+}
+
+// CheckUserOrders - Function for getting an overview of all orders of an user. [Customers]
+func (c *CustomerContract) CheckUserOrders(ctx TransactionContextInterface, user string, tpmkey string) ([]*Order, error) {
+	// Validate the arguments
+	err := validate("CheckUserOrders", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Get all orders from the ledger.
+	orders, err := ctx.GetMedicineList().GetAllOrders()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any order from ledger")
+	}
+
+	// Loop through the list and check for the user (customer).
+	var resultlist []*Order
+	for _, order := range orders {
+		if order.Customer == user {
+			resultlist = append(resultlist, order)
+		}
+	}
+	return resultlist, nil
+}
+
+// RequestReturn - Function for sending back a medicine which has been send to the customer. [Customers]
+func (c *CustomerContract) RequestReturn(ctx TransactionContextInterface, returnID string, medName string, medNumber string, reason string, user string, tpmkey string) (*MedicineReturn, error) {
+	// Validate the arguments
+	err := validate("RequestReturn", returnID, medName, medNumber, reason, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Verify the return id is not in use yet.
+	exists, err := ctx.GetMedicineList().ExistsReturn(returnID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve return from ledger")
+	}
+	if exists {
+		return nil, fieldError(CodeAlreadyExists, "RequestReturn", "returnID", fmt.Sprintf("is already in use by return %s", returnID))
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only medicine which has been send to the customer can be returned.
+	if !medicine.IsSend() || medicine.Holder != user {
+		return nil, newError(CodeInvalidState, "medicine %s:%s has not been send to you. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create MedicineReturn object.
+	medicineReturn := MedicineReturn{
+		ReturnID:  returnID,
+		MedName:   medicine.MedName,
+		MedNumber: medicine.MedNumber,
+		Customer:  user,
+		Reason:    reason,
+		PricePaid: medicine.Price,
+	}
+	medicineReturn.SetFiled()
+	medicineReturn.AddEvent("RequestReturn", user, now.Format(time.RFC3339), ctx.GetStub().GetTxID(), reason)
+
+	// Set medicine state to RETURNED, it stays with the customer until inspected.
+	medicine.SetReturned()
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	// Add the return to the ledger.
+	err = ctx.GetMedicineList().AddReturn(&medicineReturn)
+	if err != nil {
+		return nil, ledgerError(err, "could not add return to the ledger")
+	}
+
+	return &medicineReturn, nil
+}
+
+// CheckUserReturns - Function for getting an overview of all returns of an user. [Customers]
+func (c *CustomerContract) CheckUserReturns(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicineReturn, error) {
+	// Validate the arguments
+	err := validate("CheckUserReturns", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hashes user string
+	user, err = tpmHash(user)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash user string")
+	}
+
+	// Get all returns from the ledger.
+	returns, err := ctx.GetMedicineList().GetAllReturns()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any return from ledger")
+	}
+
+	// Loop through the list and check for the user (customer).
+	var resultlist []*MedicineReturn
+	for _, medicineReturn := range returns {
+		if medicineReturn.Customer == user {
+			resultlist = append(resultlist, medicineReturn)
+		}
+	}
+	return resultlist, nil
+}
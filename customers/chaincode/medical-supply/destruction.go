@@ -0,0 +1,89 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+// DestructionClass - Class of the certificate of destruction states.
+const DestructionClass = "org.medstore.destruction"
+
+// CreateDestructionKey - Creates a key for the certificate of destruction of a medicine (e.g. Destruction:vicodin:00002).
+func CreateDestructionKey(medName string, medNumber string) string {
+	return ledgerapi.MakeKey("Destruction", medName, medNumber)
+}
+
+type destructionCertificateAlias DestructionCertificate
+type jsonDestructionCertificate struct {
+	*destructionCertificateAlias
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// DestructionCertificate - Defines the evidence that a medicine has been disposed of.
+// CheckSum is the checksum of the medicine at the moment of destruction, DocumentHash optionally refers to a signed off-chain document.
+type DestructionCertificate struct {
+	CertificateID   string   `json:"certificateID"`
+	MedName         string   `json:"medName"`
+	MedNumber       string   `json:"medNumber"`
+	CheckSum        string   `json:"checkSum"`
+	Reason          string   `json:"reason"`
+	Method          string   `json:"method"`
+	Witnesses       []string `json:"witnesses"`
+	DestructionDate string   `json:"destructionDate"`
+	DocumentHash    string   `json:"documentHash"`
+	RecordedBy      string   `json:"recordedBy"`
+	RecordedAt      string   `json:"recordedAt"`
+	class           string   `metadata:"class"`
+	key             string   `metadata:"key"`
+	schemaVersion   int      `metadata:"schemaVersion"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (cert DestructionCertificate) MarshalJSON() ([]byte, error) {
+	jcert := jsonDestructionCertificate{destructionCertificateAlias: (*destructionCertificateAlias)(&cert), Class: DestructionClass, Key: CreateDestructionKey(cert.MedName, cert.MedNumber), SchemaVersion: schemaVersion(DestructionClass)}
+	return json.Marshal(&jcert)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (cert *DestructionCertificate) UnmarshalJSON(data []byte) error {
+	jcert := jsonDestructionCertificate{destructionCertificateAlias: (*destructionCertificateAlias)(cert)}
+
+	err := json.Unmarshal(data, &jcert)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// GetSplitKey - Returns values which should be used to form key.
+func (cert *DestructionCertificate) GetSplitKey() []string {
+	return []string{"Destruction", cert.MedName, cert.MedNumber}
+}
+
+// Serialize - Formats the certificate of destruction as JSON bytes.
+func (cert *DestructionCertificate) Serialize() ([]byte, error) {
+	return json.Marshal(cert)
+}
+
+// DeserializeDestruction - Formats the certificate of destruction from JSON bytes.
+func DeserializeDestruction(bytes []byte, cert *DestructionCertificate) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(DestructionClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, cert)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing certificate of destruction. %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package medicalsupply
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EPCISContext - The JSON-LD context of EPCIS 2.0 documents.
+const EPCISContext = "https://ref.gs1.org/standards/epcis/epcis-context.jsonld"
+
+// MedicineRecord - Defines a single version of a medicine in the history of the ledger, Medicine is nil for deletions.
+type MedicineRecord struct {
+	TxID      string
+	Timestamp time.Time
+	IsDelete  bool
+	Medicine  *MedicalSupply
+}
+
+// EPCISDocument - Defines an EPCIS 2.0 document in JSON-LD.
+type EPCISDocument struct {
+	Context       []string  `json:"@context"`
+	Type          string    `json:"type"`
+	SchemaVersion string    `json:"schemaVersion"`
+	CreationDate  string    `json:"creationDate"`
+	EPCISBody     EPCISBody `json:"epcisBody"`
+}
+
+// EPCISBody - Defines the body of an EPCIS document.
+type EPCISBody struct {
+	EventList []*EPCISEvent `json:"eventList"`
+}
+
+// BizTransaction - Defines a business transaction an event refers to, e.g. the purchase order of a request.
+type BizTransaction struct {
+	Type           string `json:"type"`
+	BizTransaction string `json:"bizTransaction"`
+}
+
+// EPCISSource - Defines the party a medicine is transferred from.
+type EPCISSource struct {
+	Type   string `json:"type"`
+	Source string `json:"source"`
+}
+
+// EPCISDestination - Defines the party a medicine is transferred to.
+type EPCISDestination struct {
+	Type        string `json:"type"`
+	Destination string `json:"destination"`
+}
+
+// EPCISEvent - Defines an EPCIS ObjectEvent or TransactionEvent of a single medicine.
+// Ilmd holds the lot number and expiry date of commissioned medicine.
+type EPCISEvent struct {
+	Type                string             `json:"type"`
+	EventID             string             `json:"eventID"`
+	EventTime           string             `json:"eventTime"`
+	EventTimeZoneOffset string             `json:"eventTimeZoneOffset"`
+	EPCList             []string           `json:"epcList"`
+	Action              string             `json:"action"`
+	BizStep             string             `json:"bizStep"`
+	Disposition         string             `json:"disposition"`
+	BizTransactionList  []BizTransaction   `json:"bizTransactionList,omitempty"`
+	SourceList          []EPCISSource      `json:"sourceList,omitempty"`
+	DestinationList     []EPCISDestination `json:"destinationList,omitempty"`
+	Ilmd                map[string]string  `json:"ilmd,omitempty"`
+	eventTime           time.Time
+}
+
+// NewEPCISDocument - Creates an EPCIS document of the events ordered by event time.
+func NewEPCISDocument(events []*EPCISEvent, creationDate time.Time) *EPCISDocument {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].eventTime.Equal(events[j].eventTime) {
+			return events[i].EventID < events[j].EventID
+		}
+		return events[i].eventTime.Before(events[j].eventTime)
+	})
+	if events == nil {
+		events = []*EPCISEvent{}
+	}
+
+	return &EPCISDocument{
+		Context:       []string{EPCISContext},
+		Type:          "EPCISDocument",
+		SchemaVersion: "2.0",
+		CreationDate:  creationDate.UTC().Format(time.RFC3339),
+		EPCISBody:     EPCISBody{EventList: events},
+	}
+}
+
+// InWindow - Returns true if the event happened within the time window, a zero from or to leaves that side open.
+func (event *EPCISEvent) InWindow(from time.Time, to time.Time) bool {
+	if !from.IsZero() && event.eventTime.Before(from) {
+		return false
+	}
+	if !to.IsZero() && event.eventTime.After(to) {
+		return false
+	}
+	return true
+}
+
+// EPC - Returns the identifier of the medicine used in EPCIS events,
+// a GS1 Digital Link for medicine with a GTIN and a medstore URN otherwise.
+func EPC(ms *MedicalSupply) string {
+	if ms.GTIN != "" {
+		return fmt.Sprintf("https://id.gs1.org/01/%s/21/%s", ms.GTIN, ms.SerialNumber)
+	}
+	return fmt.Sprintf("urn:medstore:medicine:%s:%s", ms.MedName, ms.MedNumber)
+}
+
+// partyURI - Returns the identifier of the holder of a medicine used in source and destination lists.
+func partyURI(holder string) string {
+	return "urn:medstore:party:" + holder
+}
+
+// EPCISEvents - Converts the history of a single medicine (oldest first) into EPCIS events.
+// Issue, request, approval, shipping, return, quarantine, destruction and deletion each result in one or more events.
+func EPCISEvents(records []*MedicineRecord) []*EPCISEvent {
+	var events []*EPCISEvent
+	var previous *MedicalSupply
+	var bizTransaction BizTransaction
+
+	for _, record := range records {
+		newEvent := func(eventType string, action string, bizStep string, disposition string, ms *MedicalSupply) *EPCISEvent {
+			event := &EPCISEvent{
+				Type:                eventType,
+				EventID:             fmt.Sprintf("urn:medstore:event:%s:%s:%s:%s", record.TxID, ms.MedName, ms.MedNumber, bizStep),
+				EventTime:           record.Timestamp.UTC().Format(time.RFC3339),
+				EventTimeZoneOffset: "+00:00",
+				EPCList:             []string{EPC(ms)},
+				Action:              action,
+				BizStep:             bizStep,
+				Disposition:         disposition,
+				eventTime:           record.Timestamp,
+			}
+			if eventType == "TransactionEvent" {
+				event.BizTransactionList = []BizTransaction{bizTransaction}
+			}
+			events = append(events, event)
+			return event
+		}
+
+		// Deleted medicine is decommissioned.
+		if record.IsDelete {
+			if previous != nil {
+				newEvent("ObjectEvent", "DELETE", "decommissioning", "inactive", previous)
+			}
+			previous = nil
+			continue
+		}
+
+		ms := record.Medicine
+		// Issued medicine is commissioned.
+		if previous == nil {
+			event := newEvent("ObjectEvent", "ADD", "commissioning", "active", ms)
+			event.Ilmd = map[string]string{"cbvmda:itemExpirationDate": strings.ReplaceAll(ms.Expiration, ".", "-")}
+			if ms.LotNumber != "" {
+				event.Ilmd["cbvmda:lotNumber"] = ms.LotNumber
+			}
+			previous = ms
+			continue
+		}
+
+		// Only changes of state are events, e.g. a change of holder alone is not.
+		if ms.GetState() == previous.GetState() {
+			previous = ms
+			continue
+		}
+
+		switch ms.GetState() {
+		case REQUESTED:
+			// The request (or the order it is part of) is the purchase order the medicine is reserved for.
+			bizTransaction = BizTransaction{Type: "po", BizTransaction: "urn:medstore:request:" + record.TxID}
+			if ms.OrderID != "" {
+				bizTransaction.BizTransaction = "urn:medstore:order:" + ms.OrderID
+			}
+			newEvent("TransactionEvent", "ADD", "reserving", "reserved", ms)
+		case PENDING_SECOND_APPROVAL:
+			// Scheduled medicine is only accepted once a second regulator has approved it as well, when it is send.
+		case SEND:
+			if previous.IsRequested() || previous.IsPendingSecondApproval() {
+				newEvent("TransactionEvent", "OBSERVE", "accepting", "reserved", ms)
+			}
+			// The customer already holds requested medicine, it is shipped by the MedStore.
+			event := newEvent("ObjectEvent", "OBSERVE", "shipping", "in_transit", ms)
+			event.SourceList = []EPCISSource{{Type: "owning_party", Source: partyURI("MedStore")}}
+			event.DestinationList = []EPCISDestination{{Type: "owning_party", Destination: partyURI(ms.Holder)}}
+		case AVAILABLE:
+			// Rejected and cancelled requests release the reservation, inspected returns are restocked.
+			if previous.IsRequested() || previous.IsPendingSecondApproval() {
+				newEvent("TransactionEvent", "DELETE", "reserving", "sellable_accessible", ms)
+			} else {
+				newEvent("ObjectEvent", "OBSERVE", "stocking", "sellable_accessible", ms)
+			}
+		case RETURNED:
+			// Returned medicine stays with the customer until it has been inspected.
+			event := newEvent("ObjectEvent", "OBSERVE", "shipping", "returned", ms)
+			event.SourceList = []EPCISSource{{Type: "owning_party", Source: partyURI(ms.Holder)}}
+			event.DestinationList = []EPCISDestination{{Type: "owning_party", Destination: partyURI("MedStore")}}
+		case QUARANTINED:
+			newEvent("ObjectEvent", "OBSERVE", "holding", "non_sellable_other", ms)
+		case DESTROYED:
+			newEvent("ObjectEvent", "DELETE", "destroying", "destroyed", ms)
+		}
+		previous = ms
+	}
+	return events
+}
@@ -0,0 +1,74 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractError(t *testing.T) {
+	err := newError(CodeAlreadyRequested, "medicine %s:%s has already been bought", "aspirin", "00001").with("medName", "aspirin")
+	assert.JSONEq(t, `{"code":"ALREADY_REQUESTED","message":"medicine aspirin:00001 has already been bought","details":{"medName":"aspirin"}}`, err.Error(), "should serialize the error as JSON")
+
+	var decoded ContractError
+	assert.Nil(t, json.Unmarshal([]byte(err.Error()), &decoded), "should be decodable by clients")
+	assert.Equal(t, *err, decoded, "should decode to the same error")
+
+	notFound := ledgerError(errors.New("wrapped: "+ledgerapi.ErrStateNotFound.Error()), "could not retrieve medicine from ledger")
+	assert.Equal(t, CodeLedger, notFound.Code, "should only recognise the not found error itself")
+	notFound = ledgerError(ledgerapi.ErrStateNotFound, "could not retrieve medicine from ledger")
+	assert.Equal(t, CodeNotFound, notFound.Code, "should return NOT_FOUND for missing states")
+	assert.Equal(t, "could not retrieve medicine from ledger: No state found", notFound.Message, "should append the cause")
+
+	assert.Equal(t, err, wrapError(CodeInternal, err, "could not request"), "should keep the code of wrapped contract errors")
+	assert.Equal(t, CodeInternal, ErrorCodeOf(errors.New("plain")), "should return INTERNAL for errors without code")
+}
+
+func TestTransactionErrorCodes(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	identity := &fakeIdentity{mspID: "Org2MSP"}
+	ctx.SetClientIdentity(identity)
+	auth := NewAuthContract()
+	customer := NewCustomerContract()
+	regulator := NewRegulatorContract()
+
+	stub.MockTransactionStart("tx1")
+	regulatorKey, _ := auth.TPMKeyGen(ctx, "regulator")
+	customerKey, _ := auth.TPMKeyGen(ctx, "customer")
+	_, err := auth.TPMKeyGen(ctx, "customer")
+	assert.Equal(t, CodeAlreadyExists, ErrorCodeOf(err), "should not register a user twice")
+	_, err = regulator.Issue(ctx, "aspirin", "00001", "pain", "2022.05.09", "$10", false, "", "regulator", regulatorKey)
+	assert.Nil(t, err, "should issue new medicine")
+	stub.MockTransactionEnd("tx1")
+
+	stub.MockTransactionStart("tx2")
+	err = customer.authorize(ctx, "Request", []string{"aspirin", "00001", "customer", "wrong"})
+	assert.Equal(t, CodeUnauthenticated, ErrorCodeOf(err), "should reject a wrong tpm key")
+	_, err = customer.Request(ctx, "aspirin", "00002", "customer", customerKey)
+	assert.Equal(t, CodeNotFound, ErrorCodeOf(err), "should report missing medicine")
+	_, err = customer.Request(ctx, "aspirin", "00001", "customer", customerKey)
+	assert.Nil(t, err, "should request the medicine")
+	_, err = customer.Request(ctx, "aspirin", "00001", "customer", customerKey)
+	assert.Equal(t, CodeAlreadyRequested, ErrorCodeOf(err), "should not request medicine twice")
+	assert.Equal(t, map[string]string{"medName": "aspirin", "medNumber": "00001", "state": "REQUESTED"}, err.(*ContractError).Details, "should add the medicine to the details")
+	stub.MockTransactionEnd("tx2")
+
+	identity.mspID = "Org1MSP"
+	err = regulator.authorize(ctx, "Delete", []string{"aspirin", "00001", "regulator", regulatorKey})
+	assert.Equal(t, CodeUnauthorizedOrg, ErrorCodeOf(err), "should reject regulators functions for customers")
+	assert.Equal(t, "Org1MSP", err.(*ContractError).Details["mspID"], "should add the organisation to the details")
+
+	stub.MockTransactionStart("tx3")
+	medicine, _ := ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	medicine.Price = "$1"
+	ctx.GetMedicineList().UpdateMedicine(medicine)
+	_, err = customer.CancelRequest(ctx, "aspirin", "00001", "customer", customerKey)
+	assert.Equal(t, CodeChecksumMismatch, ErrorCodeOf(err), "should detect tampered medicine")
+	stub.MockTransactionEnd("tx3")
+}
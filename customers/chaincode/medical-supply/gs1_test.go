@@ -0,0 +1,53 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidGTIN(t *testing.T) {
+	assert.True(t, ValidGTIN("09506000134352"), "should accept GTIN-14 with correct check digit.")
+	assert.True(t, ValidGTIN("9506000134352"), "should accept GTIN-13 with correct check digit.")
+	assert.True(t, ValidGTIN("96385074"), "should accept GTIN-8 with correct check digit.")
+	assert.False(t, ValidGTIN("09506000134353"), "should reject wrong check digit.")
+	assert.False(t, ValidGTIN("0950600013435"), "should reject GTIN-13 with wrong check digit.")
+	assert.False(t, ValidGTIN("0950600013435A"), "should reject non-digits.")
+	assert.False(t, ValidGTIN("123"), "should reject invalid length.")
+}
+
+func TestParseGS1Raw(t *testing.T) {
+	data, err := ParseGS1("]d20109506000134352" + "17220228" + "10ABC123" + GS + "2100001")
+	assert.Nil(t, err, "should parse raw scanner input")
+	assert.Equal(t, &GS1Data{GTIN: "09506000134352", Serial: "00001", Lot: "ABC123", Expiration: "2022.02.28"}, data, "should return element strings")
+
+	data, err = ParseGS1(GS + "2100001" + GS + "0109506000134352" + "10ABC123")
+	assert.Nil(t, err, "should parse element strings in any order")
+	assert.Equal(t, &GS1Data{GTIN: "09506000134352", Serial: "00001", Lot: "ABC123"}, data, "should return element strings without expiry")
+}
+
+func TestParseGS1Bracketed(t *testing.T) {
+	data, err := ParseGS1("(01)09506000134352(17)240200(10)ABC123(21)00001")
+	assert.Nil(t, err, "should parse human readable form")
+	assert.Equal(t, &GS1Data{GTIN: "09506000134352", Serial: "00001", Lot: "ABC123", Expiration: "2024.02.29"}, data, "should use the last day of the month for day 00")
+}
+
+func TestParseGS1Errors(t *testing.T) {
+	_, err := ParseGS1("0109506000134353" + "2100001")
+	assert.EqualError(t, err, "GTIN 09506000134353 has an invalid check digit", "should validate the check digit")
+
+	_, err = ParseGS1("0109506000134352")
+	assert.EqualError(t, err, "GS1 element string should contain at least a GTIN (01) and serial number (21)", "should require a serial number")
+
+	_, err = ParseGS1("0109506000134352" + "11220101" + "2100001")
+	assert.EqualError(t, err, "unsupported application identifier 11", "should reject unsupported application identifiers")
+
+	_, err = ParseGS1("0109506000134352" + "17221301" + "2100001")
+	assert.EqualError(t, err, "invalid GS1 date 221301, expected YYMMDD", "should reject invalid dates")
+
+	_, err = ParseGS1("0109506000134352" + "2100001" + GS + "2100002")
+	assert.EqualError(t, err, "application identifier 21 occurs more than once", "should reject repeated application identifiers")
+
+	_, err = ParseGS1("(01)0950600013435(21)00001")
+	assert.EqualError(t, err, "value of application identifier 01 should be 14 characters", "should check fixed lengths")
+}
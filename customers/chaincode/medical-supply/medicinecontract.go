@@ -1,547 +1,344 @@
 package medicalsupply
 
 import (
-	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+)
 
-	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+// Names of the contracts of the chaincode, clients prefix the transaction names with them (e.g. org.medstore.customer:Request).
+const (
+	CustomerContractName  = "org.medstore.customer"
+	RegulatorContractName = "org.medstore.regulator"
+	AuthContractName      = "org.medstore.auth"
 )
 
-type Contract struct {
-	contractapi.Contract
-}
+// Organisations of the network.
+const (
+	CustomerMSP  = "Org1MSP"
+	RegulatorMSP = "Org2MSP"
+)
 
-func (c *Contract) Instantiate() {
-	fmt.Println("- Contract Instantiated -")
+// transactionName - Helper function for getting the name of the invoked transaction without its contract name, and its arguments.
+func transactionName(ctx TransactionContextInterface) (string, []string) {
+	fn, params := ctx.GetStub().GetFunctionAndParameters()
+	return fn[strings.LastIndex(fn, ":")+1:], params
 }
 
-// TPMKeyGen - Helper function for generating tpm key to be used for authentication.
-// Only returns key at first creation as calling this function repeatedly would otherwise be exploitable.
-func (c *Contract) TPMKeyGen(ctx TransactionContextInterface, user string) (string, error) {
-	bool := ctx.GetMedicineList().ExistsTPMAuth(user)
-	if bool {
-
-		tpmkey, err := tpmKey()
-		if err != nil {
-			return "", fmt.Errorf("could not generate tpm key: %s", err)
-		}
-
-		user, err = tpmHash(user)
-		if err != nil {
-			return "", fmt.Errorf("could hash user name: %s", err)
-		}
+// credentials - Helper function for getting the user and tpm key of a transaction, which are its last two arguments.
+// Fails for transactions without them, so a transaction can't skip authentication by leaving them out.
+func credentials(transaction string, params []string) (string, string, error) {
+	ruleSet := ruleSets[transaction]
+	n := len(ruleSet)
+	if n < 2 || ruleSet[n-2].Field != "user" || ruleSet[n-1].Field != "tpmkey" {
+		return "", "", newError(CodeInternal, "no user and tpm key in the arguments of %s", transaction)
+	}
+	if len(params) != n {
+		return "", "", newError(CodeInvalidArgument, "%s takes %d arguments, got %d", transaction, n, len(params))
+	}
 
-		// Create MedicalSupply object.
-		tpmAuth := TPMAuth{Holder: user, TPMKey: tpmkey}
-		err = ctx.GetMedicineList().AddTPMAuth(&tpmAuth)
-		if err != nil {
-			return "", fmt.Errorf("could not add tpm authentication to ledger: %s", err)
-		}
-		return tpmAuth.TPMKey, nil
+	// Validate the credentials, as they are checked before the transaction validates its arguments
+	err := validateFields(transaction, ruleSet[n-2:], params[n-2], params[n-1])
+	if err != nil {
+		return "", "", err
 	}
-	return "", fmt.Errorf("user %s has already created a TPM authentication", user)
+	return params[n-2], params[n-1], nil
 }
 
 // tpmCheck - Helper function for verifying authentication
-func (c *Contract) tpmCheck(ctx TransactionContextInterface, user string, tpmkey string) error {
+func tpmCheck(ctx TransactionContextInterface, user string, tpmkey string) error {
 	valid, err := ctx.GetMedicineList().VerifyTPMAuth(user, tpmkey)
 	if err != nil {
-		return fmt.Errorf("user has not authenticated yet. Please invoke TPMKeyGen first: %s", err)
+		return wrapError(CodeUnauthenticated, err, "user has not authenticated yet. Please invoke TPMKeyGen first")
 	}
 	if !valid {
-		return fmt.Errorf("provided tpm key does not match with registered authentication")
+		return newError(CodeUnauthenticated, "provided tpm key does not match with registered authentication")
 	}
 	return nil
 }
 
 // hasAuthority - Helper function for verifying the invoker organisation.
-func (c *Contract) hasAuthority(ctx TransactionContextInterface, user string, tpmkey string) error {
+func hasAuthority(ctx TransactionContextInterface, user string, tpmkey string) error {
 	// Check if user is authenticated
-	err := c.tpmCheck(ctx, user, tpmkey)
+	err := tpmCheck(ctx, user, tpmkey)
 	if err != nil {
 		return err
 	}
 
 	ciMsp, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return err
+		return wrapError(CodeUnauthenticated, err, "could not retrieve organisation of the user")
 	}
-	if ciMsp != "Org2MSP" {
-		return fmt.Errorf("user from organisation %s, does not have acces to this function", ciMsp)
+	if ciMsp != RegulatorMSP {
+		return newError(CodeUnauthorizedOrg, "user from organisation %s, does not have acces to this function", ciMsp).with("mspID", ciMsp)
 	}
 	return nil
 }
 
-// InitLedger - Adds a base set of medicine (MedicalSupply) to the ledger. [Regulators]
-func (c *Contract) InitLedger(ctx TransactionContextInterface, user string, tpmkey string) error {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
+// isPrescriber - Helper function for verifying the invoker has the prescriber role.
+func isPrescriber(ctx TransactionContextInterface, user string, tpmkey string) error {
+	// Check if user is authenticated
+	err := tpmCheck(ctx, user, tpmkey)
 	if err != nil {
 		return err
 	}
 
-	// Create array of MedicalSupply objects.
-	medicines := []MedicalSupply{
-		{MedName: "aspirin", MedNumber: "00001", Disease: "pain management", Expiration: "2022.05.09", Price: "$10", Holder: "MedStore"},
-		{MedName: "vicodin", MedNumber: "00002", Disease: "pain management", Expiration: "2022.07.01", Price: "$14", Holder: "MedStore"},
-		{MedName: "synthroid", MedNumber: "00003", Disease: "thyroid deficiency", Expiration: "2021.12.03", Price: "$11", Holder: "MedStore"},
-		{MedName: "delasone", MedNumber: "00004", Disease: "arthritis", Expiration: "2022.09.12", Price: "$5", Holder: "MedStore"},
-		{MedName: "amoxil", MedNumber: "00005", Disease: "bacterial infections", Expiration: "2022.07.08", Price: "$9", Holder: "MedStore"},
-		{MedName: "neurontin", MedNumber: "00006", Disease: "seizures", Expiration: "2022.03.25", Price: "$13", Holder: "MedStore"},
-		{MedName: "zestril", MedNumber: "00007", Disease: "blood pressure", Expiration: "2022.03.11", Price: "$7", Holder: "MedStore"},
-		{MedName: "lipitor", MedNumber: "00008", Disease: "high cholesterol", Expiration: "2022.01.06", Price: "$12", Holder: "MedStore"},
-		{MedName: "glucophage", MedNumber: "00009", Disease: "type 2 diabetes", Expiration: "2022.04.24", Price: "$8", Holder: "MedStore"},
-		{MedName: "zofran", MedNumber: "00010", Disease: "fever", Expiration: "2022.02.04", Price: "$13", Holder: "MedStore"},
-		{MedName: "ibuprofen", MedNumber: "00011", Disease: "fever", Expiration: "2022.02.28", Price: "$12", Holder: "MedStore"},
-	}
-
-	// For each medicine, set it's state to Available, calculate the checksum and update the ledger
-	for _, med := range medicines {
-		med.SetAvailable()
-		med.InitialiseChecksum()
-		err := ctx.GetMedicineList().UpdateMedicine(&med)
-
-		if err != nil {
-			return fmt.Errorf("failed to put to world state. %s", err.Error())
-		}
+	err = ctx.GetClientIdentity().AssertAttributeValue("role", "prescriber")
+	if err != nil {
+		return wrapError(CodeMissingRole, err, "user does not have the prescriber role").with("role", "prescriber")
 	}
-
 	return nil
 }
 
-// Issue - Function for handling issued medicine [Regulators]
-func (c *Contract) Issue(ctx TransactionContextInterface, medname string, mednumber string,
-	disease string, expiration string, price string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create MedicalSupply object.
-	medicine := MedicalSupply{
-		MedName:    strings.ToLower(medname),
-		MedNumber:  mednumber,
-		Disease:    strings.ToLower(disease),
-		Expiration: expiration,
-		Price:      price,
-		Holder:     "MedStore",
-	}
-
-	// Calculate the checksum by using the hashfunction of the TPM.
-	err = medicine.InitialiseChecksum()
-	if err != nil {
-		return nil, fmt.Errorf("could not issue new MedicalSupply. %s", err)
-	}
-
-	// Set state to AVAILABLE.
-	medicine.SetAvailable()
-
-	// Add the medicine to the ledger.
-	err = ctx.GetMedicineList().AddMedicine(&medicine)
+// approverID - Helper function for getting the identity of the invoking regulator, used to tell approvals apart.
+func approverID(ctx TransactionContextInterface) (string, error) {
+	id, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
-		return nil, fmt.Errorf("could not add medicine to the ledger: %s", err)
+		return "", wrapError(CodeInternal, err, "could not retrieve client identity")
 	}
-
-	return &medicine, nil
+	return id, nil
 }
 
-// Delete - Function for handling medicine removal. [Regulators]
-func (c *Contract) Delete(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) error {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+// txTime - Helper function for getting the timestamp of the current transaction.
+func txTime(ctx TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	if medicine == nil {
-		return fmt.Errorf("medicine does not exist, can't delete from ledger")
+		return time.Time{}, wrapError(CodeInternal, err, "could not retrieve transaction timestamp")
 	}
-	return ctx.GetMedicineList().DeleteMedicine(medName, medNumber)
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
 }
 
-// Request - Function for handling requested medicine. [Customers]
-func (c *Contract) Request(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Hashes user string
-	user, err := tpmHash(user)
-	if err != nil {
-		return nil, fmt.Errorf("cannot hash user string: %s", err)
-	}
-
-	// Checks authentication
-	err = c.tpmCheck(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	// Checksum check
-	err = medicine.VerifyChecksum()
-	if err != nil {
-		return nil, err
-	}
+// ordered - Types of the keys results are sorted by.
+type ordered interface {
+	~int | ~int64 | ~uint | ~float64 | ~string
+}
 
-	// Verify that the current holder is MedStore, if that is not the case than the medicine has already been transferred to a different holder.
-	if medicine.Holder != "MedStore" {
-		return nil, fmt.Errorf("medicine %s:%s has already been bought", medName, medNumber)
-	}
+// sortKey - Compares two results by a single key, negative when a goes first.
+type sortKey[T any] func(a T, b T) int
 
-	// Verify that the current state is AVAILABLE, if so set to REQUESTED.
-	if medicine.IsAvailable() {
-		medicine.SetRequested()
-	} else {
-		return nil, fmt.Errorf("medicine %s:%s is currently not available at MedStore", medName, medNumber)
+// ascending - Sorts results by the key, smallest first.
+func ascending[T any, K ordered](key func(T) K) sortKey[T] {
+	return func(a T, b T) int {
+		return compare(key(a), key(b))
 	}
+}
 
-	// Verify that change to REQUESTED state has succeeded.
-	if !medicine.IsRequested() {
-		return nil, fmt.Errorf("medicine %s:%s is not requested. current state = %s", medName, medNumber, medicine.GetState())
+// descending - Sorts results by the key, largest first.
+func descending[T any, K ordered](key func(T) K) sortKey[T] {
+	return func(a T, b T) int {
+		return compare(key(b), key(a))
 	}
+}
 
-	// Update medicine holder to be the customer instead of MedStore.
-	medicine.Holder = user
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
+// compare - Returns -1, 0 or 1 when a is smaller than, equal to or larger than b.
+func compare[K ordered](a K, b K) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
 	}
-
-	return medicine, nil
+	return 0
 }
 
-// CancelRequest - Function for handling cancelled requested medicine. [Customers]
-func (c *Contract) CancelRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Hashes user string
-	user, err := tpmHash(user)
-	if err != nil {
-		return nil, fmt.Errorf("cannot hash user string: %s", err)
-	}
+// sortResults - Helper function for sorting the results of a transaction by the keys, the first key in which two
+// results differ decides. Results collected from maps are in random order, but every peer has to endorse the same
+// result, so the keys together have to tell all results apart.
+func sortResults[T any](results []T, keys ...sortKey[T]) {
+	sort.Slice(results, func(i, j int) bool {
+		for _, key := range keys {
+			if order := key(results[i], results[j]); order != 0 {
+				return order < 0
+			}
+		}
+		return false
+	})
+}
 
-	// Checks authentication
-	err = c.tpmCheck(ctx, user, tpmkey)
+// consumePrescriptions - Helper function for dispensing units from valid prescriptions of the patient.
+// Prescriptions which expire first are used first. Returns the prescription id used for every unit.
+func consumePrescriptions(ctx TransactionContextInterface, patient string, medName string, units int) ([]string, error) {
+	now, err := txTime(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	// Checksum check
-	err = medicine.VerifyChecksum()
+	prescriptions, err := ctx.GetMedicineList().GetPrescriptionsByPatient(patient, medName)
 	if err != nil {
-		return nil, err
-	}
-
-	// Check if medicine state is REQUESTED, if so set it to AVAILABLE and reset to holder to be MedStore.
-	if medicine.IsRequested() && medicine.Holder == user {
-		medicine.SetAvailable()
-		medicine.Holder = "MedStore"
-	} else {
-		return nil, fmt.Errorf("cannot cancel because medicine has not been requested")
+		return nil, ledgerError(err, "could not retrieve prescriptions from ledger")
 	}
+	sort.Slice(prescriptions, func(i, j int) bool {
+		return prescriptions[i].ValidUntil < prescriptions[j].ValidUntil
+	})
 
-	// Update medicine on the ledger
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
-	}
-
-	return medicine, nil
-}
-
-// SearchMedicineByName - Function for getting information on available medicine given the medicine name. [Customers]
-func (c *Contract) SearchMedicineByName(ctx TransactionContextInterface, medName string) ([]*MedicalSupply, error) {
-	// Retrieve the medicine from the ledger.
-	medicinelist, err := ctx.GetMedicineList().GetAllMedicineByName(medName)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-	// Loop through the list and check for AVAILABLE state.
-	var resultlist []*MedicalSupply
-	for _, med := range medicinelist {
-		// skips to next iteration if checksum fails
-		err = med.VerifyChecksum()
-		if err != nil {
+	// Dispense in memory first, as writes within a transaction can't be read back.
+	var used []string
+	var changed []*Prescription
+	for _, rx := range prescriptions {
+		if len(used) == units {
+			break
+		}
+		if !rx.IsValidAt(now) || rx.Remaining() <= 0 {
 			continue
 		}
-
-		if med.IsAvailable() {
-			resultlist = append(resultlist, med)
+		for len(used) < units && rx.Dispense() == nil {
+			used = append(used, rx.PrescriptionID)
 		}
+		changed = append(changed, rx)
 	}
-	return resultlist, nil
-}
-
-// CheckHistory - Function for getting an overview of all Medicine. [Regulators]
-func (c *Contract) CheckHistory(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
+	if len(used) < units {
+		return nil, newError(CodePrescriptionRequired, "medicine %s requires a valid prescription for %d unit(s)", medName, units).with("medName", medName).with("units", strconv.Itoa(units))
 	}
 
-	// Get all medicine from the ledger.
-	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve query any medicine from ledger: %s", err)
+	for _, rx := range changed {
+		err = ctx.GetMedicineList().UpdatePrescription(rx)
+		if err != nil {
+			return nil, ledgerError(err, "could not update prescription on the ledger")
+		}
 	}
-	return medicinelist, nil
+	return used, nil
 }
 
-// CheckAvailableMedicine - Function for getting an overview of all available medicine. [Customers]
-func (c *Contract) CheckAvailableMedicine(ctx TransactionContextInterface) ([]*MedicalSupply, error) {
-	// Get all medicine from the ledger (There is currently no efficienter way to retrieve assets from the Ledger for certain fields).
-	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
-	if err != nil {
-		return nil, fmt.Errorf("could not query any medicine from ledger: %s", err)
-	}
-
-	// Loop through the list and check for AVAILABLE state.
-	var resultlist []*MedicalSupply
-	for _, med := range medicinelist {
-		// skips to next iteration if checksum fails
-		err = med.VerifyChecksum()
-		if err != nil {
+// restorePrescriptions - Helper function for returning the units dispensed for medicine to their prescriptions.
+// Must be called while the customer is still the holder of the medicine.
+func restorePrescriptions(ctx TransactionContextInterface, medicines ...*MedicalSupply) error {
+	// Collect restores per prescription, as writes within a transaction can't be read back.
+	restored := make(map[string]*Prescription)
+	var keys []string
+	for _, medicine := range medicines {
+		if medicine.PrescriptionID == "" {
 			continue
 		}
 
-		if med.IsAvailable() {
-			resultlist = append(resultlist, med)
+		key := CreatePrescriptionKey(medicine.Holder, medicine.MedName, medicine.PrescriptionID)
+		prescription, ok := restored[key]
+		if !ok {
+			var err error
+			prescription, err = ctx.GetMedicineList().GetPrescription(medicine.Holder, medicine.MedName, medicine.PrescriptionID)
+			if err != nil {
+				return ledgerError(err, "could not retrieve prescription from ledger")
+			}
+			restored[key] = prescription
+			keys = append(keys, key)
 		}
-	}
-	return resultlist, nil
-}
-
-// CheckRequestedMedicine - Function for getting an overview of all requested medicine. [Regulators]
-func (c *Contract) CheckRequestedMedicine(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get all medicine from the ledger.
-	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
-	if err != nil {
-		return nil, fmt.Errorf("could not query any medicine from ledger: %s", err)
+		prescription.Restore()
+		medicine.PrescriptionID = ""
 	}
 
-	// Loop through the list and check for REQUESTED state.
-	var resultlist []*MedicalSupply
-	for _, med := range medicinelist {
-		// skips to next iteration if checksum fails
-		err = med.VerifyChecksum()
+	for _, key := range keys {
+		err := ctx.GetMedicineList().UpdatePrescription(restored[key])
 		if err != nil {
-			continue
-		}
-
-		if med.IsRequested() {
-			resultlist = append(resultlist, med)
+			return ledgerError(err, "could not update prescription on the ledger")
 		}
 	}
-	return resultlist, nil
+	return nil
 }
 
-// CheckUserHistory - Function for getting an overview of all medicine an user has ordered. [Customers]
-func (c *Contract) CheckUserHistory(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
-	// Hashes user string
-	user, err := tpmHash(user)
+// checkQuotas - Helper function for verifying that requesting medicine keeps the customer within all quota rules.
+// Usage is computed from the medicine the customer holds and the transaction timestamps it was requested at.
+func checkQuotas(ctx TransactionContextInterface, customer string, now time.Time, requested ...*MedicalSupply) error {
+	rules, err := ctx.GetMedicineList().GetAllQuotas()
 	if err != nil {
-		return nil, fmt.Errorf("cannot hash user string: %s", err)
+		return ledgerError(err, "could not retrieve quota rules from ledger")
 	}
-
-	// Checks authentication
-	err = c.tpmCheck(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
+	if len(rules) == 0 {
+		return nil
 	}
 
-	// Get all medicine from the ledger.
 	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
 	if err != nil {
-		return nil, fmt.Errorf("could not query any medicine from ledger: %s", err)
+		return ledgerError(err, "could not query any medicine from ledger")
 	}
 
-	// Loop through the list and check for the user (holder).
-	var resultlist []*MedicalSupply
-	for _, med := range medicinelist {
-		// skips to next iteration if checksum fails
-		err = med.VerifyChecksum()
-		if err != nil {
+	for _, rule := range rules {
+		requestedUnits := 0
+		for _, med := range requested {
+			if rule.Matches(med) {
+				requestedUnits++
+			}
+		}
+		if requestedUnits == 0 {
 			continue
 		}
 
-		if med.Holder == user {
-			resultlist = append(resultlist, med)
+		used := 0
+		for _, med := range medicinelist {
+			if med.Holder == customer && rule.Matches(med) && rule.InPeriod(med.RequestDate, now) {
+				used++
+			}
+		}
+		if used+requestedUnits > rule.MaxUnits {
+			return newError(CodeQuotaExceeded, "request exceeds quota: %s", rule.Explain(used, requestedUnits)).with("ruleID", rule.RuleID)
 		}
 	}
-	return resultlist, nil
+	return nil
 }
 
-// ApproveRequest - Function for handling approving the medicine by changing its state to SEND. [Regulators]
-func (c *Contract) ApproveRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	// Checksum check
-	err = medicine.VerifyChecksum()
+// findByGS1 - Helper function for finding the medicine with the given GTIN and serial number, returns nil if there is none.
+func findByGS1(ctx TransactionContextInterface, gtin string, serial string) (*MedicalSupply, error) {
+	// Get all medicine from the ledger (There is currently no efficienter way to retrieve assets from the Ledger for certain fields).
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
 	if err != nil {
-		return nil, err
-	}
-
-	// Check if medicine state is REQUESTED, if so set it to SEND.
-	if medicine.IsRequested() {
-		medicine.SetSend()
-	} else {
-		return nil, fmt.Errorf("cannot approve medicine that has not been requested")
+		return nil, ledgerError(err, "could not query any medicine from ledger")
 	}
 
-	// Update medicine on the ledger
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
+	for _, med := range medicinelist {
+		if med.GTIN == gtin && med.SerialNumber == serial {
+			return med, nil
+		}
 	}
-
-	return medicine, nil
+	return nil, nil
 }
 
-// RejectRequest - Function for handling disapproving the medicine by changing its state back to AVAILABLE. [Regulators]
-func (c *Contract) RejectRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	// Checksum check
-	err = medicine.VerifyChecksum()
-	if err != nil {
-		return nil, err
-	}
-
-	// Check if medicine state is REQUESTED, if so set it to AVAILABLE and reset to holder to be MedStore.
-	if medicine.IsRequested() {
-		medicine.SetAvailable()
-		medicine.Holder = "MedStore"
-	} else {
-		return nil, fmt.Errorf("cannot disapprove medicine that has not been requested")
-	}
-
-	// Update medicine on the ledger
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
+// orderMedicine - Helper function for retrieving and verifying all medicine reserved for an order.
+func orderMedicine(ctx TransactionContextInterface, order *Order) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	for _, line := range order.Lines {
+		for _, medNumber := range line.MedNumbers {
+			medicine, err := ctx.GetMedicineList().GetMedicine(line.MedName, medNumber)
+			if err != nil {
+				return nil, ledgerError(err, "could not retrieve medicine from ledger")
+			}
+
+			// Checksum check
+			err = verifyChecksum(medicine)
+			if err != nil {
+				return nil, err
+			}
+
+			if !(medicine.IsRequested() || medicine.IsPendingSecondApproval()) || medicine.OrderID != order.OrderID {
+				return nil, newError(CodeInvalidState, "medicine %s:%s is no longer reserved for order %s", line.MedName, medNumber, order.OrderID).with("orderID", order.OrderID)
+			}
+			medicines = append(medicines, medicine)
+		}
 	}
-
-	return medicine, nil
+	return medicines, nil
 }
 
-// ChangeStatus - Function for changing the status of a medicine. [Regulators]
-func (c *Contract) ChangeStatus(ctx TransactionContextInterface, medName string, medNumber string, status string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+// releaseOrder - Helper function for returning all medicine of a pending order to MedStore.
+func releaseOrder(ctx TransactionContextInterface, order *Order) error {
+	medicines, err := orderMedicine(ctx, order)
 	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
+		return err
 	}
 
-	// Checksum check
-	err = medicine.VerifyChecksum()
+	// Restore prescriptions while the customer still is the holder.
+	err = restorePrescriptions(ctx, medicines...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Match case on status and change it.
-	switch strings.ToLower(status) {
-	case "available":
+	for _, medicine := range medicines {
 		medicine.SetAvailable()
-	case "requested":
-		medicine.SetRequested()
-	case "send":
-		medicine.SetSend()
-	default:
-		return nil, fmt.Errorf("cannot change status to a non-possible state")
-	}
-
-	// Update medicine on the ledger
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
-	}
-
-	return medicine, nil
-}
-
-// ChangeHolder - Function for changing the holder of a medicine. [Regulators]
-func (c *Contract) ChangeHolder(ctx TransactionContextInterface, medName string, medNumber string, customer string, user string, tpmkey string) (*MedicalSupply, error) {
-	// Check acces rights
-	err := c.hasAuthority(ctx, user, tpmkey)
-	if err != nil {
-		return nil, err
-	}
-
-	// Retrieve the medicine from the ledger.
-	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
-	if err != nil {
-		return nil, fmt.Errorf("could not retrieve medicine from ledger: %s", err)
-	}
-
-	// Checksum check
-	err = medicine.VerifyChecksum()
-	if err != nil {
-		return nil, err
-	}
-
-	// Hash username
-	customer, err = tpmHash(customer)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(customer) > 0 {
-		medicine.Holder = customer
-	} else {
-		return nil, fmt.Errorf("can't change current holder to invalid username")
-	}
-
-	// Update medicine on the ledger
-	err = ctx.GetMedicineList().UpdateMedicine(medicine)
-	if err != nil {
-		return nil, fmt.Errorf("could not update medicine on the ledger: %s", err)
+		medicine.Holder = "MedStore"
+		medicine.RequestDate = ""
+		medicine.OrderID = ""
+		medicine.FirstApprover = ""
+		err = ctx.GetMedicineList().UpdateMedicine(medicine)
+		if err != nil {
+			return ledgerError(err, "could not update medicine on the ledger")
+		}
 	}
-
-	return medicine, nil
+	return nil
 }
@@ -0,0 +1,149 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+type OrderState uint
+
+const (
+	// PENDING state for when an order has been placed and its medicine is reserved.
+	PENDING OrderState = iota + 1
+	// APPROVED state for when an order has been approved and its medicine is send.
+	APPROVED
+	// REJECTED state for when an order has been rejected and its medicine is available again.
+	REJECTED
+	// CANCELLED state for when an order has been cancelled by the customer.
+	CANCELLED
+)
+
+// String - Changes order state enum to string.
+func (state OrderState) String() string {
+	names := []string{"PENDING", "APPROVED", "REJECTED", "CANCELLED"}
+
+	if state < PENDING || state > CANCELLED {
+		return "UNKNOWN"
+	}
+	return names[state-1]
+}
+
+// OrderClass - Class of the order states.
+const OrderClass = "org.medstore.order"
+
+// CreateOrderKey - Creates a key for the order (e.g. Order:ORD0001).
+func CreateOrderKey(orderID string) string {
+	return ledgerapi.MakeKey("Order", orderID)
+}
+
+// OrderLine - Defines a single line of an order, MedNumbers holds the medicine reserved for the line.
+type OrderLine struct {
+	MedName    string   `json:"medName"`
+	Quantity   int      `json:"quantity"`
+	MedNumbers []string `json:"medNumbers"`
+}
+
+type orderAlias Order
+type jsonOrder struct {
+	*orderAlias
+	State         OrderState `json:"currentState"`
+	Class         string     `json:"class"`
+	Key           string     `json:"key"`
+	SchemaVersion int        `json:"schemaVersion"`
+}
+
+// Order - Defines a customer order of several medicines which is reserved, approved and rejected as a whole.
+// FirstApprover holds the identity of the regulator that gave the first approval of an order containing scheduled medicine.
+type Order struct {
+	OrderID       string      `json:"orderID"`
+	Customer      string      `json:"customer"`
+	OrderDate     string      `json:"orderDate"`
+	Lines         []OrderLine `json:"lines"`
+	FirstApprover string      `json:"firstApprover,omitempty" metadata:",optional"`
+	state         OrderState  `metadata:"currentState"`
+	class         string      `metadata:"class"`
+	key           string      `metadata:"key"`
+	schemaVersion int         `metadata:"schemaVersion"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (order Order) MarshalJSON() ([]byte, error) {
+	jorder := jsonOrder{orderAlias: (*orderAlias)(&order), State: order.state, Class: OrderClass, Key: CreateOrderKey(order.OrderID), SchemaVersion: schemaVersion(OrderClass)}
+	return json.Marshal(&jorder)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (order *Order) UnmarshalJSON(data []byte) error {
+	jorder := jsonOrder{orderAlias: (*orderAlias)(order)}
+
+	err := json.Unmarshal(data, &jorder)
+	if err != nil {
+		return err
+	}
+
+	order.state = jorder.State
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// GetState - Returns the state.
+func (order *Order) GetState() OrderState {
+	return order.state
+}
+
+// SetPending - Returns the state to PENDING.
+func (order *Order) SetPending() {
+	order.state = PENDING
+}
+
+// SetApproved - Returns the state to APPROVED.
+func (order *Order) SetApproved() {
+	order.state = APPROVED
+}
+
+// SetRejected - Returns the state to REJECTED.
+func (order *Order) SetRejected() {
+	order.state = REJECTED
+}
+
+// SetCancelled - Returns the state to CANCELLED.
+func (order *Order) SetCancelled() {
+	order.state = CANCELLED
+}
+
+// IsPending - Returns true if state is PENDING.
+func (order *Order) IsPending() bool {
+	return order.state == PENDING
+}
+
+//-------------------------------------------------------//
+
+// GetSplitKey - Returns values which should be used to form key.
+func (order *Order) GetSplitKey() []string {
+	return []string{"Order", order.OrderID}
+}
+
+// Serialize - Formats the order as JSON bytes.
+func (order *Order) Serialize() ([]byte, error) {
+	return json.Marshal(order)
+}
+
+// DeserializeOrder - Formats the order from JSON bytes.
+func DeserializeOrder(bytes []byte, order *Order) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(OrderClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, order)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing order. %s", err.Error())
+	}
+
+	return nil
+}
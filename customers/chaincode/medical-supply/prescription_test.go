@@ -0,0 +1,99 @@
+package medicalsupply
+
+import (
+	"testing"
+	"time"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePrescriptionKey(t *testing.T) {
+	assert.Equal(t, ledgerapi.MakeKey("Prescription", "alice", "vicodin", "RX0001"), CreatePrescriptionKey("alice", "vicodin", "RX0001"), "should return key comprised of passed values.")
+}
+
+func TestGetPrescriptionSplitKey(t *testing.T) {
+	rx := new(Prescription)
+	rx.PrescriptionID = "RX0001"
+	rx.Patient = "alice"
+	rx.MedName = "vicodin"
+
+	assert.Equal(t, []string{"Prescription", "alice", "vicodin", "RX0001"}, rx.GetSplitKey(),
+		"should return patient, medicine name and prescription id as split key.")
+}
+
+func TestPrescriptionAllowance(t *testing.T) {
+	rx := new(Prescription)
+	rx.Quantity = 2
+	rx.Refills = 1
+
+	assert.Equal(t, 4, rx.Allowance(), "should allow quantity for the first fill and every refill.")
+	assert.Equal(t, 4, rx.Remaining(), "should have everything remaining when nothing is dispensed.")
+
+	assert.Nil(t, rx.Dispense(), "should dispense when units remain.")
+	assert.Equal(t, 3, rx.Remaining(), "should lower remaining after dispense.")
+
+	rx.Restore()
+	assert.Equal(t, 4, rx.Remaining(), "should raise remaining after restore.")
+
+	rx.Dispensed = 4
+	assert.EqualError(t, rx.Dispense(), "prescription  has no remaining units", "should not dispense more than allowed.")
+}
+
+func TestPrescriptionIsValidAt(t *testing.T) {
+	rx := new(Prescription)
+	rx.ValidFrom = "2022.01.01"
+	rx.ValidUntil = "2022.01.31"
+
+	assert.False(t, rx.IsValidAt(time.Date(2021, 12, 31, 23, 0, 0, 0, time.UTC)), "should be false before the window.")
+	assert.True(t, rx.IsValidAt(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)), "should be true at the start of the window.")
+	assert.True(t, rx.IsValidAt(time.Date(2022, 1, 31, 18, 0, 0, 0, time.UTC)), "should be true on the last day of the window.")
+	assert.False(t, rx.IsValidAt(time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)), "should be false after the window.")
+
+	rx.ValidUntil = "31-01-2022"
+	assert.False(t, rx.IsValidAt(time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)), "should be false for malformed dates.")
+}
+
+func TestSerializePrescription(t *testing.T) {
+	rx := new(Prescription)
+	rx.PrescriptionID = "RX0001"
+	rx.Prescriber = "drhouse"
+	rx.Patient = "alice"
+	rx.MedName = "vicodin"
+	rx.Quantity = 1
+	rx.Refills = 2
+	rx.ValidFrom = "2022.01.01"
+	rx.ValidUntil = "2022.06.30"
+	correctJson := `{"prescriptionID":"RX0001","prescriber":"drhouse","patient":"alice","medName":"vicodin","quantity":1,"refills":2,"validFrom":"2022.01.01","validUntil":"2022.06.30","dispensed":0,"class":"org.medstore.prescription","key":"Prescription:alice:vicodin:RX0001","schemaVersion":2}`
+
+	bytes, err := rx.Serialize()
+	assert.Nil(t, err, "should not error on serialize")
+	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
+}
+
+func TestDeserializePrescription(t *testing.T) {
+	var rx *Prescription
+	var err error
+
+	rx = new(Prescription)
+	correctJson := `{"prescriptionID":"RX0001","prescriber":"drhouse","patient":"alice","medName":"vicodin","quantity":1,"refills":2,"validFrom":"2022.01.01","validUntil":"2022.06.30","dispensed":1,"class":"org.medstore.prescription","key":"Prescription:alice:vicodin:RX0001"}`
+	err = DeserializePrescription([]byte(correctJson), rx)
+	assert.Nil(t, err, "should not return error for deserialize")
+
+	expectedRx := new(Prescription)
+	expectedRx.PrescriptionID = "RX0001"
+	expectedRx.Prescriber = "drhouse"
+	expectedRx.Patient = "alice"
+	expectedRx.MedName = "vicodin"
+	expectedRx.Quantity = 1
+	expectedRx.Refills = 2
+	expectedRx.ValidFrom = "2022.01.01"
+	expectedRx.ValidUntil = "2022.06.30"
+	expectedRx.Dispensed = 1
+	assert.Equal(t, expectedRx, rx, "should create expected prescription")
+
+	incorrectJson := `{"prescriptionID":"RX0001","quantity":"one"}`
+	rx = new(Prescription)
+	err = DeserializePrescription([]byte(incorrectJson), rx)
+	assert.EqualError(t, err, "error deserializing prescription. json: cannot unmarshal string into Go struct field jsonPrescription.quantity of type int", "should return error for bad data")
+}
@@ -0,0 +1,215 @@
+package medicalsupply
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MedicineFilter - Defines the restricted filter accepted by QueryMedicines.
+// State is a state name (e.g. AVAILABLE), expiry dates use the ledger layout and prices the price layout (e.g. $10).
+// Sort is medName or expiration, prefixed with - for descending order.
+type MedicineFilter struct {
+	State      string `json:"state,omitempty"`
+	Holder     string `json:"holder,omitempty"`
+	Disease    string `json:"disease,omitempty"`
+	ExpiryFrom string `json:"expiryFrom,omitempty"`
+	ExpiryTo   string `json:"expiryTo,omitempty"`
+	PriceMin   string `json:"priceMin,omitempty"`
+	PriceMax   string `json:"priceMax,omitempty"`
+	Sort       string `json:"sort,omitempty"`
+	state      State
+	priceMin   int64
+	priceMax   int64
+}
+
+// Indexes shipped in META-INF/statedb/couchdb/indexes, used to sort on CouchDB.
+var sortIndexes = map[string][]string{
+	"medName":    {"_design/indexMedNameDoc", "indexMedName"},
+	"expiration": {"_design/indexExpirationDoc", "indexExpiration"},
+}
+
+// ParseState - Returns the state with the given name (e.g. AVAILABLE).
+func ParseState(name string) (State, bool) {
+	for state := AVAILABLE; state <= PENDING_SECOND_APPROVAL; state++ {
+		if strings.EqualFold(state.String(), name) {
+			return state, true
+		}
+	}
+	return 0, false
+}
+
+// ParseMedicineFilter - Parses and validates a JSON filter, unknown fields are rejected.
+func ParseMedicineFilter(data string) (*MedicineFilter, error) {
+	filter := new(MedicineFilter)
+	if strings.TrimSpace(data) != "" {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+
+	if filter.State != "" {
+		state, ok := ParseState(filter.State)
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: unknown state %s", filter.State)
+		}
+		filter.state = state
+	}
+	filter.Disease = strings.ToLower(filter.Disease)
+	for _, date := range []string{filter.ExpiryFrom, filter.ExpiryTo} {
+		if _, err := time.Parse(DateLayout, date); date != "" && err != nil {
+			return nil, fmt.Errorf("invalid filter: expiry date %s should be formatted as %s", date, DateLayout)
+		}
+	}
+
+	var err error
+	filter.priceMin, filter.priceMax = -1, -1
+	if filter.PriceMin != "" {
+		filter.priceMin, err = ParsePrice(filter.PriceMin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+	if filter.PriceMax != "" {
+		filter.priceMax, err = ParsePrice(filter.PriceMax)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %s", err)
+		}
+	}
+
+	if _, ok := sortIndexes[strings.TrimPrefix(filter.Sort, "-")]; filter.Sort != "" && !ok {
+		return nil, fmt.Errorf("invalid filter: can't sort on %s, expected medName or expiration", filter.Sort)
+	}
+	return filter, nil
+}
+
+// Selector - Translates the filter into a CouchDB Mango query.
+// Prices are stored as text and can't be compared by CouchDB, the price range is applied by Matches instead.
+func (filter *MedicineFilter) Selector() (string, error) {
+	selector := map[string]interface{}{"class": MedicineClass}
+	if filter.state != 0 {
+		selector["currentState"] = filter.state
+	}
+	if filter.Holder != "" {
+		selector["holder"] = filter.Holder
+	}
+	if filter.Disease != "" {
+		selector["disease"] = filter.Disease
+	}
+	if filter.ExpiryFrom != "" || filter.ExpiryTo != "" {
+		expiration := make(map[string]string)
+		if filter.ExpiryFrom != "" {
+			expiration["$gte"] = filter.ExpiryFrom
+		}
+		if filter.ExpiryTo != "" {
+			expiration["$lte"] = filter.ExpiryTo
+		}
+		selector["expiration"] = expiration
+	}
+
+	query := map[string]interface{}{"selector": selector}
+	if filter.Sort != "" {
+		// CouchDB sorts on an index, which starts with the class the selector always contains.
+		field, direction := filter.sortField()
+		query["sort"] = []map[string]string{{"class": direction}, {field: direction}}
+		query["use_index"] = sortIndexes[field]
+	}
+
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sortField - Returns the field and direction (asc or desc) to sort on.
+func (filter *MedicineFilter) sortField() (string, string) {
+	if strings.HasPrefix(filter.Sort, "-") {
+		return filter.Sort[1:], "desc"
+	}
+	return filter.Sort, "asc"
+}
+
+// Matches - Returns true if the medicine matches the filter.
+func (filter *MedicineFilter) Matches(ms *MedicalSupply) bool {
+	if filter.state != 0 && ms.GetState() != filter.state {
+		return false
+	}
+	if filter.Holder != "" && ms.Holder != filter.Holder {
+		return false
+	}
+	if filter.Disease != "" && ms.Disease != filter.Disease {
+		return false
+	}
+	// Dates in the ledger layout compare chronologically as strings.
+	if filter.ExpiryFrom != "" && ms.Expiration < filter.ExpiryFrom {
+		return false
+	}
+	if filter.ExpiryTo != "" && ms.Expiration > filter.ExpiryTo {
+		return false
+	}
+	if filter.priceMin >= 0 || filter.priceMax >= 0 {
+		price, err := ParsePrice(ms.Price)
+		if err != nil || (filter.priceMin >= 0 && price < filter.priceMin) || (filter.priceMax >= 0 && price > filter.priceMax) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply - Returns the medicine matching the filter in the requested order, ties are ordered by key.
+func (filter *MedicineFilter) Apply(medicines []*MedicalSupply) []*MedicalSupply {
+	resultlist := []*MedicalSupply{}
+	for _, ms := range medicines {
+		if filter.Matches(ms) {
+			resultlist = append(resultlist, ms)
+		}
+	}
+
+	field, direction := filter.sortField()
+	sort.SliceStable(resultlist, func(i, j int) bool {
+		a, b := resultlist[i], resultlist[j]
+		if direction == "desc" {
+			a, b = b, a
+		}
+		switch {
+		case field == "medName" && a.MedName != b.MedName:
+			return a.MedName < b.MedName
+		case field == "expiration" && a.Expiration != b.Expiration:
+			return a.Expiration < b.Expiration
+		}
+		return CreateMedicalKey(resultlist[i].MedName, resultlist[i].MedNumber) < CreateMedicalKey(resultlist[j].MedName, resultlist[j].MedNumber)
+	})
+	return resultlist
+}
+
+// richQueryNotSupported - Error of peers using LevelDB, which has no rich queries.
+const richQueryNotSupported = "ExecuteQuery not supported for leveldb"
+
+// queryMedicines - Retrieves the medicine matching the filter, using a rich query on CouchDB peers.
+// Peers using LevelDB fall back to filtering all medicine, which gives the same result. Other errors of the rich
+// query (e.g. an invalid selector or a timeout of CouchDB) are returned.
+func queryMedicines(medicineList ListInterface, filter *MedicineFilter) ([]*MedicalSupply, error) {
+	query, err := filter.Selector()
+	if err != nil {
+		return nil, err
+	}
+
+	medicinelist, err := medicineList.QueryMedicines(query)
+	if err != nil {
+		if !strings.Contains(err.Error(), richQueryNotSupported) {
+			return nil, fmt.Errorf("could not query medicine from ledger: %s", err)
+		}
+		medicinelist, err = medicineList.GetAllMedicine()
+		if err != nil {
+			return nil, fmt.Errorf("could not query any medicine from ledger: %s", err)
+		}
+	}
+	return filter.Apply(medicinelist), nil
+}
@@ -0,0 +1,124 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+// QuotaClass - Class of the quota rule states.
+const QuotaClass = "org.medstore.quota"
+
+// CreateQuotaKey - Creates a key for the quota rule (e.g. Quota:Q0001).
+func CreateQuotaKey(ruleID string) string {
+	return ledgerapi.MakeKey("Quota", ruleID)
+}
+
+type quotaRuleAlias QuotaRule
+type jsonQuotaRule struct {
+	*quotaRuleAlias
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// QuotaRule - Defines the maximum amount of units a single customer may request within a period.
+// Scope is either "medicine", matching the medicine name, "category", matching the disease of a medicine,
+// or "schedule", matching the drug schedule of a controlled substance (e.g. II).
+type QuotaRule struct {
+	RuleID        string `json:"ruleID"`
+	Scope         string `json:"scope"`
+	Target        string `json:"target"`
+	MaxUnits      int    `json:"maxUnits"`
+	PeriodDays    int    `json:"periodDays"`
+	class         string `metadata:"class"`
+	key           string `metadata:"key"`
+	schemaVersion int    `metadata:"schemaVersion"`
+}
+
+// QuotaUsage - Defines how much of a quota rule a customer has used within the current period.
+type QuotaUsage struct {
+	RuleID   string `json:"ruleID"`
+	Customer string `json:"customer"`
+	Used     int    `json:"used"`
+	MaxUnits int    `json:"maxUnits"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (rule QuotaRule) MarshalJSON() ([]byte, error) {
+	jrule := jsonQuotaRule{quotaRuleAlias: (*quotaRuleAlias)(&rule), Class: QuotaClass, Key: CreateQuotaKey(rule.RuleID), SchemaVersion: schemaVersion(QuotaClass)}
+	return json.Marshal(&jrule)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (rule *QuotaRule) UnmarshalJSON(data []byte) error {
+	jrule := jsonQuotaRule{quotaRuleAlias: (*quotaRuleAlias)(rule)}
+
+	err := json.Unmarshal(data, &jrule)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// Matches - Returns true if the quota rule applies to the medicine.
+func (rule *QuotaRule) Matches(ms *MedicalSupply) bool {
+	switch rule.Scope {
+	case "medicine":
+		return strings.EqualFold(rule.Target, ms.MedName)
+	case "category":
+		return strings.EqualFold(rule.Target, ms.Disease)
+	case "schedule":
+		return ms.IsScheduled() && strings.EqualFold(rule.Target, ms.Schedule)
+	}
+	return false
+}
+
+// InPeriod - Returns true if the request date lies within the period of the rule ending at now.
+func (rule *QuotaRule) InPeriod(requestDate string, now time.Time) bool {
+	requested, err := time.Parse(time.RFC3339, requestDate)
+	if err != nil {
+		return false
+	}
+	return !requested.After(now) && requested.After(now.AddDate(0, 0, -rule.PeriodDays))
+}
+
+// Explain - Describes why requesting more units would exceed the quota rule.
+func (rule *QuotaRule) Explain(used int, requested int) string {
+	return fmt.Sprintf("quota %s allows at most %d unit(s) of %s %s per %d day(s), %d unit(s) requested in the last %d day(s) and %d more requested now",
+		rule.RuleID, rule.MaxUnits, rule.Scope, rule.Target, rule.PeriodDays, used, rule.PeriodDays, requested)
+}
+
+//-------------------------------------------------------//
+
+// GetSplitKey - Returns values which should be used to form key.
+func (rule *QuotaRule) GetSplitKey() []string {
+	return []string{"Quota", rule.RuleID}
+}
+
+// Serialize - Formats the quota rule as JSON bytes.
+func (rule *QuotaRule) Serialize() ([]byte, error) {
+	return json.Marshal(rule)
+}
+
+// DeserializeQuota - Formats the quota rule from JSON bytes.
+func DeserializeQuota(bytes []byte, rule *QuotaRule) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(QuotaClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, rule)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing quota rule. %s", err.Error())
+	}
+
+	return nil
+}
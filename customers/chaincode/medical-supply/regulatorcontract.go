@@ -0,0 +1,1204 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegulatorContract - Contract for the functions of regulators, which are only available to the regulator organisation.
+type RegulatorContract struct {
+	contractapi.Contract
+}
+
+// NewRegulatorContract - Creates the regulator contract, whose transactions all require an authenticated regulator.
+func NewRegulatorContract() *RegulatorContract {
+	c := new(RegulatorContract)
+	c.Name = RegulatorContractName
+	c.TransactionContextHandler = new(TransactionContext)
+	c.BeforeTransaction = c.beforeTransaction
+	return c
+}
+
+// beforeTransaction - Hook called before every transaction of the contract, checks the access rights of the user.
+func (c *RegulatorContract) beforeTransaction(ctx TransactionContextInterface) error {
+	transaction, params := transactionName(ctx)
+	return c.authorize(ctx, transaction, params)
+}
+
+// authorize - Checks that the invoker may call the transaction with the given arguments.
+func (c *RegulatorContract) authorize(ctx TransactionContextInterface, transaction string, params []string) error {
+	user, tpmkey, err := credentials(transaction, params)
+	if err != nil {
+		return err
+	}
+
+	// Check acces rights
+	return hasAuthority(ctx, user, tpmkey)
+}
+
+// InitLedger - Adds a base set of medicine (MedicalSupply) to the ledger, skipping medicine already on it. [Regulators]
+func (c *RegulatorContract) InitLedger(ctx TransactionContextInterface, user string, tpmkey string) error {
+	// Validate the arguments
+	err := validate("InitLedger", user, tpmkey)
+	if err != nil {
+		return err
+	}
+
+	// Create array of MedicalSupply objects.
+	medicines := []MedicalSupply{
+		{MedName: "aspirin", MedNumber: "00001", Disease: "pain management", Expiration: "2022.05.09", Price: "$10", Holder: "MedStore"},
+		{MedName: "vicodin", MedNumber: "00002", Disease: "pain management", Expiration: "2022.07.01", Price: "$14", Holder: "MedStore", RxOnly: true, Schedule: "II"},
+		{MedName: "synthroid", MedNumber: "00003", Disease: "thyroid deficiency", Expiration: "2021.12.03", Price: "$11", Holder: "MedStore", RxOnly: true},
+		{MedName: "delasone", MedNumber: "00004", Disease: "arthritis", Expiration: "2022.09.12", Price: "$5", Holder: "MedStore", RxOnly: true},
+		{MedName: "amoxil", MedNumber: "00005", Disease: "bacterial infections", Expiration: "2022.07.08", Price: "$9", Holder: "MedStore", RxOnly: true},
+		{MedName: "neurontin", MedNumber: "00006", Disease: "seizures", Expiration: "2022.03.25", Price: "$13", Holder: "MedStore", RxOnly: true},
+		{MedName: "zestril", MedNumber: "00007", Disease: "blood pressure", Expiration: "2022.03.11", Price: "$7", Holder: "MedStore", RxOnly: true},
+		{MedName: "lipitor", MedNumber: "00008", Disease: "high cholesterol", Expiration: "2022.01.06", Price: "$12", Holder: "MedStore", RxOnly: true},
+		{MedName: "glucophage", MedNumber: "00009", Disease: "type 2 diabetes", Expiration: "2022.04.24", Price: "$8", Holder: "MedStore", RxOnly: true},
+		{MedName: "zofran", MedNumber: "00010", Disease: "fever", Expiration: "2022.02.04", Price: "$13", Holder: "MedStore", RxOnly: true},
+		{MedName: "ibuprofen", MedNumber: "00011", Disease: "fever", Expiration: "2022.02.28", Price: "$12", Holder: "MedStore"},
+	}
+
+	// For each medicine, set it's state to Available, calculate the checksum and add it to the ledger.
+	// Medicine which is already on the ledger is skipped, running InitLedger again never overwrites live records.
+	for _, med := range medicines {
+		exists, err := ctx.GetMedicineList().ExistsMedicine(med.MedName, med.MedNumber)
+		if err != nil {
+			return ledgerError(err, "could not retrieve medicine from ledger")
+		}
+		if exists {
+			continue
+		}
+
+		med.SetAvailable()
+		med.InitialiseChecksum()
+		err = ctx.GetMedicineList().AddMedicine(&med)
+
+		if err != nil {
+			return ledgerError(err, "failed to put to world state")
+		}
+	}
+
+	return nil
+}
+
+// Issue - Function for handling issued medicine [Regulators]
+func (c *RegulatorContract) Issue(ctx TransactionContextInterface, medname string, mednumber string,
+	disease string, expiration string, price string, rxonly bool, schedule string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("Issue", medname, mednumber, disease, expiration, price, rxonly, schedule, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issuing medicine which is already on the ledger would overwrite it, whatever its state.
+	exists, err := ctx.GetMedicineList().ExistsMedicine(medname, mednumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+	if exists {
+		return nil, fieldError(CodeAlreadyExists, "Issue", "medNumber", fmt.Sprintf("is already in use by medicine %s:%s", strings.ToLower(medname), mednumber))
+	}
+
+	schedule = strings.ToUpper(schedule)
+
+	// Create MedicalSupply object.
+	medicine := MedicalSupply{
+		MedName:    strings.ToLower(medname),
+		MedNumber:  mednumber,
+		Disease:    strings.ToLower(disease),
+		Expiration: expiration,
+		Price:      price,
+		Holder:     "MedStore",
+		RxOnly:     rxonly,
+		Schedule:   schedule,
+	}
+
+	// Calculate the checksum by using the hashfunction of the TPM.
+	err = medicine.InitialiseChecksum()
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "could not issue new MedicalSupply")
+	}
+
+	// Set state to AVAILABLE.
+	medicine.SetAvailable()
+
+	// Add the medicine to the ledger.
+	err = ctx.GetMedicineList().AddMedicine(&medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not add medicine to the ledger")
+	}
+
+	return &medicine, nil
+}
+
+// IssueFromGS1 - Function for handling issued medicine given the scanned GS1 DataMatrix of the pack [Regulators]
+// The serial number (AI 21) is used as medicine number and the expiry date (AI 17) as expiration.
+func (c *RegulatorContract) IssueFromGS1(ctx TransactionContextInterface, elementString string, medname string,
+	disease string, price string, rxonly bool, schedule string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("IssueFromGS1", elementString, medname, disease, price, rxonly, schedule, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the element strings of the DataMatrix.
+	data, err := ParseGS1(elementString)
+	if err != nil {
+		return nil, wrapError(CodeInvalidArgument, err, "could not parse GS1 element string")
+	}
+	if data.Expiration == "" {
+		return nil, newError(CodeInvalidArgument, "GS1 element string should contain an expiry date (17)")
+	}
+	err = validateGS1("IssueFromGS1", data)
+	if err != nil {
+		return nil, err
+	}
+
+	// A GTIN and serial number identify a single pack.
+	existing, err := findByGS1(ctx, data.GTIN, data.Serial)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, newError(CodeAlreadyExists, "pack with GTIN %s and serial %s has already been issued as %s:%s", data.GTIN, data.Serial, existing.MedName, existing.MedNumber).withMedicine(existing)
+	}
+
+	// The serial number becomes the medicine number, which may already be used by a pack of another product.
+	exists, err := ctx.GetMedicineList().ExistsMedicine(medname, data.Serial)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+	if exists {
+		return nil, fieldError(CodeAlreadyExists, "IssueFromGS1", "elementString", fmt.Sprintf("serial is already in use by medicine %s:%s", strings.ToLower(medname), data.Serial))
+	}
+
+	schedule = strings.ToUpper(schedule)
+
+	// Create MedicalSupply object.
+	medicine := MedicalSupply{
+		MedName:      strings.ToLower(medname),
+		MedNumber:    data.Serial,
+		Disease:      strings.ToLower(disease),
+		Expiration:   data.Expiration,
+		Price:        price,
+		Holder:       "MedStore",
+		RxOnly:       rxonly,
+		Schedule:     schedule,
+		GTIN:         data.GTIN,
+		SerialNumber: data.Serial,
+		LotNumber:    data.Lot,
+	}
+
+	// Calculate the checksum by using the hashfunction of the TPM.
+	err = medicine.InitialiseChecksum()
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "could not issue new MedicalSupply")
+	}
+
+	// Set state to AVAILABLE.
+	medicine.SetAvailable()
+
+	// Add the medicine to the ledger.
+	err = ctx.GetMedicineList().AddMedicine(&medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not add medicine to the ledger")
+	}
+
+	return &medicine, nil
+}
+
+// Delete - Function for handling medicine removal. [Regulators]
+func (c *RegulatorContract) Delete(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) error {
+	// Validate the arguments
+	err := validate("Delete", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	if medicine == nil {
+		return newError(CodeNotFound, "medicine does not exist, can't delete from ledger")
+	}
+
+	// Quarantined and destroyed medicine has to stay on the ledger as evidence, use Destroy instead.
+	if medicine.IsQuarantined() || medicine.IsDestroyed() {
+		return newError(CodeInvalidState, "medicine %s:%s is %s and can't be deleted from ledger", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	// Reserved medicine holds the units of its prescription or order and returned medicine awaits its inspection,
+	// deleting it would leave them unresolved.
+	if medicine.OrderID != "" && (medicine.IsRequested() || medicine.IsPendingSecondApproval()) {
+		return newError(CodeInvalidState, "medicine %s:%s is reserved for order %s, approve or reject the order before deleting it", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+	if medicine.IsRequested() || medicine.IsPendingSecondApproval() || medicine.IsReturned() {
+		return newError(CodeInvalidState, "medicine %s:%s is %s, approve, reject or inspect it before deleting it", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+	err = ctx.GetMedicineList().DeleteMedicine(medName, medNumber)
+	if err != nil {
+		return ledgerError(err, "could not delete medicine from ledger")
+	}
+	return nil
+}
+
+// CheckHistory - Function for getting an overview of all Medicine. [Regulators]
+func (c *RegulatorContract) CheckHistory(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("CheckHistory", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve query any medicine from ledger")
+	}
+	return medicinelist, nil
+}
+
+// CheckRequestedMedicine - Function for getting an overview of all requested medicine, including medicine awaiting a second approval. [Regulators]
+func (c *RegulatorContract) CheckRequestedMedicine(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("CheckRequestedMedicine", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	// Loop through the list and check for REQUESTED state.
+	var resultlist []*MedicalSupply
+	for _, med := range medicinelist {
+		// skips to next iteration if checksum fails
+		err = med.VerifyChecksum()
+		if err != nil {
+			continue
+		}
+
+		if med.IsRequested() || med.IsPendingSecondApproval() {
+			resultlist = append(resultlist, med)
+		}
+	}
+	return resultlist, nil
+}
+
+// ApproveRequest - Function for handling approving the medicine by changing its state to SEND. [Regulators]
+// Scheduled medicine is set to PENDING_SECOND_APPROVAL first and is only send after approval of a second regulator.
+func (c *RegulatorContract) ApproveRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("ApproveRequest", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Medicine reserved for an order can only be approved together with the order.
+	if medicine.OrderID != "" {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is part of order %s, approve the order instead", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+
+	approver, err := approverID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if medicine state is REQUESTED, if so set it to SEND.
+	// Scheduled medicine needs a second approval of a different regulator before it is send.
+	switch {
+	case medicine.IsRequested() && medicine.IsScheduled():
+		medicine.SetPendingSecondApproval()
+		medicine.FirstApprover = approver
+	case medicine.IsRequested():
+		medicine.SetSend()
+	case medicine.IsPendingSecondApproval():
+		if medicine.FirstApprover == approver {
+			return nil, newError(CodeSecondApproval, "medicine %s:%s has already been approved by you, a second regulator has to approve it", medName, medNumber).withMedicine(medicine)
+		}
+		medicine.SetSend()
+	default:
+		return nil, newError(CodeInvalidState, "cannot approve medicine that has not been requested").withMedicine(medicine)
+	}
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// RejectRequest - Function for handling disapproving the medicine by changing its state back to AVAILABLE. [Regulators]
+func (c *RegulatorContract) RejectRequest(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("RejectRequest", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Medicine reserved for an order can only be rejected together with the order.
+	if medicine.OrderID != "" {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is part of order %s, reject the order instead", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+
+	// Check if medicine state is REQUESTED or PENDING_SECOND_APPROVAL, if so set it to AVAILABLE and reset to holder to be MedStore.
+	if medicine.IsRequested() || medicine.IsPendingSecondApproval() {
+		err = restorePrescriptions(ctx, medicine)
+		if err != nil {
+			return nil, err
+		}
+		medicine.SetAvailable()
+		medicine.Holder = "MedStore"
+		medicine.RequestDate = ""
+		medicine.FirstApprover = ""
+	} else {
+		return nil, newError(CodeInvalidState, "cannot disapprove medicine that has not been requested").withMedicine(medicine)
+	}
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// ChangeStatus - Function for changing the status of a medicine. [Regulators]
+// Only medicine outside the workflows is changed: scheduled medicine and orders are approved with ApproveRequest and
+// ApproveOrder, returns are inspected with InspectReturn and quarantined medicine is destroyed with Destroy.
+func (c *RegulatorContract) ChangeStatus(ctx TransactionContextInterface, medName string, medNumber string, status string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("ChangeStatus", medName, medNumber, status, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returned medicine is only restocked after its inspection.
+	if medicine.IsReturned() {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is awaiting inspection, use InspectReturn instead", medName, medNumber).withMedicine(medicine)
+	}
+
+	// Quarantined medicine can only be destroyed and destroyed medicine stays destroyed.
+	if medicine.IsQuarantined() || medicine.IsDestroyed() {
+		return nil, newError(CodeInvalidState, "cannot change status of medicine %s:%s. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	// Scheduled medicine is only send after the approval of two regulators, medicine of an order with the order.
+	if medicine.IsScheduled() || medicine.IsPendingSecondApproval() {
+		return nil, newError(CodeSecondApproval, "medicine %s:%s is scheduled, use ApproveRequest for the approval of two regulators", medName, medNumber).withMedicine(medicine)
+	}
+	if medicine.OrderID != "" {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is part of order %s, approve or reject the order instead", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+
+	// Match case on status and change it.
+	switch strings.ToLower(status) {
+	case "available":
+		medicine.SetAvailable()
+	case "requested":
+		medicine.SetRequested()
+	case "send":
+		medicine.SetSend()
+	default:
+		return nil, newError(CodeInvalidArgument, "cannot change status to a non-possible state")
+	}
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// ChangeHolder - Function for changing the holder of a medicine. [Regulators]
+func (c *RegulatorContract) ChangeHolder(ctx TransactionContextInterface, medName string, medNumber string, customer string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("ChangeHolder", medName, medNumber, customer, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returned, quarantined and destroyed medicine stays with the MedStore, medicine of an order with the order.
+	if medicine.IsReturned() || medicine.IsQuarantined() || medicine.IsDestroyed() {
+		return nil, newError(CodeInvalidState, "cannot change holder of medicine %s:%s. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+	if medicine.OrderID != "" {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is part of order %s, approve or reject the order instead", medName, medNumber, medicine.OrderID).withMedicine(medicine).with("orderID", medicine.OrderID)
+	}
+
+	// Hash username
+	customer, err = tpmHash(customer)
+	if err != nil {
+		return nil, wrapError(CodeInternal, err, "cannot hash customer string")
+	}
+
+	if len(customer) > 0 {
+		medicine.Holder = customer
+	} else {
+		return nil, newError(CodeInvalidArgument, "can't change current holder to invalid username")
+	}
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// CheckPrescriptions - Function for getting an overview of all prescriptions and how much has been dispensed. [Regulators]
+func (c *RegulatorContract) CheckPrescriptions(ctx TransactionContextInterface, user string, tpmkey string) ([]*Prescription, error) {
+	// Validate the arguments
+	err := validate("CheckPrescriptions", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all prescriptions from the ledger.
+	prescriptions, err := ctx.GetMedicineList().GetAllPrescriptions()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any prescription from ledger")
+	}
+	return prescriptions, nil
+}
+
+// CheckOrders - Function for getting an overview of all orders. [Regulators]
+func (c *RegulatorContract) CheckOrders(ctx TransactionContextInterface, user string, tpmkey string) ([]*Order, error) {
+	// Validate the arguments
+	err := validate("CheckOrders", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all orders from the ledger.
+	orders, err := ctx.GetMedicineList().GetAllOrders()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any order from ledger")
+	}
+	return orders, nil
+}
+
+// ApproveOrder - Function for approving an order by changing the state of all its medicine to SEND. [Regulators]
+// Orders containing scheduled medicine stay PENDING after the first approval until a second regulator approves them.
+func (c *RegulatorContract) ApproveOrder(ctx TransactionContextInterface, orderID string, user string, tpmkey string) (*Order, error) {
+	// Validate the arguments
+	err := validate("ApproveOrder", orderID, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the order from the ledger.
+	order, err := ctx.GetMedicineList().GetOrder(orderID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve order from ledger")
+	}
+
+	if !order.IsPending() {
+		return nil, newError(CodeInvalidState, "cannot approve order %s, current state = %s", orderID, order.GetState()).with("orderID", orderID).with("state", order.GetState().String())
+	}
+
+	medicines, err := orderMedicine(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	approver, err := approverID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders containing scheduled medicine need a second approval of a different regulator.
+	scheduled := false
+	for _, medicine := range medicines {
+		scheduled = scheduled || medicine.IsScheduled()
+	}
+	firstApproval := scheduled && order.FirstApprover == ""
+	if scheduled && order.FirstApprover == approver {
+		return nil, newError(CodeSecondApproval, "order %s has already been approved by you, a second regulator has to approve it", orderID).with("orderID", orderID)
+	}
+
+	for _, medicine := range medicines {
+		if firstApproval {
+			medicine.SetPendingSecondApproval()
+			medicine.FirstApprover = approver
+		} else {
+			medicine.SetSend()
+		}
+		err = ctx.GetMedicineList().UpdateMedicine(medicine)
+		if err != nil {
+			return nil, ledgerError(err, "could not update medicine on the ledger")
+		}
+	}
+
+	// Update order on the ledger
+	if firstApproval {
+		order.FirstApprover = approver
+	} else {
+		order.SetApproved()
+	}
+	err = ctx.GetMedicineList().UpdateOrder(order)
+	if err != nil {
+		return nil, ledgerError(err, "could not update order on the ledger")
+	}
+
+	return order, nil
+}
+
+// RejectOrder - Function for rejecting an order, all its medicine becomes available again. [Regulators]
+func (c *RegulatorContract) RejectOrder(ctx TransactionContextInterface, orderID string, user string, tpmkey string) (*Order, error) {
+	// Validate the arguments
+	err := validate("RejectOrder", orderID, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the order from the ledger.
+	order, err := ctx.GetMedicineList().GetOrder(orderID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve order from ledger")
+	}
+
+	if !order.IsPending() {
+		return nil, newError(CodeInvalidState, "cannot reject order %s, current state = %s", orderID, order.GetState()).with("orderID", orderID).with("state", order.GetState().String())
+	}
+
+	err = releaseOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update order on the ledger
+	order.SetRejected()
+	err = ctx.GetMedicineList().UpdateOrder(order)
+	if err != nil {
+		return nil, ledgerError(err, "could not update order on the ledger")
+	}
+
+	return order, nil
+}
+
+// InspectReturn - Function for inspecting a returned medicine and either restocking or quarantining it for destruction. [Regulators]
+// Outcome is either "restock" or "destroy", refund is the amount to be refunded to the customer (e.g. $10), at most the price paid.
+// Medicine to be destroyed is quarantined with the notes as reason, Destroy records its certificate of destruction.
+func (c *RegulatorContract) InspectReturn(ctx TransactionContextInterface, returnID string, outcome string, refund string, notes string, user string, tpmkey string) (*MedicineReturn, error) {
+	// Validate the arguments
+	err := validate("InspectReturn", returnID, outcome, refund, notes, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the return from the ledger.
+	medicineReturn, err := ctx.GetMedicineList().GetReturn(returnID)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve return from ledger")
+	}
+	if !medicineReturn.IsFiled() {
+		return nil, newError(CodeInvalidState, "return %s has already been inspected. current state = %s", returnID, medicineReturn.GetState()).with("returnID", returnID).with("state", medicineReturn.GetState().String())
+	}
+
+	// The refund can't exceed the price the customer paid, nothing is refunded for a price which can't be read.
+	refundCents, err := ParsePrice(refund)
+	if err != nil {
+		return nil, fieldError(CodeInvalidArgument, "InspectReturn", "refund", "should be a price, e.g. $10 or $2.50")
+	}
+	paidCents, _ := ParsePrice(medicineReturn.PricePaid)
+	if refundCents > paidCents {
+		return nil, fieldError(CodeInvalidArgument, "InspectReturn", "refund", fmt.Sprintf("should not exceed the price paid of %s", medicineReturn.PricePaid))
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medicineReturn.MedName, medicineReturn.MedNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+	if !medicine.IsReturned() {
+		return nil, newError(CodeInvalidState, "medicine %s:%s is not awaiting inspection. current state = %s", medicine.MedName, medicine.MedNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	// Checksum check, tampered medicine may not be restocked.
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the outcome of the inspection.
+	switch strings.ToLower(outcome) {
+	case "restock":
+		medicine.SetAvailable()
+		// Restocked medicine goes back on sale with a freshly calculated checksum.
+		err = medicine.InitialiseChecksum()
+		if err != nil {
+			return nil, wrapError(CodeInternal, err, "could not restock medicine")
+		}
+		medicineReturn.SetRestocked()
+	case "destroy":
+		medicine.SetQuarantined()
+		medicine.QuarantineNote = "return " + returnID
+		if strings.TrimSpace(notes) != "" {
+			medicine.QuarantineNote += ": " + notes
+		}
+		medicineReturn.SetDiscarded()
+	default:
+		return nil, newError(CodeInvalidArgument, "inspection outcome should be either restock or destroy")
+	}
+	medicine.Holder = "MedStore"
+	medicine.RequestDate = ""
+	medicine.PrescriptionID = ""
+	medicine.OrderID = ""
+
+	medicineReturn.RefundAmount = FormatPrice(refundCents)
+	medicineReturn.AddEvent("InspectReturn", user, now.Format(time.RFC3339), ctx.GetStub().GetTxID(), notes)
+	medicineReturn.AddEvent(medicineReturn.GetState().String(), user, now.Format(time.RFC3339), ctx.GetStub().GetTxID(), "refund "+medicineReturn.RefundAmount)
+
+	// Update medicine and return on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+	err = ctx.GetMedicineList().UpdateReturn(medicineReturn)
+	if err != nil {
+		return nil, ledgerError(err, "could not update return on the ledger")
+	}
+
+	return medicineReturn, nil
+}
+
+// CheckReturns - Function for getting an overview of all returns. [Regulators]
+func (c *RegulatorContract) CheckReturns(ctx TransactionContextInterface, user string, tpmkey string) ([]*MedicineReturn, error) {
+	// Validate the arguments
+	err := validate("CheckReturns", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all returns from the ledger.
+	returns, err := ctx.GetMedicineList().GetAllReturns()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any return from ledger")
+	}
+	return returns, nil
+}
+
+// Quarantine - Function for taking available medicine out of stock (e.g. recalled, damaged or expired). [Regulators]
+func (c *RegulatorContract) Quarantine(ctx TransactionContextInterface, medName string, medNumber string, note string, user string, tpmkey string) (*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("Quarantine", medName, medNumber, note, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only stock at MedStore can be quarantined, requested medicine has to be rejected first.
+	if !medicine.IsAvailable() {
+		return nil, newError(CodeInvalidState, "cannot quarantine medicine %s:%s. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+	if len(strings.TrimSpace(note)) == 0 {
+		return nil, newError(CodeInvalidArgument, "a reason is required for quarantining medicine")
+	}
+
+	medicine.SetQuarantined()
+	medicine.QuarantineNote = note
+
+	// Update medicine on the ledger
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	return medicine, nil
+}
+
+// Destroy - Function for disposing of quarantined medicine and recording a certificate of destruction. [Regulators]
+// Witnesses is a comma separated list of the identities witnessing the destruction, documentHash is optional.
+func (c *RegulatorContract) Destroy(ctx TransactionContextInterface, medName string, medNumber string, method string, witnesses string,
+	date string, documentHash string, user string, tpmkey string) (*DestructionCertificate, error) {
+	// Validate the arguments
+	err := validate("Destroy", medName, medNumber, method, witnesses, date, documentHash, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the medicine from the ledger.
+	medicine, err := ctx.GetMedicineList().GetMedicine(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve medicine from ledger")
+	}
+
+	// Checksum check
+	err = verifyChecksum(medicine)
+	if err != nil {
+		return nil, err
+	}
+
+	if !medicine.IsQuarantined() {
+		return nil, newError(CodeInvalidState, "medicine %s:%s has to be quarantined before destruction. current state = %s", medName, medNumber, medicine.GetState()).withMedicine(medicine)
+	}
+
+	// Witnesses have to be distinct and may not include the regulator recording the destruction.
+	var witnessList []string
+	seen := make(map[string]bool)
+	for _, witness := range strings.Split(witnesses, ",") {
+		witness = strings.TrimSpace(witness)
+		if witness == "" || seen[strings.ToLower(witness)] {
+			continue
+		}
+		if strings.EqualFold(witness, user) {
+			return nil, newError(CodeInvalidArgument, "regulator recording the destruction cannot be a witness")
+		}
+		seen[strings.ToLower(witness)] = true
+		witnessList = append(witnessList, witness)
+	}
+	if len(witnessList) == 0 {
+		return nil, newError(CodeInvalidArgument, "at least one witness is required for destruction")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	destroyed, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "invalid destruction date %s, expected format %s", date, DateLayout)
+	}
+	if destroyed.After(now) {
+		return nil, newError(CodeInvalidArgument, "destruction date %s lies in the future", date)
+	}
+
+	// Create DestructionCertificate object.
+	cert := DestructionCertificate{
+		CertificateID:   ctx.GetStub().GetTxID(),
+		MedName:         medicine.MedName,
+		MedNumber:       medicine.MedNumber,
+		CheckSum:        medicine.CheckSum,
+		Reason:          medicine.QuarantineNote,
+		Method:          method,
+		Witnesses:       witnessList,
+		DestructionDate: date,
+		DocumentHash:    strings.ToLower(documentHash),
+		RecordedBy:      user,
+		RecordedAt:      now.Format(time.RFC3339),
+	}
+
+	// Set medicine state to DESTROYED, the record stays on the ledger as evidence.
+	medicine.SetDestroyed()
+	err = ctx.GetMedicineList().UpdateMedicine(medicine)
+	if err != nil {
+		return nil, ledgerError(err, "could not update medicine on the ledger")
+	}
+
+	// Add the certificate to the ledger.
+	err = ctx.GetMedicineList().AddDestruction(&cert)
+	if err != nil {
+		return nil, ledgerError(err, "could not add certificate of destruction to the ledger")
+	}
+
+	return &cert, nil
+}
+
+// GetDestructionCertificate - Function for getting the certificate of destruction of a medicine. [Regulators]
+func (c *RegulatorContract) GetDestructionCertificate(ctx TransactionContextInterface, medName string, medNumber string, user string, tpmkey string) (*DestructionCertificate, error) {
+	// Validate the arguments
+	err := validate("GetDestructionCertificate", medName, medNumber, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := ctx.GetMedicineList().GetDestruction(medName, medNumber)
+	if err != nil {
+		return nil, ledgerError(err, "could not retrieve certificate of destruction from ledger")
+	}
+	return cert, nil
+}
+
+// CheckDestructions - Function for getting an overview of all certificates of destruction. [Regulators]
+func (c *RegulatorContract) CheckDestructions(ctx TransactionContextInterface, user string, tpmkey string) ([]*DestructionCertificate, error) {
+	// Validate the arguments
+	err := validate("CheckDestructions", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all certificates from the ledger.
+	certs, err := ctx.GetMedicineList().GetAllDestructions()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any certificate of destruction from ledger")
+	}
+	return certs, nil
+}
+
+// SetQuotaRule - Function for adding or changing a quota rule. [Regulators]
+// Scope is either "medicine", "category" or "schedule", target the medicine name, disease or drug schedule the rule applies to.
+func (c *RegulatorContract) SetQuotaRule(ctx TransactionContextInterface, ruleID string, scope string, target string, maxUnits int, periodDays int, user string, tpmkey string) (*QuotaRule, error) {
+	// Validate the arguments
+	err := validate("SetQuotaRule", ruleID, scope, target, maxUnits, periodDays, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create QuotaRule object.
+	rule := QuotaRule{RuleID: ruleID, Scope: strings.ToLower(scope), Target: strings.ToLower(target), MaxUnits: maxUnits, PeriodDays: periodDays}
+
+	// Add or update the quota rule on the ledger.
+	err = ctx.GetMedicineList().UpdateQuota(&rule)
+	if err != nil {
+		return nil, ledgerError(err, "could not update quota rule on the ledger")
+	}
+
+	return &rule, nil
+}
+
+// RemoveQuotaRule - Function for removing a quota rule. [Regulators]
+func (c *RegulatorContract) RemoveQuotaRule(ctx TransactionContextInterface, ruleID string, user string, tpmkey string) error {
+	// Validate the arguments
+	err := validate("RemoveQuotaRule", ruleID, user, tpmkey)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.GetMedicineList().GetQuota(ruleID)
+	if err != nil {
+		return ledgerError(err, "could not retrieve quota rule from ledger")
+	}
+	err = ctx.GetMedicineList().DeleteQuota(ruleID)
+	if err != nil {
+		return ledgerError(err, "could not delete quota rule from ledger")
+	}
+	return nil
+}
+
+// CheckQuotaRules - Function for getting an overview of all quota rules. [Regulators]
+func (c *RegulatorContract) CheckQuotaRules(ctx TransactionContextInterface, user string, tpmkey string) ([]*QuotaRule, error) {
+	// Validate the arguments
+	err := validate("CheckQuotaRules", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all quota rules from the ledger.
+	rules, err := ctx.GetMedicineList().GetAllQuotas()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any quota rule from ledger")
+	}
+	return rules, nil
+}
+
+// CheckQuotaUsage - Function for getting the customers which have used at least threshold percent of a quota. [Regulators]
+func (c *RegulatorContract) CheckQuotaUsage(ctx TransactionContextInterface, threshold int, user string, tpmkey string) ([]*QuotaUsage, error) {
+	// Validate the arguments
+	err := validate("CheckQuotaUsage", threshold, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ctx.GetMedicineList().GetAllQuotas()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any quota rule from ledger")
+	}
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	var resultlist []*QuotaUsage
+	for _, rule := range rules {
+		// Count the units per customer within the period of the rule.
+		usage := make(map[string]int)
+		for _, med := range medicinelist {
+			if med.Holder != "MedStore" && rule.Matches(med) && rule.InPeriod(med.RequestDate, now) {
+				usage[med.Holder]++
+			}
+		}
+
+		for customer, used := range usage {
+			if used*100 >= threshold*rule.MaxUnits {
+				resultlist = append(resultlist, &QuotaUsage{RuleID: rule.RuleID, Customer: customer, Used: used, MaxUnits: rule.MaxUnits})
+			}
+		}
+	}
+
+	sortResults(resultlist,
+		ascending(func(usage *QuotaUsage) string { return usage.RuleID }),
+		descending(func(usage *QuotaUsage) int { return usage.Used }),
+		ascending(func(usage *QuotaUsage) string { return usage.Customer }))
+	return resultlist, nil
+}
+
+// ExportEPCIS - Function for exporting the supply-chain history of medicine as an EPCIS 2.0 JSON-LD document. [Regulators]
+// From and to (RFC3339) limit the events to a time window and may be left empty, product is a medicine name or GTIN and may be left empty.
+// Only medicine which is still on the ledger is exported, the history of deleted medicine can't be looked up by key anymore.
+func (c *RegulatorContract) ExportEPCIS(ctx TransactionContextInterface, from string, to string, product string, user string, tpmkey string) (string, error) {
+	// Validate the arguments
+	err := validate("ExportEPCIS", from, to, product, user, tpmkey)
+	if err != nil {
+		return "", err
+	}
+
+	var fromTime, toTime time.Time
+	if from != "" {
+		fromTime, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return "", newError(CodeInvalidArgument, "invalid start of time window %s, expected RFC3339 (e.g. 2022-02-22T00:00:00Z)", from)
+		}
+	}
+	if to != "" {
+		toTime, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return "", newError(CodeInvalidArgument, "invalid end of time window %s, expected RFC3339 (e.g. 2022-02-22T00:00:00Z)", to)
+		}
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return "", ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	var events []*EPCISEvent
+	for _, med := range medicinelist {
+		if product != "" && !strings.EqualFold(product, med.MedName) && product != med.GTIN {
+			continue
+		}
+
+		// Convert the history of the medicine into events and keep those within the time window.
+		records, err := ctx.GetMedicineList().GetMedicineHistory(med.MedName, med.MedNumber)
+		if err != nil {
+			return "", ledgerError(err, "could not retrieve history of medicine %s:%s from ledger", med.MedName, med.MedNumber)
+		}
+		for _, event := range EPCISEvents(records) {
+			if event.InWindow(fromTime, toTime) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	document, err := json.Marshal(NewEPCISDocument(events, now))
+	if err != nil {
+		return "", wrapError(CodeInternal, err, "could not create EPCIS document")
+	}
+	return string(document), nil
+}
+
+// InventoryReport - Function for getting the stock aggregated by medicine name and state. [Regulators]
+func (c *RegulatorContract) InventoryReport(ctx TransactionContextInterface, user string, tpmkey string) (*InventoryReport, error) {
+	// Validate the arguments
+	err := validate("InventoryReport", user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+	return NewInventoryReport(medicinelist, now), nil
+}
+
+// ExpiryForecast - Function for getting the available medicine expiring within days of the transaction, grouped by name. [Regulators]
+func (c *RegulatorContract) ExpiryForecast(ctx TransactionContextInterface, days int, user string, tpmkey string) ([]*ExpiryGroup, error) {
+	// Validate the arguments
+	err := validate("ExpiryForecast", days, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return nil, ledgerError(err, "could not query any medicine from ledger")
+	}
+	return NewExpiryForecast(medicinelist, now, days), nil
+}
+
+// RaiseExpiryAlert - Function for emitting an ExpiryAlert event other organisations can listen to. [Regulators]
+func (c *RegulatorContract) RaiseExpiryAlert(ctx TransactionContextInterface, alert string, user string, tpmkey string) error {
+	// Validate the arguments
+	err := validate("RaiseExpiryAlert", alert, user, tpmkey)
+	if err != nil {
+		return err
+	}
+
+	var expiryAlert ExpiryAlert
+	err = json.Unmarshal([]byte(alert), &expiryAlert)
+	if err != nil || expiryAlert.MedName == "" {
+		return newError(CodeInvalidArgument, "invalid expiry alert, expected JSON with at least a medName")
+	}
+
+	// Emit the alert as chaincode event.
+	payload, err := json.Marshal(expiryAlert)
+	if err != nil {
+		return wrapError(CodeInternal, err, "could not create expiry alert")
+	}
+	err = ctx.GetStub().SetEvent("ExpiryAlert", payload)
+	if err != nil {
+		return wrapError(CodeInternal, err, "could not emit expiry alert")
+	}
+	return nil
+}
+
+// QueryMedicines - Function for searching medicine with a JSON filter on state, holder, disease, expiry and price. [Regulators]
+func (c *RegulatorContract) QueryMedicines(ctx TransactionContextInterface, filter string, user string, tpmkey string) ([]*MedicalSupply, error) {
+	// Validate the arguments
+	err := validate("QueryMedicines", filter, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	medicineFilter, err := ParseMedicineFilter(filter)
+	if err != nil {
+		return nil, newError(CodeInvalidArgument, "%s", err)
+	}
+
+	// Query the medicine, CouchDB peers use the shipped indexes while LevelDB peers filter all medicine.
+	medicinelist, err := queryMedicines(ctx.GetMedicineList(), medicineFilter)
+	if err != nil {
+		return nil, ledgerError(err, "could not query medicine")
+	}
+	return medicinelist, nil
+}
+
+// RebuildSearchIndex - Function for adding all medicine to the search index, e.g. medicine issued before the index existed. [Regulators]
+func (c *RegulatorContract) RebuildSearchIndex(ctx TransactionContextInterface, user string, tpmkey string) (int, error) {
+	// Validate the arguments
+	err := validate("RebuildSearchIndex", user, tpmkey)
+	if err != nil {
+		return 0, err
+	}
+
+	// Get all medicine from the ledger.
+	medicinelist, err := ctx.GetMedicineList().GetAllMedicine()
+	if err != nil {
+		return 0, ledgerError(err, "could not query any medicine from ledger")
+	}
+
+	// Index every medicine, returning the amount of available medicine which can be found.
+	indexed := 0
+	for _, med := range medicinelist {
+		err = ctx.GetMedicineList().IndexMedicine(med)
+		if err != nil {
+			return 0, ledgerError(err, "could not index medicine %s %s", med.MedName, med.MedNumber)
+		}
+		if med.IsAvailable() {
+			indexed++
+		}
+	}
+	return indexed, nil
+}
+
+// MigrateStates - Function for rewriting a page of states to the latest schema versions, starting after the bookmark. [Regulators]
+// Pass the returned bookmark to the next transaction until the migration is done, an empty bookmark starts at the beginning.
+func (c *RegulatorContract) MigrateStates(ctx TransactionContextInterface, bookmark string, pageSize int, user string, tpmkey string) (*MigrationProgress, error) {
+	// Validate the arguments
+	err := validate("MigrateStates", bookmark, pageSize, user, tpmkey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite the page of states.
+	progress, err := ctx.GetMedicineList().MigrateStates(bookmark, pageSize)
+	if err != nil {
+		return nil, ledgerError(err, "could not migrate states")
+	}
+	return progress, nil
+}
@@ -0,0 +1,201 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegulatorAuthorize(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	identity := &fakeIdentity{mspID: RegulatorMSP}
+	ctx.SetClientIdentity(identity)
+	c := NewRegulatorContract()
+
+	stub.MockTransactionStart("tx1")
+	regulatorKey, _ := NewAuthContract().TPMKeyGen(ctx, "regulator")
+	stub.MockTransactionEnd("tx1")
+
+	assert.Nil(t, c.authorize(ctx, "CheckHistory", []string{"regulator", regulatorKey}), "should allow authenticated regulators")
+	err := c.authorize(ctx, "CheckHistory", []string{"regulator", "wrong"})
+	assert.Equal(t, CodeUnauthenticated, ErrorCodeOf(err), "should reject a wrong tpm key")
+	err = c.authorize(ctx, "CheckHistory", []string{"regulator", ""})
+	assert.Equal(t, CodeInvalidArgument, ErrorCodeOf(err), "should validate the credentials first")
+
+	identity.mspID = CustomerMSP
+	err = c.authorize(ctx, "CheckHistory", []string{"regulator", regulatorKey})
+	assert.Equal(t, CodeUnauthorizedOrg, ErrorCodeOf(err), "should reject other organisations")
+}
+
+// newRegulatorContext - Returns the context of a regulator on an empty ledger within transaction tx1.
+func newRegulatorContext(t *testing.T) (*TransactionContext, *fakeIdentity) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	stub.MockTransactionStart("tx1")
+	t.Cleanup(func() { stub.MockTransactionEnd("tx1") })
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	identity := &fakeIdentity{id: "regulator1", mspID: RegulatorMSP}
+	ctx.SetClientIdentity(identity)
+	return ctx, identity
+}
+
+// addTestMedicine - Adds medicine in the given state to the ledger.
+func addTestMedicine(t *testing.T, ctx *TransactionContext, medicine MedicalSupply, state State) *MedicalSupply {
+	medicine.Disease = "pain management"
+	medicine.Expiration = "2099.05.09"
+	medicine.Price = "$10"
+	assert.Nil(t, medicine.InitialiseChecksum(), "should calculate the checksum")
+	medicine.state = state
+	assert.Nil(t, ctx.GetMedicineList().AddMedicine(&medicine), "should add the medicine")
+	return &medicine
+}
+
+func TestInspectReturn(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	fileReturn := func(returnID string, medicine *MedicalSupply) {
+		medicineReturn := &MedicineReturn{ReturnID: returnID, MedName: medicine.MedName, MedNumber: medicine.MedNumber, Customer: "alice", PricePaid: medicine.Price}
+		medicineReturn.SetFiled()
+		assert.Nil(t, ctx.GetMedicineList().AddReturn(medicineReturn), "should file the return")
+	}
+
+	restocked := addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00001", Holder: "alice"}, RETURNED)
+	fileReturn("RET0001", restocked)
+	_, err := c.ChangeStatus(ctx, "aspirin", "00001", "available", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not restock returned medicine without inspection")
+	_, err = c.InspectReturn(ctx, "RET0001", "restock", "$10.01", "seal intact", "bob", "secret")
+	assert.Equal(t, "should not exceed the price paid of $10", err.(*ContractError).Fields[0].Message, "should not refund more than the price paid")
+	_, err = c.InspectReturn(ctx, "RET0001", "restock", "-$1", "seal intact", "bob", "secret")
+	assert.Equal(t, CodeInvalidArgument, ErrorCodeOf(err), "should not accept a negative refund")
+	_, err = c.InspectReturn(ctx, "RET0001", "restock", "", "seal intact", "bob", "secret")
+	assert.Equal(t, CodeInvalidArgument, ErrorCodeOf(err), "should require the refund")
+	medicineReturn, err := c.InspectReturn(ctx, "RET0001", "restock", "10", "seal intact", "bob", "secret")
+	assert.Nil(t, err, "should restock the medicine")
+	assert.Equal(t, "$10.00", medicineReturn.RefundAmount, "should store the refund as a price")
+	medicine, _ := ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	assert.True(t, medicine.IsAvailable(), "should make restocked medicine available")
+	assert.Nil(t, medicine.VerifyChecksum(), "should store restocked medicine with a valid checksum")
+	assert.Equal(t, "MedStore", medicine.Holder, "should hand restocked medicine back to the MedStore")
+
+	destroyed := addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00002", Holder: "alice"}, RETURNED)
+	fileReturn("RET0002", destroyed)
+	_, err = c.InspectReturn(ctx, "RET0002", "destroy", "$0", "seal broken", "bob", "secret")
+	assert.Nil(t, err, "should discard the medicine")
+	medicine, _ = ctx.GetMedicineList().GetMedicine("aspirin", "00002")
+	assert.True(t, medicine.IsQuarantined(), "should quarantine discarded medicine until its destruction is recorded")
+	assert.Equal(t, "return RET0002: seal broken", medicine.QuarantineNote, "should quarantine with the notes of the inspection")
+
+	tampered := addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00003", Holder: "alice"}, RETURNED)
+	fileReturn("RET0003", tampered)
+	tampered.Price = "$1"
+	assert.Nil(t, ctx.GetMedicineList().UpdateMedicine(tampered), "should tamper with the medicine")
+	_, err = c.InspectReturn(ctx, "RET0003", "restock", "$10", "", "bob", "secret")
+	assert.Equal(t, CodeChecksumMismatch, ErrorCodeOf(err), "should not restock tampered medicine")
+}
+
+func TestDestroy(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00001", Holder: "MedStore", QuarantineNote: "recalled"}, QUARANTINED)
+
+	_, err := c.ChangeStatus(ctx, "aspirin", "00001", "available", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not restock quarantined medicine")
+
+	cert, err := c.Destroy(ctx, "aspirin", "00001", "incineration", "carol, dave", "2022.02.22", "", "bob", "secret")
+	assert.Nil(t, err, "should destroy quarantined medicine")
+	assert.Equal(t, []string{"carol", "dave"}, cert.Witnesses, "should record the witnesses")
+	assert.Equal(t, "recalled", cert.Reason, "should record the reason of the quarantine")
+
+	_, err = c.ChangeStatus(ctx, "aspirin", "00001", "available", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should keep destroyed medicine destroyed")
+	err = ctx.GetMedicineList().AddDestruction(&DestructionCertificate{MedName: "aspirin", MedNumber: "00001", CertificateID: "tx2"})
+	assert.Equal(t, CodeAlreadyExists, ErrorCodeOf(err), "should not replace the certificate of destruction")
+	stored, _ := ctx.GetMedicineList().GetDestruction("aspirin", "00001")
+	assert.Equal(t, cert.CertificateID, stored.CertificateID, "should keep the first certificate of destruction")
+}
+
+func TestChangeStatus(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00001", Holder: "alice"}, REQUESTED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "vicodin", MedNumber: "00002", Holder: "alice", Schedule: "II"}, REQUESTED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "vicodin", MedNumber: "00003", Holder: "alice", Schedule: "II", FirstApprover: "regulator1"}, PENDING_SECOND_APPROVAL)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00004", Holder: "alice", OrderID: "ORD0001"}, REQUESTED)
+
+	medicine, err := c.ChangeStatus(ctx, "aspirin", "00001", "send", "bob", "secret")
+	assert.Nil(t, err, "should change the status of medicine outside the workflows")
+	assert.True(t, medicine.IsSend(), "should send the medicine")
+
+	_, err = c.ChangeStatus(ctx, "vicodin", "00002", "send", "bob", "secret")
+	assert.Equal(t, CodeSecondApproval, ErrorCodeOf(err), "should not send scheduled medicine without two approvals")
+	_, err = c.ChangeStatus(ctx, "vicodin", "00003", "send", "bob", "secret")
+	assert.Equal(t, CodeSecondApproval, ErrorCodeOf(err), "should not skip the second approval")
+	_, err = c.ChangeStatus(ctx, "aspirin", "00004", "send", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not send medicine of an order")
+}
+
+func TestChangeHolder(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00001", Holder: "MedStore"}, AVAILABLE)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00002", Holder: "alice"}, RETURNED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00003", Holder: "MedStore", QuarantineNote: "recalled"}, QUARANTINED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00004", Holder: "MedStore"}, DESTROYED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00005", Holder: "alice", OrderID: "ORD0001"}, REQUESTED)
+
+	medicine, err := c.ChangeHolder(ctx, "aspirin", "00001", "Alice", "bob", "secret")
+	assert.Nil(t, err, "should change the holder of medicine outside the workflows")
+	assert.Equal(t, "alice", medicine.Holder, "should make the customer the holder")
+
+	for _, medNumber := range []string{"00002", "00003", "00004"} {
+		_, err = c.ChangeHolder(ctx, "aspirin", medNumber, "alice", "bob", "secret")
+		assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should keep returned, quarantined and destroyed medicine at the MedStore")
+	}
+	_, err = c.ChangeHolder(ctx, "aspirin", "00005", "bob", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not reassign medicine of an order")
+}
+
+func TestDelete(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00001", Holder: "MedStore"}, AVAILABLE)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00002", Holder: "alice", OrderID: "ORD0001"}, REQUESTED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00003", Holder: "alice", PrescriptionID: "RX0001"}, REQUESTED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "vicodin", MedNumber: "00004", Holder: "alice", Schedule: "II", FirstApprover: "regulator1"}, PENDING_SECOND_APPROVAL)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00005", Holder: "alice"}, RETURNED)
+	addTestMedicine(t, ctx, MedicalSupply{MedName: "aspirin", MedNumber: "00006", Holder: "MedStore", QuarantineNote: "recalled"}, QUARANTINED)
+
+	assert.Nil(t, c.Delete(ctx, "aspirin", "00001", "bob", "secret"), "should delete medicine outside the workflows")
+	medicine, _ := ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	assert.Nil(t, medicine, "should remove the medicine from the ledger")
+
+	err := c.Delete(ctx, "aspirin", "00002", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not delete medicine reserved for an order")
+	assert.Equal(t, "ORD0001", err.(*ContractError).Details["orderID"], "should name the order")
+	err = c.Delete(ctx, "aspirin", "00003", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not delete requested medicine holding prescription units")
+	err = c.Delete(ctx, "vicodin", "00004", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not delete medicine awaiting its second approval")
+	err = c.Delete(ctx, "aspirin", "00005", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should not delete returned medicine awaiting inspection")
+	err = c.Delete(ctx, "aspirin", "00006", "bob", "secret")
+	assert.Equal(t, CodeInvalidState, ErrorCodeOf(err), "should keep quarantined medicine as evidence")
+}
+
+func TestInitLedger(t *testing.T) {
+	ctx, _ := newRegulatorContext(t)
+	c := NewRegulatorContract()
+	assert.Nil(t, c.InitLedger(ctx, "bob", "secret"), "should add the base set of medicine")
+	medicine, _ := ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	medicine.Holder = "alice"
+	medicine.SetSend()
+	assert.Nil(t, ctx.GetMedicineList().UpdateMedicine(medicine), "should send the medicine")
+
+	assert.Nil(t, c.InitLedger(ctx, "bob", "secret"), "should skip the medicine already on the ledger")
+	medicine, _ = ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	assert.True(t, medicine.IsSend(), "should not overwrite live records")
+	assert.Equal(t, "alice", medicine.Holder, "should keep the holder")
+}
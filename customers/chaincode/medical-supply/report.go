@@ -0,0 +1,141 @@
+package medicalsupply
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InventoryLine - Defines the aggregated stock of a medicine in a state, the totals per state leave MedName empty
+// and the grand total leaves both empty. Unpriced counts the medicine whose price could not be read.
+type InventoryLine struct {
+	MedName         string `json:"medName,omitempty" metadata:",optional"`
+	State           string `json:"state,omitempty" metadata:",optional"`
+	Count           int    `json:"count"`
+	TotalValue      string `json:"totalValue"`
+	Unpriced        int    `json:"unpriced,omitempty" metadata:",optional"`
+	DistinctHolders int    `json:"distinctHolders"`
+	EarliestExpiry  string `json:"earliestExpiry,omitempty" metadata:",optional"`
+	LatestExpiry    string `json:"latestExpiry,omitempty" metadata:",optional"`
+}
+
+// InventoryReport - Defines the stock on the ledger aggregated by medicine name and state.
+type InventoryReport struct {
+	GeneratedAt string           `json:"generatedAt"`
+	Lines       []*InventoryLine `json:"lines"`
+	StateTotals []*InventoryLine `json:"stateTotals"`
+	Total       *InventoryLine   `json:"total"`
+}
+
+// ParsePrice - Parses a price in dollars such as $10 or $2.50 into cents, the dollar sign is optional. Other currencies
+// are rejected as the reports total all prices in dollars, as are signs, other characters and prices without whole
+// amount (e.g. .50).
+func ParsePrice(price string) (int64, error) {
+	amount := strings.TrimPrefix(strings.TrimSpace(price), "$")
+
+	whole, fraction := amount, ""
+	if dot := strings.Index(amount, "."); dot >= 0 {
+		whole, fraction = amount[:dot], amount[dot+1:]
+	}
+	if whole == "" || strings.Trim(whole, "0123456789") != "" || len(fraction) > 2 || strings.Trim(fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid price %s, expected e.g. $10 or $2.50", price)
+	}
+
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %s, expected e.g. $10 or $2.50", price)
+	}
+	return cents, nil
+}
+
+// FormatPrice - Formats cents as a price (e.g. $2.50).
+func FormatPrice(cents int64) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
+
+// inventoryTotal - Aggregates medicine into a single inventory line.
+type inventoryTotal struct {
+	line    *InventoryLine
+	value   int64
+	holders map[string]bool
+}
+
+// add - Adds a medicine to the line.
+func (total *inventoryTotal) add(ms *MedicalSupply) {
+	total.line.Count++
+	total.holders[ms.Holder] = true
+
+	cents, err := ParsePrice(ms.Price)
+	if err != nil {
+		total.line.Unpriced++
+	} else {
+		total.value += cents
+	}
+
+	// Dates in the ledger layout sort chronologically as strings.
+	if _, err := time.Parse(DateLayout, ms.Expiration); err == nil {
+		if total.line.EarliestExpiry == "" || ms.Expiration < total.line.EarliestExpiry {
+			total.line.EarliestExpiry = ms.Expiration
+		}
+		if ms.Expiration > total.line.LatestExpiry {
+			total.line.LatestExpiry = ms.Expiration
+		}
+	}
+}
+
+// finish - Fills in the total value and distinct holders of the line.
+func (total *inventoryTotal) finish() *InventoryLine {
+	total.line.TotalValue = FormatPrice(total.value)
+	total.line.DistinctHolders = len(total.holders)
+	return total.line
+}
+
+// inventoryKey - Identifies the line of a medicine name and state.
+type inventoryKey struct {
+	medName string
+	state   State
+}
+
+// NewInventoryReport - Aggregates the medicine by medicine name and state, lines are ordered by name and then by state.
+func NewInventoryReport(medicines []*MedicalSupply, generatedAt time.Time) *InventoryReport {
+	lines := make(map[inventoryKey]*inventoryTotal)
+	states := make(map[State]*inventoryTotal)
+	total := &inventoryTotal{line: &InventoryLine{}, holders: make(map[string]bool)}
+
+	for _, ms := range medicines {
+		key := inventoryKey{medName: ms.MedName, state: ms.GetState()}
+		if lines[key] == nil {
+			lines[key] = &inventoryTotal{line: &InventoryLine{MedName: ms.MedName, State: ms.GetState().String()}, holders: make(map[string]bool)}
+		}
+		if states[ms.GetState()] == nil {
+			states[ms.GetState()] = &inventoryTotal{line: &InventoryLine{State: ms.GetState().String()}, holders: make(map[string]bool)}
+		}
+		lines[key].add(ms)
+		states[ms.GetState()].add(ms)
+		total.add(ms)
+	}
+
+	var keys []inventoryKey
+	for key := range lines {
+		keys = append(keys, key)
+	}
+	sortResults(keys,
+		ascending(func(key inventoryKey) string { return key.medName }),
+		ascending(func(key inventoryKey) State { return key.state }))
+
+	report := &InventoryReport{GeneratedAt: generatedAt.UTC().Format(time.RFC3339), Lines: []*InventoryLine{}, StateTotals: []*InventoryLine{}}
+	for _, key := range keys {
+		report.Lines = append(report.Lines, lines[key].finish())
+	}
+	for state := AVAILABLE; state <= PENDING_SECOND_APPROVAL; state++ {
+		if states[state] != nil {
+			report.StateTotals = append(report.StateTotals, states[state].finish())
+		}
+	}
+	report.Total = total.finish()
+	return report
+}
@@ -0,0 +1,152 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+type ReturnState uint
+
+const (
+	// FILED state for when a customer has filed a return and it awaits inspection.
+	FILED ReturnState = iota + 1
+	// RESTOCKED state for when the inspected medicine has been put back on stock.
+	RESTOCKED
+	// DISCARDED state for when the inspected medicine has been destroyed.
+	DISCARDED
+)
+
+// String - Changes return state enum to string.
+func (state ReturnState) String() string {
+	names := []string{"FILED", "RESTOCKED", "DISCARDED"}
+
+	if state < FILED || state > DISCARDED {
+		return "UNKNOWN"
+	}
+	return names[state-1]
+}
+
+// ReturnClass - Class of the return states.
+const ReturnClass = "org.medstore.return"
+
+// CreateReturnKey - Creates a key for the return (e.g. Return:RET0001).
+func CreateReturnKey(returnID string) string {
+	return ledgerapi.MakeKey("Return", returnID)
+}
+
+// ReturnEvent - Audit record of a single step in the returns workflow.
+type ReturnEvent struct {
+	Step  string `json:"step"`
+	Actor string `json:"actor"`
+	Date  string `json:"date"`
+	TxID  string `json:"txID"`
+	Notes string `json:"notes"`
+}
+
+type medicineReturnAlias MedicineReturn
+type jsonMedicineReturn struct {
+	*medicineReturnAlias
+	State         ReturnState `json:"currentState"`
+	Class         string      `json:"class"`
+	Key           string      `json:"key"`
+	SchemaVersion int         `json:"schemaVersion"`
+}
+
+// MedicineReturn - Defines the return of a send medicine by a customer.
+// PricePaid and RefundAmount hold the data needed for refunding the customer.
+type MedicineReturn struct {
+	ReturnID      string        `json:"returnID"`
+	MedName       string        `json:"medName"`
+	MedNumber     string        `json:"medNumber"`
+	Customer      string        `json:"customer"`
+	Reason        string        `json:"reason"`
+	PricePaid     string        `json:"pricePaid"`
+	RefundAmount  string        `json:"refundAmount"`
+	Events        []ReturnEvent `json:"events"`
+	state         ReturnState   `metadata:"currentState"`
+	class         string        `metadata:"class"`
+	key           string        `metadata:"key"`
+	schemaVersion int           `metadata:"schemaVersion"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (mr MedicineReturn) MarshalJSON() ([]byte, error) {
+	jmr := jsonMedicineReturn{medicineReturnAlias: (*medicineReturnAlias)(&mr), State: mr.state, Class: ReturnClass, Key: CreateReturnKey(mr.ReturnID), SchemaVersion: schemaVersion(ReturnClass)}
+	return json.Marshal(&jmr)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (mr *MedicineReturn) UnmarshalJSON(data []byte) error {
+	jmr := jsonMedicineReturn{medicineReturnAlias: (*medicineReturnAlias)(mr)}
+
+	err := json.Unmarshal(data, &jmr)
+	if err != nil {
+		return err
+	}
+
+	mr.state = jmr.State
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// GetState - Returns the state.
+func (mr *MedicineReturn) GetState() ReturnState {
+	return mr.state
+}
+
+// SetFiled - Returns the state to FILED.
+func (mr *MedicineReturn) SetFiled() {
+	mr.state = FILED
+}
+
+// SetRestocked - Returns the state to RESTOCKED.
+func (mr *MedicineReturn) SetRestocked() {
+	mr.state = RESTOCKED
+}
+
+// SetDiscarded - Returns the state to DISCARDED.
+func (mr *MedicineReturn) SetDiscarded() {
+	mr.state = DISCARDED
+}
+
+// IsFiled - Returns true if state is FILED.
+func (mr *MedicineReturn) IsFiled() bool {
+	return mr.state == FILED
+}
+
+// AddEvent - Appends an audit record for a step of the returns workflow.
+func (mr *MedicineReturn) AddEvent(step string, actor string, date string, txID string, notes string) {
+	mr.Events = append(mr.Events, ReturnEvent{Step: step, Actor: actor, Date: date, TxID: txID, Notes: notes})
+}
+
+//-------------------------------------------------------//
+
+// GetSplitKey - Returns values which should be used to form key.
+func (mr *MedicineReturn) GetSplitKey() []string {
+	return []string{"Return", mr.ReturnID}
+}
+
+// Serialize - Formats the return as JSON bytes.
+func (mr *MedicineReturn) Serialize() ([]byte, error) {
+	return json.Marshal(mr)
+}
+
+// DeserializeReturn - Formats the return from JSON bytes.
+func DeserializeReturn(bytes []byte, mr *MedicineReturn) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(ReturnClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, mr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing return. %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package medicalsupply
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+// MaxMigrationPageSize - Maximum amount of states rewritten by a single MigrateStates transaction.
+const MaxMigrationPageSize = 1000
+
+// Schemas of the states keyed by class. Adding or retyping a field means appending an upcaster to the schema of its
+// class, which rewrites the JSON of the previous version. An upcaster changing checksummed fields of a medicine
+// has to update its checksum as well.
+var schemas = map[string]ledgerapi.Schema{
+	MedicineClass:     {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	TPMAuthClass:      {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	PrescriptionClass: {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	OrderClass:        {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	ReturnClass:       {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	DestructionClass:  {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	QuotaClass:        {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	SearchTermClass:   {Upcasters: []ledgerapi.Upcaster{unversioned}},
+	SearchEntryClass:  {Upcasters: []ledgerapi.Upcaster{unversioned}},
+}
+
+// unversioned - Upcasts states written before the schema version was stored, their fields are unchanged in version 2.
+func unversioned(fields map[string]interface{}) error {
+	return nil
+}
+
+// schemaVersion - Returns the schema version states of the class are written as.
+func schemaVersion(class string) int {
+	return schemas[class].Version()
+}
+
+// upcast - Rewrites the JSON of a state of the class to the latest schema version.
+func upcast(class string, data []byte) ([]byte, error) {
+	upcasted, _, err := schemas[class].Upcast(data)
+	return upcasted, err
+}
+
+// MigrationProgress - Defines the progress of rewriting the states to the latest schema versions.
+// Bookmark is passed to the next MigrateStates transaction and is empty once Done.
+type MigrationProgress struct {
+	Scanned  int    `json:"scanned"`
+	Migrated int    `json:"migrated"`
+	Skipped  int    `json:"skipped"`
+	Bookmark string `json:"bookmark"`
+	Done     bool   `json:"done"`
+}
+
+// migrateStates - Rewrites a page of states to the latest schema version of their class.
+// States of an unknown class or a newer schema version are skipped and left as they are.
+func migrateStates(statelist ledgerapi.StatePagerInterface, bookmark string, pageSize int) (*MigrationProgress, error) {
+	if pageSize < 1 || pageSize > MaxMigrationPageSize {
+		return nil, fmt.Errorf("page size should be between 1 and %d", MaxMigrationPageSize)
+	}
+	// Ledger keys contain null characters, the bookmark is encoded to pass it around safely.
+	after, err := base64.StdEncoding.DecodeString(bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bookmark %s", bookmark)
+	}
+
+	page, next, err := statelist.GetStatesPage(string(after), pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &MigrationProgress{Bookmark: base64.StdEncoding.EncodeToString([]byte(next)), Done: next == ""}
+	for _, kv := range page {
+		progress.Scanned++
+
+		var state struct {
+			Class string `json:"class"`
+		}
+		err = json.Unmarshal(kv.Value, &state)
+		schema, ok := schemas[state.Class]
+		if err != nil || !ok {
+			progress.Skipped++
+			continue
+		}
+
+		upcasted, version, err := schema.Upcast(kv.Value)
+		if err != nil {
+			progress.Skipped++
+			continue
+		}
+		if version == schema.Version() {
+			continue
+		}
+
+		err = statelist.PutStateData(kv.Key, upcasted)
+		if err != nil {
+			return nil, err
+		}
+		progress.Migrated++
+	}
+	return progress, nil
+}
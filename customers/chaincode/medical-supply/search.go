@@ -0,0 +1,351 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
+)
+
+// Fields of a medicine which are indexed for searching, matches on the name weigh more than matches on the disease.
+const (
+	SearchFieldName    = "name"
+	SearchFieldDisease = "disease"
+)
+
+var searchFieldWeights = map[string]int{SearchFieldName: 2, SearchFieldDisease: 1}
+
+// SearchTermClass - Class of the search term states.
+const SearchTermClass = "org.medstore.searchterm"
+
+// SearchEntryClass - Class of the search entry states.
+const SearchEntryClass = "org.medstore.searchentry"
+
+// CreateSearchTermKey - Creates a key for a term of the search index (e.g. SearchTerm:aspirin).
+func CreateSearchTermKey(token string) string {
+	return ledgerapi.MakeKey("SearchTerm", token)
+}
+
+// CreateSearchEntryKey - Creates a key for an entry of the search index (e.g. Search:aspirin:name:aspirin:00001).
+func CreateSearchEntryKey(token string, field string, medName string, medNumber string) string {
+	return ledgerapi.MakeKey("Search", token, field, medName, medNumber)
+}
+
+type searchTermAlias SearchTerm
+type jsonSearchTerm struct {
+	*searchTermAlias
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// SearchTerm - Defines a token occurring in the search index, the terms form the vocabulary matched against a query
+// so only the entries of matching terms have to be read.
+type SearchTerm struct {
+	Token         string `json:"token"`
+	class         string `metadata:"class"`
+	key           string `metadata:"key"`
+	schemaVersion int    `metadata:"schemaVersion"`
+}
+
+type searchEntryAlias SearchEntry
+type jsonSearchEntry struct {
+	*searchEntryAlias
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// SearchEntry - Defines an available medicine containing a token in its name or disease.
+type SearchEntry struct {
+	Token         string `json:"token"`
+	Field         string `json:"field"`
+	MedName       string `json:"medName"`
+	MedNumber     string `json:"medNumber"`
+	class         string `metadata:"class"`
+	key           string `metadata:"key"`
+	schemaVersion int    `metadata:"schemaVersion"`
+}
+
+// SearchMatch - Defines the score of a medicine for a query and the indexed tokens it matched on.
+type SearchMatch struct {
+	MedName   string   `json:"medName"`
+	MedNumber string   `json:"medNumber"`
+	Score     int      `json:"score"`
+	Matched   []string `json:"matched"`
+}
+
+// SearchResult - Defines an available medicine found by a search, best matches have the highest score.
+type SearchResult struct {
+	Score    int            `json:"score"`
+	Matched  []string       `json:"matched"`
+	Medicine *MedicalSupply `json:"medicine"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (term SearchTerm) MarshalJSON() ([]byte, error) {
+	jterm := jsonSearchTerm{searchTermAlias: (*searchTermAlias)(&term), Class: SearchTermClass, Key: CreateSearchTermKey(term.Token), SchemaVersion: schemaVersion(SearchTermClass)}
+	return json.Marshal(&jterm)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (term *SearchTerm) UnmarshalJSON(data []byte) error {
+	jterm := jsonSearchTerm{searchTermAlias: (*searchTermAlias)(term)}
+	return json.Unmarshal(data, &jterm)
+}
+
+// GetSplitKey - Returns values which should be used to form key.
+func (term *SearchTerm) GetSplitKey() []string {
+	return []string{"SearchTerm", term.Token}
+}
+
+// Serialize - Formats the search term as JSON bytes.
+func (term *SearchTerm) Serialize() ([]byte, error) {
+	return json.Marshal(term)
+}
+
+// DeserializeSearchTerm - Formats the search term from JSON bytes.
+func DeserializeSearchTerm(bytes []byte, term *SearchTerm) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(SearchTermClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, term)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing search term. %s", err.Error())
+	}
+
+	return nil
+}
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (entry SearchEntry) MarshalJSON() ([]byte, error) {
+	jentry := jsonSearchEntry{searchEntryAlias: (*searchEntryAlias)(&entry), Class: SearchEntryClass, Key: CreateSearchEntryKey(entry.Token, entry.Field, entry.MedName, entry.MedNumber), SchemaVersion: schemaVersion(SearchEntryClass)}
+	return json.Marshal(&jentry)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (entry *SearchEntry) UnmarshalJSON(data []byte) error {
+	jentry := jsonSearchEntry{searchEntryAlias: (*searchEntryAlias)(entry)}
+	return json.Unmarshal(data, &jentry)
+}
+
+// GetSplitKey - Returns values which should be used to form key.
+func (entry *SearchEntry) GetSplitKey() []string {
+	return []string{"Search", entry.Token, entry.Field, entry.MedName, entry.MedNumber}
+}
+
+// Serialize - Formats the search entry as JSON bytes.
+func (entry *SearchEntry) Serialize() ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+// DeserializeSearchEntry - Formats the search entry from JSON bytes.
+func DeserializeSearchEntry(bytes []byte, entry *SearchEntry) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(SearchEntryClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, entry)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing search entry. %s", err.Error())
+	}
+
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// Tokenize - Splits text into distinct lower case tokens of letters and digits (e.g. "Aspirin 500" into aspirin and 500).
+func Tokenize(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// NewSearchEntries - Returns the search index entries of a medicine.
+func NewSearchEntries(ms *MedicalSupply) []*SearchEntry {
+	var entries []*SearchEntry
+	for _, field := range []string{SearchFieldName, SearchFieldDisease} {
+		text := ms.MedName
+		if field == SearchFieldDisease {
+			text = ms.Disease
+		}
+		for _, token := range Tokenize(text) {
+			entries = append(entries, &SearchEntry{Token: token, Field: field, MedName: ms.MedName, MedNumber: ms.MedNumber})
+		}
+	}
+	return entries
+}
+
+// EditDistance - Returns the amount of insertions, deletions, substitutions and transpositions of adjacent
+// characters needed to change a into b.
+func EditDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = rows[i-1][j-1] + cost
+			if rows[i-1][j]+1 < rows[i][j] {
+				rows[i][j] = rows[i-1][j] + 1
+			}
+			if rows[i][j-1]+1 < rows[i][j] {
+				rows[i][j] = rows[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && rows[i-2][j-2]+1 < rows[i][j] {
+				rows[i][j] = rows[i-2][j-2] + 1
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
+
+// maxEdits - Returns the amount of typos tolerated in a query token, short tokens have to match exactly.
+func maxEdits(token string) int {
+	switch length := len([]rune(token)); {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	}
+	return 2
+}
+
+// matchScore - Scores how well a query token matches an indexed token: 4 for an exact match, 3 for a prefix
+// of at least two characters and 2 or 1 for a match with one or two typos. Zero means no match.
+func matchScore(query string, token string) int {
+	switch {
+	case query == token:
+		return 4
+	case len([]rune(query)) >= 2 && strings.HasPrefix(token, query):
+		return 3
+	}
+
+	distance := EditDistance(query, token)
+	if distance > maxEdits(query) {
+		return 0
+	}
+	return 3 - distance
+}
+
+// MatchingTerms - Returns the terms of the search index which match a token of the query, in order.
+func MatchingTerms(query string, terms []string) []string {
+	var matching []string
+	for _, term := range terms {
+		for _, token := range Tokenize(query) {
+			if matchScore(token, term) > 0 {
+				matching = append(matching, term)
+				break
+			}
+		}
+	}
+	sort.Strings(matching)
+	return matching
+}
+
+// RankSearchEntries - Scores every medicine in the entries by summing the best weighted match of each query token,
+// the best matches come first and ties are ordered by key.
+func RankSearchEntries(query string, entries []*SearchEntry) []*SearchMatch {
+	type medicine struct {
+		medName   string
+		medNumber string
+	}
+	best := make(map[medicine]map[string]int)
+	matched := make(map[medicine]map[string]bool)
+	for _, entry := range entries {
+		med := medicine{entry.MedName, entry.MedNumber}
+		for _, token := range Tokenize(query) {
+			score := matchScore(token, entry.Token) * searchFieldWeights[entry.Field]
+			if score == 0 {
+				continue
+			}
+			if best[med] == nil {
+				best[med] = make(map[string]int)
+				matched[med] = make(map[string]bool)
+			}
+			if score > best[med][token] {
+				best[med][token] = score
+			}
+			matched[med][entry.Token] = true
+		}
+	}
+
+	matches := []*SearchMatch{}
+	for med, scores := range best {
+		match := &SearchMatch{MedName: med.medName, MedNumber: med.medNumber}
+		for _, score := range scores {
+			match.Score += score
+		}
+		for token := range matched[med] {
+			match.Matched = append(match.Matched, token)
+		}
+		sort.Strings(match.Matched)
+		matches = append(matches, match)
+	}
+
+	sortResults(matches,
+		descending(func(match *SearchMatch) int { return match.Score }),
+		ascending(func(match *SearchMatch) string { return CreateMedicalKey(match.MedName, match.MedNumber) }))
+	return matches
+}
+
+// searchMedicine - Looks the query up in the search index and returns the ranked available medicine.
+func searchMedicine(medicineList ListInterface, query string) ([]*SearchResult, error) {
+	if len(Tokenize(query)) == 0 {
+		return nil, fmt.Errorf("search query should contain at least one letter or digit")
+	}
+
+	terms, err := medicineList.GetSearchTerms()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve search terms from ledger: %s", err)
+	}
+
+	// Only read the entries of the terms matching the query.
+	var entries []*SearchEntry
+	for _, term := range MatchingTerms(query, terms) {
+		termEntries, err := medicineList.GetSearchEntries(term)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve search entries from ledger: %s", err)
+		}
+		entries = append(entries, termEntries...)
+	}
+
+	results := []*SearchResult{}
+	for _, match := range RankSearchEntries(query, entries) {
+		medicine, err := medicineList.GetMedicine(match.MedName, match.MedNumber)
+		if err != nil {
+			continue
+		}
+		// The index only holds available medicine, but skip medicine whose checksum fails just like the other searches.
+		if !medicine.IsAvailable() || medicine.VerifyChecksum() != nil {
+			continue
+		}
+		results = append(results, &SearchResult{Score: match.Score, Matched: match.Matched, Medicine: medicine})
+	}
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"aspirin", "500"}, Tokenize("Aspirin 500"), "should split on non-alphanumeric characters and lower case")
+	assert.Equal(t, []string{"pain", "management"}, Tokenize("pain-management, pain"), "should only return distinct tokens")
+	assert.Empty(t, Tokenize(" - "), "should be empty without letters or digits")
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, EditDistance("aspirin", "aspirin"), "should be zero for equal strings")
+	assert.Equal(t, 1, EditDistance("asprin", "aspirin"), "should count insertions")
+	assert.Equal(t, 1, EditDistance("apsirin", "aspirin"), "should count a transposition as a single edit")
+	assert.Equal(t, 2, EditDistance("ibuprofin", "ibuprofen2"), "should count substitutions and insertions")
+	assert.Equal(t, 3, EditDistance("", "abc"), "should count all insertions from an empty string")
+}
+
+func TestMatchScore(t *testing.T) {
+	assert.Equal(t, 4, matchScore("aspirin", "aspirin"), "should score exact matches highest")
+	assert.Equal(t, 3, matchScore("asp", "aspirin"), "should match prefixes")
+	assert.Equal(t, 2, matchScore("asprin", "aspirin"), "should tolerate a typo")
+	assert.Equal(t, 1, matchScore("ibuprofin", "ibuprofen2"), "should tolerate two typos in long tokens")
+	assert.Equal(t, 0, matchScore("flu", "flo"), "should not tolerate typos in short tokens")
+	assert.Equal(t, 0, matchScore("a", "aspirin"), "should not match single character prefixes")
+
+	assert.Equal(t, []string{"aspirin", "fever"}, MatchingTerms("Asprin fever", []string{"pain", "fever", "aspirin", "vicodin"}), "should return the matching terms in order")
+}
+
+func TestRankSearchEntries(t *testing.T) {
+	entries := []*SearchEntry{
+		{Token: "aspirin", Field: SearchFieldName, MedName: "aspirin", MedNumber: "00002"},
+		{Token: "aspirin", Field: SearchFieldName, MedName: "aspirin", MedNumber: "00001"},
+		{Token: "fever", Field: SearchFieldDisease, MedName: "aspirin", MedNumber: "00001"},
+		{Token: "fever", Field: SearchFieldDisease, MedName: "paracetamol", MedNumber: "00003"},
+		{Token: "aspirine", Field: SearchFieldDisease, MedName: "placebo", MedNumber: "00004"},
+	}
+
+	matches := RankSearchEntries("aspirin fever", entries)
+	assert.Equal(t, []*SearchMatch{
+		{MedName: "aspirin", MedNumber: "00001", Score: 12, Matched: []string{"aspirin", "fever"}},
+		{MedName: "aspirin", MedNumber: "00002", Score: 8, Matched: []string{"aspirin"}},
+		{MedName: "paracetamol", MedNumber: "00003", Score: 4, Matched: []string{"fever"}},
+		{MedName: "placebo", MedNumber: "00004", Score: 3, Matched: []string{"aspirine"}},
+	}, matches, "should rank by the summed weighted scores and break ties by key")
+	assert.Empty(t, RankSearchEntries("vicodin", entries), "should be empty when nothing matches")
+}
+
+func TestSearchMedicine(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	list := ctx.GetMedicineList()
+
+	medicine := func(name string, number string, disease string) *MedicalSupply {
+		ms := &MedicalSupply{MedName: name, MedNumber: number, Disease: disease, Expiration: "2030.01.01", Price: "$2", Holder: "MedStore"}
+		ms.SetAvailable()
+		ms.InitialiseChecksum()
+		return ms
+	}
+	stub.MockTransactionStart("tx1")
+	assert.Nil(t, list.AddMedicine(medicine("aspirin", "00001", "fever")), "should add medicine")
+	assert.Nil(t, list.AddMedicine(medicine("aspirin", "00002", "fever")), "should add medicine")
+	assert.Nil(t, list.AddMedicine(medicine("paracetamol", "00003", "fever")), "should add medicine")
+	assert.Nil(t, list.AddMedicine(medicine("vicodin", "00004", "pain management")), "should add medicine")
+	stub.MockTransactionEnd("tx1")
+
+	stub.MockTransactionStart("tx2")
+	send, _ := list.GetMedicine("aspirin", "00002")
+	send.SetSend()
+	send.InitialiseChecksum()
+	assert.Nil(t, list.UpdateMedicine(send), "should update medicine")
+	assert.Nil(t, list.DeleteMedicine("paracetamol", "00003"), "should delete medicine")
+	stub.MockTransactionEnd("tx2")
+
+	results, err := searchMedicine(list, "Asprin 500")
+	assert.Nil(t, err, "should not error on search")
+	assert.Len(t, results, 1, "should tolerate typos and only find available medicine")
+	assert.Equal(t, "00001", results[0].Medicine.MedNumber, "should return the matching medicine")
+
+	results, _ = searchMedicine(list, "fever")
+	assert.Len(t, results, 1, "should look up the disease and skip deleted medicine")
+
+	results, _ = searchMedicine(list, "pain")
+	assert.Equal(t, "vicodin", results[0].Medicine.MedName, "should match the start of a disease")
+
+	_, err = searchMedicine(list, "?")
+	assert.EqualError(t, err, "search query should contain at least one letter or digit", "should reject empty queries")
+
+	entries, _ := list.GetSearchEntries("aspirin")
+	assert.Len(t, entries, 1, "should only keep available medicine in the index")
+}
@@ -10,6 +10,9 @@ import (
 	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/customers/chaincode/ledger-api"
 )
 
+// TPMAuthClass - Class of the tpm authentication states.
+const TPMAuthClass = "org.medstore.tpmauth"
+
 // createTPMledgerKey - Creates a key for the TPM Authentication.
 func createTPMledgerKey(holder string) string {
 	return ledgerapi.MakeKey("TPMAUTH", holder)
@@ -18,22 +21,24 @@ func createTPMledgerKey(holder string) string {
 type tpmAuthAlias TPMAuth
 type jsonTPMAuth struct {
 	*tpmAuthAlias
-	Class string `json:"class"`
-	Key   string `json:"key"`
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
 }
 
 type TPMAuth struct {
-	Holder string `json:"holder"`
-	TPMKey string `json:"tpmkey"`
-	class  string `metadata:"class"`
-	key    string `metadata:"key"`
+	Holder        string `json:"holder"`
+	TPMKey        string `json:"tpmkey"`
+	class         string `metadata:"class"`
+	key           string `metadata:"key"`
+	schemaVersion int    `metadata:"schemaVersion"`
 }
 
 //-------------------------------------------------------//
 
 // MarshalJSON - Special handler for managing JSON marshalling.
 func (auth TPMAuth) MarshalJSON() ([]byte, error) {
-	jauth := jsonTPMAuth{tpmAuthAlias: (*tpmAuthAlias)(&auth), Class: "org.medstore.tpmauth", Key: createTPMledgerKey(auth.Holder)}
+	jauth := jsonTPMAuth{tpmAuthAlias: (*tpmAuthAlias)(&auth), Class: TPMAuthClass, Key: createTPMledgerKey(auth.Holder), SchemaVersion: schemaVersion(TPMAuthClass)}
 	return json.Marshal(&jauth)
 }
 
@@ -60,7 +65,11 @@ func (auth *TPMAuth) Serialize() ([]byte, error) {
 
 // Deserialize - Formats the tpm authentication from JSON bytes.
 func DeserializeTPM(bytes []byte, auth *TPMAuth) error {
-	err := json.Unmarshal(bytes, auth)
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(TPMAuthClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, auth)
+	}
 
 	if err != nil {
 		return fmt.Errorf("error deserializing tpm authentication. %s", err.Error())
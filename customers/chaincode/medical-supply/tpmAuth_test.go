@@ -23,7 +23,7 @@ func TestSerializeTPM(t *testing.T) {
 	auth := new(TPMAuth)
 	auth.Holder = "hashedusername"
 	auth.TPMKey = "hashedkey"
-	correctJson := `{"holder":"hashedusername","tpmkey":"hashedkey","class":"org.medstore.tpmauth","key":"TPMAUTH:hashedusername"}`
+	correctJson := `{"holder":"hashedusername","tpmkey":"hashedkey","class":"org.medstore.tpmauth","key":"TPMAUTH:hashedusername","schemaVersion":2}`
 
 	bytes, err := auth.Serialize()
 	assert.Nil(t, err, "should not error on serialize")
@@ -0,0 +1,148 @@
+package medicalsupply
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIdentity - Client identity of an organisation with an optional role, other functions of the identity are not supported.
+type fakeIdentity struct {
+	cid.ClientIdentity
+	id    string
+	mspID string
+	role  string
+}
+
+func (fi *fakeIdentity) GetID() (string, error) {
+	return fi.id, nil
+}
+
+func (fi *fakeIdentity) GetMSPID() (string, error) {
+	return fi.mspID, nil
+}
+
+func (fi *fakeIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	if attrName != "role" || fi.role != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attrName, fi.role, attrValue)
+	}
+	return nil
+}
+
+func TestRuleSetsCoverContract(t *testing.T) {
+	inherited := reflect.TypeOf(new(contractapi.Contract))
+	contracts := []interface{}{NewCustomerContract(), NewRegulatorContract(), NewAuthContract()}
+
+	transactions := 0
+	for _, c := range contracts {
+		contract := reflect.TypeOf(c)
+		for i := 0; i < contract.NumMethod(); i++ {
+			method := contract.Method(i)
+			if _, ok := inherited.MethodByName(method.Name); ok {
+				continue
+			}
+
+			// Arguments following the receiver and the transaction context.
+			args := method.Type.NumIn() - 2
+			ruleSet, ok := ruleSets[method.Name]
+			assert.True(t, ok, "should have a rule set for %s", method.Name)
+			assert.Len(t, ruleSet, args, "should have rules for every argument of %s", method.Name)
+			transactions++
+		}
+	}
+	assert.Len(t, ruleSets, transactions, "should only have rule sets for transactions")
+
+	assert.Equal(t, "no rule set for the 1 arguments of Unknown", validate("Unknown", "a").(*ContractError).Message, "should fail for transactions without rule set")
+	assert.Equal(t, "no rule set for the 1 arguments of Delete", validate("Delete", "aspirin").(*ContractError).Message, "should fail for the wrong amount of arguments")
+}
+
+func TestValidate(t *testing.T) {
+	err := validate("Issue", "aspirin", "00001", "pain", "2022.05.09", "$10", false, "ii", "regulator", "tpmkey")
+	assert.Nil(t, err, "should accept valid arguments")
+
+	err = validate("Issue", " ", "00:01", "pain\x00", "09-05-2022", "ten", false, "VI", "reg\x00ulator", "")
+	ce, ok := err.(*ContractError)
+	assert.True(t, ok, "should return a contract error")
+	assert.Equal(t, CodeInvalidArgument, ce.Code, "should return the code of invalid arguments")
+	assert.Equal(t, []*FieldError{
+		{Field: "medName", Message: "is required"},
+		{Field: "medNumber", Message: "should not contain ':' or control characters"},
+		{Field: "disease", Message: "should be valid UTF-8 without null characters"},
+		{Field: "expiration", Message: "should be a date formatted as 2006.01.02"},
+		{Field: "price", Message: "should be a price, e.g. $10 or $2.50"},
+		{Field: "schedule", Message: "should be one of I, II, III, IV, V"},
+		{Field: "user", Message: "should not contain ':' or control characters"},
+		{Field: "tpmkey", Message: "is required"},
+	}, ce.Fields, "should return the first failing rule of every invalid field in argument order")
+
+	err = validate("SetQuotaRule", "Q1", "region", "aspirin", -1, 0, "regulator", "tpmkey")
+	assert.Equal(t, "invalid SetQuotaRule: scope should be one of medicine, category, schedule; maxUnits should be at least 0; periodDays should be at least 1", err.(*ContractError).Message, "should join the field errors")
+
+	err = validate("RequestReturn", "R1", "aspirin", strings.Repeat("0", MaxKeyPartLength+1), "broken", "customer", "tpmkey")
+	assert.Equal(t, "invalid RequestReturn: medNumber should be at most 128 characters", err.(*ContractError).Message, "should limit the length")
+
+	err = validate("PlaceOrder", "O1", `[{"medName":"aspirin"`, "customer", "tpmkey")
+	assert.JSONEq(t, `{"code":"INVALID_ARGUMENT","message":"invalid PlaceOrder: lines should be valid JSON","fields":[{"field":"lines","message":"should be valid JSON"}]}`, err.Error(), "should serialize the field errors")
+
+	err = validate("QueryMedicines", "", "regulator", "tpmkey")
+	assert.Nil(t, err, "should accept empty optional arguments")
+}
+
+func TestIssueExisting(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&fakeIdentity{mspID: "Org2MSP"})
+	auth := NewAuthContract()
+	customer := NewCustomerContract()
+	regulator := NewRegulatorContract()
+
+	stub.MockTransactionStart("tx1")
+	regulatorKey, err := auth.TPMKeyGen(ctx, "regulator")
+	assert.Nil(t, err, "should register the regulator")
+	customerKey, err := auth.TPMKeyGen(ctx, "customer")
+	assert.Nil(t, err, "should register the customer")
+	_, err = regulator.Issue(ctx, "Aspirin", "00001", "pain", "2022.05.09", "$10", false, "", "regulator", regulatorKey)
+	assert.Nil(t, err, "should issue new medicine")
+	stub.MockTransactionEnd("tx1")
+
+	stub.MockTransactionStart("tx2")
+	_, err = customer.Request(ctx, "aspirin", "00001", "customer", customerKey)
+	assert.Nil(t, err, "should request the medicine")
+	stub.MockTransactionEnd("tx2")
+
+	stub.MockTransactionStart("tx3")
+	_, err = regulator.Issue(ctx, "aspirin", "00001", "fever", "2023.01.01", "$1", false, "", "regulator", regulatorKey)
+	assert.Equal(t, CodeAlreadyExists, ErrorCodeOf(err), "should not overwrite existing medicine")
+	assert.Equal(t, "invalid Issue: medNumber is already in use by medicine aspirin:00001", err.(*ContractError).Message, "should name the field in use")
+	stub.MockTransactionEnd("tx3")
+
+	medicine, _ := ctx.GetMedicineList().GetMedicine("aspirin", "00001")
+	assert.True(t, medicine.IsRequested(), "should keep the requested medicine")
+	assert.Equal(t, "customer", medicine.Holder, "should keep the holder")
+
+	_, err = regulator.Issue(ctx, "aspirin", "0000:1", "pain", "2022.05.09", "$10", false, "", "regulator", regulatorKey)
+	assert.Equal(t, "invalid Issue: medNumber should not contain ':' or control characters", err.(*ContractError).Message, "should validate before checking access rights")
+}
+
+func TestIssueFromGS1Keys(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	regulator := NewRegulatorContract()
+
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	_, err := regulator.IssueFromGS1(ctx, "(01)09506000134352(17)240200(10)ABC123(21)0000:1", "aspirin", "pain", "$10", false, "", "regulator", "tpmkey")
+	assert.Equal(t, "invalid IssueFromGS1: elementString serial (21) should not contain ':' or control characters", err.(*ContractError).Message,
+		"should apply the rules of medicine numbers to the serial number")
+	_, err = regulator.IssueFromGS1(ctx, "(01)09506000134352(17)240200(10)ABC:123(21)00001", "aspirin", "pain", "$10", false, "", "regulator", "tpmkey")
+	assert.Equal(t, "invalid IssueFromGS1: elementString lot (10) should not contain ':' or control characters", err.(*ContractError).Message,
+		"should apply the rules of keys to the lot")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"medical-supply/client"
+)
+
+// Configuration of the expiry alerts, thresholds are per medicine name with * as default for all other medicine.
+type alertConfig struct {
+	Days       int
+	Thresholds map[string]int
+	Sinks      []string
+	WebhookURL string
+	StateFile  string
+}
+
+// Parses thresholds such as "aspirin=5,*=1", a medicine without threshold never raises an alert.
+func parseThresholds(value string) (map[string]int, error) {
+	thresholds := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid threshold %s, expected name=units (e.g. aspirin=5 or *=1)", part)
+		}
+		units, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil || units <= 0 {
+			return nil, fmt.Errorf("invalid threshold %s, units should be a positive number", part)
+		}
+		thresholds[strings.ToLower(strings.TrimSpace(pair[0]))] = units
+	}
+	return thresholds, nil
+}
+
+// Returns the threshold of a medicine, falling back to the default threshold.
+func (config alertConfig) threshold(medName string) (int, bool) {
+	if units, ok := config.Thresholds[medName]; ok {
+		return units, true
+	}
+	units, ok := config.Thresholds["*"]
+	return units, ok
+}
+
+// Returns an alert for every medicine whose expiring stock reached its threshold since the previous check,
+// so a scheduled check only alerts once until the stock drops below the threshold again.
+func crossedThresholds(forecast []*client.ExpiryGroup, previous map[string]int, config alertConfig) []client.ExpiryAlert {
+	var alerts []client.ExpiryAlert
+	for _, group := range forecast {
+		threshold, ok := config.threshold(group.MedName)
+		if !ok || group.Count < threshold || previous[group.MedName] >= threshold {
+			continue
+		}
+		alerts = append(alerts, client.ExpiryAlert{
+			MedName:        group.MedName,
+			Count:          group.Count,
+			Threshold:      threshold,
+			Days:           config.Days,
+			EarliestExpiry: group.EarliestExpiry,
+		})
+	}
+	return alerts
+}
+
+// Sends the alerts to the configured sinks, raiseEvent emits an alert as chaincode event.
+func sendAlerts(alerts []client.ExpiryAlert, config alertConfig, raiseEvent func(client.ExpiryAlert) error) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	for _, sink := range config.Sinks {
+		switch sink {
+		case "log":
+			for _, alert := range alerts {
+				log.Printf("EXPIRY ALERT: %d unit(s) of %s expire within %d day(s) (threshold %d), earliest on %s",
+					alert.Count, alert.MedName, alert.Days, alert.Threshold, alert.EarliestExpiry)
+			}
+		case "webhook":
+			body, err := json.Marshal(alerts)
+			if err != nil {
+				return err
+			}
+			response, err := http.Post(config.WebhookURL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				return fmt.Errorf("could not call webhook: %v", err)
+			}
+			response.Body.Close()
+			if response.StatusCode >= 300 {
+				return fmt.Errorf("webhook responded with %s", response.Status)
+			}
+		case "event":
+			for _, alert := range alerts {
+				err := raiseEvent(alert)
+				if err != nil {
+					return fmt.Errorf("could not raise expiry alert event: %v", err)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown alert sink %s, expected log, webhook or event", sink)
+		}
+	}
+	return nil
+}
+
+// Reads the counts of the previous check, a missing file means there was no previous check.
+func loadAlertState(filename string) (map[string]int, error) {
+	state := make(map[string]int)
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// Stores the counts of this check for the next one.
+func saveAlertState(filename string, forecast []*client.ExpiryGroup) error {
+	state := make(map[string]int)
+	for _, group := range forecast {
+		state[group.MedName] = group.Count
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// Runs a single expiry check: retrieves the forecast, alerts on crossed thresholds and stores the counts.
+func checkExpiry(medstore *client.Client, config alertConfig) error {
+	log.Println("--> Evaluate Transaction: ExpiryForecast, function shows available medicine expiring soon.")
+	forecast, err := medstore.ExpiryForecast(context.Background(), config.Days)
+	if err != nil {
+		return fmt.Errorf("failed to Evaluate transaction: %v", err)
+	}
+
+	previous, err := loadAlertState(config.StateFile)
+	if err != nil {
+		return fmt.Errorf("could not read previous expiry check: %v", err)
+	}
+	raiseEvent := func(alert client.ExpiryAlert) error {
+		log.Println("--> Submit Transaction: RaiseExpiryAlert, function emits an ExpiryAlert event.")
+		return medstore.RaiseExpiryAlert(context.Background(), alert)
+	}
+	err = sendAlerts(crossedThresholds(forecast, previous, config), config, raiseEvent)
+	if err != nil {
+		return err
+	}
+	return saveAlertState(config.StateFile, forecast)
+}
+
+// Handling regulators wanting to see which available medicine expires soon.
+func expiryForecast(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Amount of days (e.g. 30):")
+	days := readNumber(scanner, "amount of days")
+
+	log.Println("--> Evaluate Transaction: ExpiryForecast, function shows available medicine expiring soon.")
+	forecast, err := medstore.ExpiryForecast(context.Background(), days)
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(forecast), forecast)
+}
+
+// Handling regulators watching expiring stock, runs once (e.g. from cron) or repeatedly at an interval.
+func expiryAlerts(medstore *client.Client, scanner *bufio.Scanner) {
+	config := alertConfig{Days: 30, Thresholds: map[string]int{"*": 1}, Sinks: []string{"log"}, StateFile: "expiry-alerts.json"}
+
+	log.Println("Amount of days (default 30):")
+	scanner.Scan()
+	if days := scanner.Text(); days != "" {
+		value, err := strconv.Atoi(days)
+		if err != nil || value < 0 {
+			log.Fatalf("\nInvalid amount of days: %s", days)
+		}
+		config.Days = value
+	}
+	log.Println("Thresholds in units per medicine (default *=1, e.g. aspirin=5,*=2):")
+	scanner.Scan()
+	if thresholds := scanner.Text(); thresholds != "" {
+		value, err := parseThresholds(thresholds)
+		if err != nil {
+			log.Fatalf("\n%v", err)
+		}
+		config.Thresholds = value
+	}
+	log.Println("Alert sinks (default log, e.g. log,webhook,event):")
+	scanner.Scan()
+	if sinks := scanner.Text(); sinks != "" {
+		config.Sinks = strings.Split(strings.ReplaceAll(sinks, " ", ""), ",")
+	}
+	for _, sink := range config.Sinks {
+		if sink == "webhook" {
+			log.Println("Webhook URL (e.g. https://hooks.example.com/expiry):")
+			scanner.Scan()
+			config.WebhookURL = scanner.Text()
+		}
+	}
+	log.Println("Interval between checks (e.g. 24h, leave empty to check once):")
+	scanner.Scan()
+	interval := scanner.Text()
+
+	if interval == "" {
+		err := checkExpiry(medstore, config)
+		if err != nil {
+			log.Fatalf("\nExpiry check failed: %v", err)
+		}
+		return
+	}
+
+	every, err := time.ParseDuration(interval)
+	if err != nil || every <= 0 {
+		log.Fatalf("\nInvalid interval: %s", interval)
+	}
+	for {
+		// A failed check is retried at the next interval.
+		err := checkExpiry(medstore, config)
+		if err != nil {
+			log.Printf("Expiry check failed: %v", err)
+		}
+		time.Sleep(every)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := parseThresholds("Aspirin=5, *=1")
+	assert.Nil(t, err, "should parse thresholds")
+	assert.Equal(t, map[string]int{"aspirin": 5, "*": 1}, thresholds, "should map medicine names to units")
+
+	_, err = parseThresholds("aspirin")
+	assert.EqualError(t, err, "invalid threshold aspirin, expected name=units (e.g. aspirin=5 or *=1)", "should require units")
+
+	_, err = parseThresholds("aspirin=0")
+	assert.NotNil(t, err, "should require positive units")
+}
+
+func TestCrossedThresholds(t *testing.T) {
+	config := alertConfig{Days: 30, Thresholds: map[string]int{"aspirin": 3, "*": 1}}
+	forecast := []*client.ExpiryGroup{
+		{MedName: "vicodin", Count: 1, EarliestExpiry: "2022.02.20"},
+		{MedName: "aspirin", Count: 2, EarliestExpiry: "2022.03.01"},
+		{MedName: "lipitor", Count: 4, EarliestExpiry: "2022.03.05"},
+	}
+
+	alerts := crossedThresholds(forecast, map[string]int{"lipitor": 2}, config)
+	assert.Equal(t, []client.ExpiryAlert{{MedName: "vicodin", Count: 1, Threshold: 1, Days: 30, EarliestExpiry: "2022.02.20"}}, alerts,
+		"should only alert for medicine which crossed its threshold since the previous check")
+
+	config.Thresholds = map[string]int{"aspirin": 3}
+	assert.Empty(t, crossedThresholds(forecast, nil, config), "should not alert for medicine without threshold")
+}
+
+func TestSendAlertsWebhookAndEvent(t *testing.T) {
+	var received []client.ExpiryAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	var events []client.ExpiryAlert
+	alerts := []client.ExpiryAlert{{MedName: "aspirin", Count: 3, Threshold: 3, Days: 30, EarliestExpiry: "2022.03.01"}}
+	config := alertConfig{Sinks: []string{"log", "webhook", "event"}, WebhookURL: server.URL}
+	err := sendAlerts(alerts, config, func(alert client.ExpiryAlert) error {
+		events = append(events, alert)
+		return nil
+	})
+	assert.Nil(t, err, "should send alerts to all sinks")
+	assert.Equal(t, alerts, received, "should post the alerts to the webhook")
+	assert.Equal(t, alerts, events, "should raise an event per alert")
+
+	err = sendAlerts(alerts, alertConfig{Sinks: []string{"sms"}}, nil)
+	assert.EqualError(t, err, "unknown alert sink sms, expected log, webhook or event", "should reject unknown sinks")
+}
+
+func TestAlertState(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "expiry-alerts.json")
+
+	state, err := loadAlertState(filename)
+	assert.Nil(t, err, "should not error without previous check")
+	assert.Empty(t, state, "should be empty without previous check")
+
+	err = saveAlertState(filename, []*client.ExpiryGroup{{MedName: "aspirin", Count: 2}})
+	assert.Nil(t, err, "should store the counts")
+	state, err = loadAlertState(filename)
+	assert.Nil(t, err, "should read the counts")
+	assert.Equal(t, map[string]int{"aspirin": 2}, state, "should return the stored counts")
+}
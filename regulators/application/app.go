@@ -2,37 +2,134 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/client"
+	"medical-supply/identity"
+	"medical-supply/metrics"
+	"medical-supply/profile"
+	"medical-supply/simulator"
 )
 
-const (
-	mspID         = "Org2MSP"
-	appUser       = "bob"
-	peerEndpoint  = "localhost:9051"
-	gatewayPeer   = "peer0.org2.example.com"
-	channelName   = "mychannel"
-	chaincodeName = "medicinecontract"
-)
+// Profile used when there is no configuration file, matching the test network. Settings missing in a profile
+// of the configuration file fall back to these.
+var defaultProfile = profile.Profile{
+	MSPID:             "Org2MSP",
+	User:              "bob",
+	PeerEndpoint:      "localhost:9051",
+	GatewayPeer:       "peer0.org2.example.com",
+	Channel:           "mychannel",
+	Chaincode:         "medicinecontract",
+	ConnectionProfile: filepath.Join("..", "configuration", "gateway", "connection-org2.yaml"),
+	Credentials: filepath.Join("..", "..", "..", "test-network", "organizations", "peerOrganizations", "org2.example.com",
+		"users", "User1@org2.example.com", "msp"),
+	Wallet:   "wallet",
+	TPMKeys:  ".",
+	Offline:  filepath.Join("..", "..", "offline"),
+	AuditLog: "audit.log",
+	CA: profile.CA{
+		URL:         "https://localhost:8054",
+		Name:        "ca-org2",
+		TLSCert:     filepath.Join("..", "..", "..", "test-network", "organizations", "fabric-ca", "org2", "tls-cert.pem"),
+		Affiliation: "org2.department1",
+	},
+}
 
 func main() {
-	wallet := enrollUser()
-	contract := connectToNetwork(wallet)
+	configFile := flag.String("config", "medstore.yaml", "configuration file with the profiles of the networks")
+	profileName := flag.String("profile", "", "profile of the configuration file to use (default $MEDSTORE_PROFILE or the default of the file)")
+	user := flag.String("user", "", "identity of the wallet to act as (default the user of the profile)")
+	offline := flag.Bool("offline", false, "run the smart contracts in-process on a local ledger instead of the network")
+	metricsAddress := flag.String("metrics", "", "serve Prometheus metrics on /metrics and health checks on /healthz and /readyz at this address (e.g. :9100)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nWithout command the menu of functions is shown.\n%s\n%s\n%s\n", identityUsage, loadgenUsage, auditUsage)
+	}
+	flag.Parse()
+
+	// Only a configuration file which is set explicitly has to exist.
+	required := false
+	flag.Visit(func(f *flag.Flag) {
+		required = required || f.Name == "config"
+	})
+	settings, err := profile.Load(*configFile, *profileName, required, defaultProfile)
+	if err != nil {
+		log.Fatalf("\nFailed to load configuration: %v", err)
+	}
+	if *user == "" {
+		*user = settings.User
+	}
+
+	// Offline the smart contracts run in-process, identities are enrolled with the simulated CA.
+	var sim *simulator.Simulator
+	var ca certificateAuthority
+	if *offline {
+		settings = offlineProfile(settings)
+		sim = startSimulator(settings)
+		ca = sim.CA()
+		log.Printf("Using profile %s offline as %s on the ledger in %s", settings.Name, *user, settings.Offline)
+	} else {
+		log.Printf("Using profile %s as %s on channel %s (%s)", settings.Name, *user, settings.Channel, settings.PeerEndpoint)
+	}
+
+	if flag.Arg(0) == "verify-audit-log" {
+		err := verifyAuditLog(settings, flag.Args()[1:])
+		if err != nil {
+			log.Fatalf("\n%v", err)
+		}
+		return
+	}
 
-	tpmkey, err := tpmKeyHandler(contract, "tpmkey.txt")
+	wallet := openWallet(settings)
+	loadgenCommand := flag.Arg(0) == "loadgen"
+	if flag.NArg() > 0 && !loadgenCommand {
+		err := runIdentityCommand(wallet, settings, ca, *user, flag.Args())
+		if err != nil {
+			log.Fatalf("\n%v", err)
+		}
+		return
+	}
+
+	var medstore *client.Client
+	if *offline {
+		enrollOfflineUser(wallet, settings, *user)
+		medstore = connectToSimulator(sim, wallet, *user)
+	} else {
+		enrollUser(wallet, settings, *user)
+		medstore = connectToNetwork(wallet, settings, *user)
+	}
+	observers := client.Observers{openAuditLog(settings, *user)}
+	if *metricsAddress != "" {
+		observers = append(observers, serveMetrics(medstore, "regulators", *metricsAddress))
+	}
+	medstore.SetObserver(observers)
+
+	tpmkey, err := tpmKeyHandler(medstore, tpmKeyFile(settings, *user))
 	if err != nil {
 		log.Fatalf("Failed to generate TPM key: %v", err)
 	}
 	log.Printf("TPM Key used is: %v", tpmkey)
+	medstore.SetTPMKey(tpmkey)
+
+	if loadgenCommand {
+		err := runLoadgen(medstore, flag.Args()[1:])
+		if err != nil {
+			log.Fatalf("\nFailed to run the load generator: %v", err)
+		}
+		return
+	}
 
 	log.Println("Choose number to invoke function: \n" +
 		"1 - Initialise the ledger \n" +
@@ -43,7 +140,30 @@ func main() {
 		"6 - Check all requested medicine \n" +
 		"7 - Approve request for medicine \n" +
 		"8 - Reject request for medicine \n" +
-		"9 - Delete medicine")
+		"9 - Delete medicine \n" +
+		"10 - Check all prescriptions \n" +
+		"11 - Check all orders \n" +
+		"12 - Approve an order \n" +
+		"13 - Reject an order \n" +
+		"14 - Check all returns \n" +
+		"15 - Inspect a return \n" +
+		"16 - Quarantine medicine \n" +
+		"17 - Destroy quarantined medicine \n" +
+		"18 - Check all certificates of destruction \n" +
+		"19 - Set a quota rule \n" +
+		"20 - Remove a quota rule \n" +
+		"21 - Check all quota rules \n" +
+		"22 - Check customers near their quota \n" +
+		"23 (scan) - Issue new medicine by scanning its GS1 DataMatrix \n" +
+		"24 (epcis) - Export supply-chain history as EPCIS 2.0 events \n" +
+		"25 (fhir) - Export medicine and dispenses as a FHIR bundle \n" +
+		"26 (fhir-serve) - Serve the FHIR bundle over HTTP \n" +
+		"27 (report) - Show the inventory report \n" +
+		"28 (expiry) - Check available medicine expiring soon \n" +
+		"29 (expiry-alerts) - Alert when stock expiring soon crosses a threshold \n" +
+		"30 (query) - Search medicine with a filter \n" +
+		"31 (reindex) - Rebuild the search index of the medicine \n" +
+		"32 (migrate) - Migrate the ledger records to the latest schema")
 
 	scanner := bufio.NewScanner(os.Stdin)
 	scanner.Scan()
@@ -51,126 +171,161 @@ func main() {
 
 	switch strings.ToLower(input) {
 	case "1":
-		initLedger(contract, tpmkey)
+		initLedger(medstore)
 	case "2":
-		checkHistory(contract, tpmkey)
+		checkHistory(medstore)
 	case "3":
-		issue(contract, scanner, tpmkey)
+		issue(medstore, scanner)
 	case "4":
-		changeStatus(contract, scanner, tpmkey)
+		changeStatus(medstore, scanner)
 	case "5":
-		changeHolder(contract, scanner, tpmkey)
+		changeHolder(medstore, scanner)
 	case "6":
-		checkRequestedMedicine(contract, tpmkey)
+		checkRequestedMedicine(medstore)
 	case "7":
-		approveRequest(contract, scanner, tpmkey)
+		approveRequest(medstore, scanner)
 	case "8":
-		rejectRequest(contract, scanner, tpmkey)
+		rejectRequest(medstore, scanner)
 	case "9":
-		delete(contract, scanner, tpmkey)
+		delete(medstore, scanner)
+	case "10":
+		checkPrescriptions(medstore)
+	case "11":
+		checkOrders(medstore)
+	case "12":
+		approveOrder(medstore, scanner)
+	case "13":
+		rejectOrder(medstore, scanner)
+	case "14":
+		checkReturns(medstore)
+	case "15":
+		inspectReturn(medstore, scanner)
+	case "16":
+		quarantine(medstore, scanner)
+	case "17":
+		destroy(medstore, scanner)
+	case "18":
+		checkDestructions(medstore)
+	case "19":
+		setQuotaRule(medstore, scanner)
+	case "20":
+		removeQuotaRule(medstore, scanner)
+	case "21":
+		checkQuotaRules(medstore)
+	case "22":
+		checkQuotaUsage(medstore, scanner)
+	case "23", "scan":
+		scan(medstore, scanner)
+	case "24", "epcis":
+		exportEPCIS(medstore, scanner)
+	case "25", "fhir":
+		exportFHIR(medstore, scanner)
+	case "26", "fhir-serve":
+		serveFHIR(medstore, scanner)
+	case "27", "report":
+		report(medstore, scanner)
+	case "28", "expiry":
+		expiryForecast(medstore, scanner)
+	case "29", "expiry-alerts":
+		expiryAlerts(medstore, scanner)
+	case "30", "query":
+		queryMedicines(medstore, scanner)
+	case "31", "reindex":
+		rebuildSearchIndex(medstore)
+	case "32", "migrate":
+		migrateStates(medstore, scanner)
 	default:
 		log.Fatalf("\n Error: Function to invoke not found.")
 	}
 }
 
-// Enrolls user as peer to the network
-func enrollUser() *gateway.Wallet {
-	err := os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
-	if err != nil {
-		log.Fatalf("\nError setting DISCOVERY_AS_LOCALHOST environemnt variable: %v", err)
+// Opens the wallet holding the identities of the users.
+func openWallet(settings *profile.Profile) *gateway.Wallet {
+	// The peers of the test network run in Docker, networks with reachable peers set DISCOVERY_AS_LOCALHOST=false.
+	if _, ok := os.LookupEnv("DISCOVERY_AS_LOCALHOST"); !ok {
+		err := os.Setenv("DISCOVERY_AS_LOCALHOST", "true")
+		if err != nil {
+			log.Fatalf("\nError setting DISCOVERY_AS_LOCALHOST environemnt variable: %v", err)
+		}
 	}
 
-	wallet, err := gateway.NewFileSystemWallet("wallet")
+	wallet, err := gateway.NewFileSystemWallet(settings.Wallet)
 	if err != nil {
 		log.Fatalf("\nFailed to create wallet: %v", err)
 	}
-
-	if !wallet.Exists(appUser) {
-		err = populateWallet(wallet)
-		if err != nil {
-			log.Fatalf("\nFailed to populate wallet contents: %v", err)
-		}
-	} else {
-		log.Println("============ Sucessfully populated wallet ============")
-	}
 	return wallet
 }
 
-// Connects to the network channel and gets the smart contract to invoke functions on.
-func connectToNetwork(wallet *gateway.Wallet) *gateway.Contract {
-	ccpPath := filepath.Join("..", "configuration", "gateway", "connection-org2.yaml")
-
-	gw, err := gateway.Connect(
-		gateway.WithConfig(config.FromFile(filepath.Clean(ccpPath))),
-		gateway.WithIdentity(wallet, appUser),
-	)
-	if err != nil {
-		log.Fatalf("\nFailed to connect to gateway: %v", err)
+// Enrolls user as peer to the network, only the user of the profile is imported from its credentials, other users are
+// enrolled with the CA or imported first.
+func enrollUser(wallet *gateway.Wallet, settings *profile.Profile, user string) {
+	if wallet.Exists(user) {
+		log.Println("============ Sucessfully populated wallet ============")
+		return
+	}
+	if user != settings.User || settings.Credentials == "" {
+		log.Fatalf("\nIdentity %s is not in the wallet, enroll it with the CA (ca enroll) or import it (wallet import).", user)
 	}
-	defer gw.Close()
 
-	network, err := gw.GetNetwork(channelName)
+	err := populateWallet(wallet, settings)
 	if err != nil {
-		log.Fatalf("\nFailed to get network: %v", err)
+		log.Fatalf("\nFailed to populate wallet contents: %v", err)
 	}
-
-	contract := network.GetContract(chaincodeName)
-	return contract
 }
 
-// Create wallet and keystore folder for user to use.
-func populateWallet(wallet *gateway.Wallet) error {
-	credPath := filepath.Join(
-		"..",
-		"..",
-		"..",
-		"test-network",
-		"organizations",
-		"peerOrganizations",
-		"org2.example.com",
-		"users",
-		"User1@org2.example.com",
-		"msp",
-	)
-
-	certPath := filepath.Join(credPath, "signcerts", "cert.pem")
-	// read the certificate pem
-	cert, err := ioutil.ReadFile(filepath.Clean(certPath))
+// Connects to the network channel and creates the client of the smart contracts to invoke functions on.
+func connectToNetwork(wallet *gateway.Wallet, settings *profile.Profile, user string) *client.Client {
+	id, err := identity.Get(wallet, user)
 	if err != nil {
-		return err
+		log.Fatalf("\n%v", err)
 	}
 
-	keyDir := filepath.Join(credPath, "keystore")
-	// there's a single file in this dir containing the private key
-	files, err := ioutil.ReadDir(keyDir)
+	medstore, err := client.Connect(config.FromFile(filepath.Clean(settings.ConnectionProfile)), settings.Channel,
+		settings.Chaincode, user, id)
 	if err != nil {
-		return err
-	}
-	if len(files) < 1 {
-		return fmt.Errorf("keystore folder should contain one file")
+		log.Fatalf("\nFailed to connect to network: %v", err)
 	}
-	keyPath := filepath.Join(keyDir, files[0].Name())
-	key, err := ioutil.ReadFile(filepath.Clean(keyPath))
+	return medstore
+}
+
+// Serves the metrics of the transactions of the client while the application runs, the health checks ping the
+// chaincode through the gateway.
+func serveMetrics(medstore *client.Client, application string, address string) *metrics.Metrics {
+	m := metrics.New(application)
+	addr, err := m.Serve(address, medstore.Ping)
 	if err != nil {
-		return err
+		log.Fatalf("\n%v", err)
 	}
+	log.Printf("Serving metrics on http://%s/metrics and health checks on /healthz and /readyz", addr)
+	return m
+}
 
-	identity := gateway.NewX509Identity(mspID, string(cert), string(key))
+// Imports the user of the profile into the wallet, by default the user of the test network.
+func populateWallet(wallet *gateway.Wallet, settings *profile.Profile) error {
+	return identity.Import(wallet, settings.User, settings.MSPID, settings.Credentials)
+}
 
-	return wallet.Put(appUser, identity)
+// Returns the file the tpm key of the user is stored in, the user of the profile keeps using tpmkey.txt.
+func tpmKeyFile(settings *profile.Profile, user string) string {
+	if user == settings.User {
+		return filepath.Join(settings.TPMKeys, "tpmkey.txt")
+	}
+	return filepath.Join(settings.TPMKeys, "tpmkey-"+user+".txt")
 }
 
 // Reads tpm key from file, if no success then request for new key and store that.
-func tpmKeyHandler(contract *gateway.Contract, filepath string) (string, error) {
+func tpmKeyHandler(medstore *client.Client, filepath string) (string, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		// Request tpm key from smart contract
 		log.Println("--> Submit Transaction: TPMKeyGen, function requests for tpm generated key.")
-		result, err := contract.SubmitTransaction("TPMKeyGen", appUser)
+		tpmkey, err := medstore.TPMKeyGen(context.Background())
+		if client.ErrorCode(err) == client.CodeAlreadyExists {
+			log.Fatalf("\nUser %s already has a TPM key but %s is missing, restore it from a backup.", medstore.User(), filepath)
+		}
 		if err != nil {
-			log.Fatalf("\nFailed to Submit transaction: %v", err)
+			failTransaction(err)
 		}
-		tpmkey := string(result)
 
 		// Store tpmkey to file
 		file, err := os.Create(filepath)
@@ -194,74 +349,148 @@ func tpmKeyHandler(contract *gateway.Contract, filepath string) (string, error)
 }
 
 // Helper function for pretty printing results to the terminal.
-func prettyPrint(body []byte) {
-	var indentedFormat bytes.Buffer
-	error := json.Indent(&indentedFormat, body, "", "\t")
-	if error != nil {
-		log.Println("Error encountered Json parse error: ", error)
-		// Print bytes normally without the pretty printed format
-		log.Println(string(body))
+func prettyPrint(result interface{}) {
+	body, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		log.Println("Error encountered Json parse error: ", err)
+		// Print the result normally without the pretty printed format
+		log.Println(result)
 		return
 	}
-	log.Println(indentedFormat.String())
+	log.Println(string(body))
 }
 
-// Helper function for printing array results
-func printArray(result []byte) {
-	if len(result) > 0 {
+// Helper function for printing array results.
+func printArray(count int, result interface{}) {
+	if count > 0 {
 		prettyPrint(result)
 	} else {
 		log.Println("No transactions found on ledger.")
 	}
 }
 
+// Helper function for printing one or more orders with their status and line-level detail.
+func printOrders(orders ...*client.Order) {
+	if len(orders) == 0 {
+		log.Println("No orders found on ledger.")
+		return
+	}
+
+	for _, o := range orders {
+		log.Printf("Order %s (%s) placed %s by %s", o.OrderID, o.State, o.OrderDate, o.Customer)
+		for _, line := range o.Lines {
+			log.Printf("\t%dx %s: %s", line.Quantity, line.MedName, strings.Join(line.MedNumbers, ", "))
+		}
+	}
+}
+
+// Reads a number from the scanner, stopping the application if it is not a number.
+func readNumber(scanner *bufio.Scanner, name string) int {
+	scanner.Scan()
+	number, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Fatalf("\nInvalid %s: %v", name, err)
+	}
+	return number
+}
+
+// Reads true or false from the scanner, stopping the application if it is neither.
+func readBool(scanner *bufio.Scanner, name string) bool {
+	scanner.Scan()
+	value, err := strconv.ParseBool(scanner.Text())
+	if err != nil {
+		log.Fatalf("\nInvalid %s: %v", name, err)
+	}
+	return value
+}
+
 // Initiliase the ledger with mock data.
-func initLedger(contract *gateway.Contract, tpmkey string) {
+func initLedger(medstore *client.Client) {
 	log.Println("--> Submit Transaction: InitLedger, function creates the initial set of medical supply on the ledger")
-	_, err := contract.SubmitTransaction("InitLedger", appUser, tpmkey)
+	err := medstore.InitLedger(context.Background())
 	if err != nil {
-		log.Fatalf("Failed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
 }
 
 // Handling checking the entire transaction history.
-func checkHistory(contract *gateway.Contract, tpmkey string) {
-	log.Println("--> Submit Transaction: CheckHistory, function shows history.")
-	result, err := contract.SubmitTransaction("CheckHistory", appUser, tpmkey)
+func checkHistory(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckHistory, function shows history.")
+	medicines, err := medstore.CheckHistory(context.Background())
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	printArray(result)
+	printArray(len(medicines), medicines)
 }
 
 // Handling when regulators issue a new medicine (add to the ledger).
-func issue(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func issue(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.IssueInput
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
-	medName := scanner.Text()
+	input.MedName = scanner.Text()
 	log.Println("Medicine number (e.g. 00012):")
 	scanner.Scan()
-	medNumber := scanner.Text()
+	input.MedNumber = scanner.Text()
 	log.Println("Disease (e.g. Pain management):")
 	scanner.Scan()
-	disease := scanner.Text()
+	input.Disease = scanner.Text()
 	log.Println("Expiration date (e.g. 2022.05.09):")
 	scanner.Scan()
-	expirationDate := scanner.Text()
+	input.Expiration = scanner.Text()
 	log.Println("Price (e.g. $10):")
 	scanner.Scan()
-	price := scanner.Text()
+	input.Price = scanner.Text()
+	log.Println("Prescription only (true or false):")
+	input.RxOnly = readBool(scanner, "prescription only")
+	log.Println("Drug schedule of a controlled substance (I to V, leave empty if not controlled):")
+	scanner.Scan()
+	input.Schedule = scanner.Text()
 
 	log.Println("--> Submit Transaction: Issue, function sends issue for medicine.")
-	result, err := contract.SubmitTransaction("Issue", medName, medNumber, disease, expirationDate, price, appUser, tpmkey)
+	medicine, err := medstore.Issue(context.Background(), input)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
+}
+
+// Reads a GS1 element string from the scanner, keyboard wedge scanners which can't send the FNC1 (GS) character
+// may be configured to send <GS> instead.
+func readElementString(scanner *bufio.Scanner) string {
+	log.Println("Scan the GS1 DataMatrix of the pack (e.g. (01)09506000134352(17)240531(10)ABC123(21)00012):")
+	scanner.Scan()
+	return strings.ReplaceAll(scanner.Text(), "<GS>", "\x1d")
+}
+
+// Handling when regulators issue a new medicine by scanning the GS1 DataMatrix of the pack.
+func scan(medstore *client.Client, scanner *bufio.Scanner) {
+	input := client.IssueFromGS1Input{ElementString: readElementString(scanner)}
+	log.Println("Medicine name (e.g. Aspirin):")
+	scanner.Scan()
+	input.MedName = scanner.Text()
+	log.Println("Disease (e.g. Pain management):")
+	scanner.Scan()
+	input.Disease = scanner.Text()
+	log.Println("Price (e.g. $10):")
+	scanner.Scan()
+	input.Price = scanner.Text()
+	log.Println("Prescription only (true or false):")
+	input.RxOnly = readBool(scanner, "prescription only")
+	log.Println("Drug schedule of a controlled substance (I to V, leave empty if not controlled):")
+	scanner.Scan()
+	input.Schedule = scanner.Text()
+
+	log.Println("--> Submit Transaction: IssueFromGS1, function sends issue for the scanned medicine.")
+	medicine, err := medstore.IssueFromGS1(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(medicine)
 }
 
 // Changing status of medicine manually.
-func changeStatus(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func changeStatus(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -273,15 +502,15 @@ func changeStatus(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey str
 	status := scanner.Text()
 
 	log.Println("--> Submit Transaction: ChangeStatus, function sends request for medicine.")
-	result, err := contract.SubmitTransaction("ChangeStatus", medName, medNumber, status, appUser, tpmkey)
+	medicine, err := medstore.ChangeStatus(context.Background(), medName, medNumber, status)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Changing holder of medicine manually.
-func changeHolder(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func changeHolder(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -293,25 +522,35 @@ func changeHolder(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey str
 	holder := scanner.Text()
 
 	log.Println("--> Submit Transaction: ChangeHolder, function sends request for medicine.")
-	result, err := contract.SubmitTransaction("ChangeHolder", medName, medNumber, holder, appUser, tpmkey)
+	medicine, err := medstore.ChangeHolder(context.Background(), medName, medNumber, holder)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Handling regulators wanting to see all requested medicine matching the medicine name.
-func checkRequestedMedicine(contract *gateway.Contract, tpmkey string) {
-	log.Println("--> Submit Transaction: CheckRequestedMedicine, function shows all requested medicine.")
-	result, err := contract.SubmitTransaction("CheckRequestedMedicine", appUser, tpmkey)
+func checkRequestedMedicine(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckRequestedMedicine, function shows all requested medicine.")
+	medicines, err := medstore.CheckRequestedMedicine(context.Background())
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	printArray(result)
+	printArray(len(medicines), medicines)
 }
 
-// Approves a medicine (changes its state from REQUESTED to SEND).
-func approveRequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+// Handling regulators wanting to see all prescriptions and how much of each has been dispensed.
+func checkPrescriptions(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckPrescriptions, function shows all prescriptions.")
+	prescriptions, err := medstore.CheckPrescriptions(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(prescriptions), prescriptions)
+}
+
+// Approves a medicine (changes its state from REQUESTED to SEND, scheduled medicine needs the approval of two regulators).
+func approveRequest(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -320,15 +559,19 @@ func approveRequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey s
 	medNumber := scanner.Text()
 
 	log.Println("--> Submit Transaction: ApproveRequest, function that approves medicine.")
-	result, err := contract.SubmitTransaction("ApproveRequest", medName, medNumber, appUser, tpmkey)
+	medicine, err := medstore.ApproveRequest(context.Background(), medName, medNumber)
+	if client.ErrorCode(err) == client.CodeSecondApproval {
+		log.Printf("\nYou already approved %s %s, it awaits the approval of a second regulator.", medName, medNumber)
+		return
+	}
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Rejecting a medicine (changes its state from REQUESTED to AVAILABLE).
-func rejectRequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func rejectRequest(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -337,15 +580,15 @@ func rejectRequest(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey st
 	medNumber := scanner.Text()
 
 	log.Println("--> Submit Transaction: RejectRequest, function that approves medicine.")
-	result, err := contract.SubmitTransaction("RejectRequest", medName, medNumber, appUser, tpmkey)
+	medicine, err := medstore.RejectRequest(context.Background(), medName, medNumber)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	}
-	prettyPrint(result)
+	prettyPrint(medicine)
 }
 
 // Deletes a medicine from ledger.
-func delete(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
+func delete(medstore *client.Client, scanner *bufio.Scanner) {
 	log.Println("Medicine name (e.g. Aspirin):")
 	scanner.Scan()
 	medName := scanner.Text()
@@ -354,10 +597,304 @@ func delete(contract *gateway.Contract, scanner *bufio.Scanner, tpmkey string) {
 	medNumber := scanner.Text()
 
 	log.Println("--> Submit Transaction: Delete, function that approves medicine.")
-	_, err := contract.SubmitTransaction("Delete", medName, medNumber, appUser, tpmkey)
+	err := medstore.Delete(context.Background(), medName, medNumber)
 	if err != nil {
-		log.Fatalf("\nFailed to Submit transaction: %v", err)
+		failTransaction(err)
 	} else {
 		log.Println("Deleting medical supply was succesful.")
 	}
 }
+
+// Handling regulators wanting to see all orders with their status and lines.
+func checkOrders(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckOrders, function shows all orders.")
+	orders, err := medstore.CheckOrders(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(orders...)
+}
+
+// Approves an order (changes the state of all its medicine from REQUESTED to SEND).
+func approveOrder(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Order id (e.g. ORD0001):")
+	scanner.Scan()
+	orderID := scanner.Text()
+
+	log.Println("--> Submit Transaction: ApproveOrder, function that approves an order.")
+	order, err := medstore.ApproveOrder(context.Background(), orderID)
+	if client.ErrorCode(err) == client.CodeSecondApproval {
+		log.Printf("\nYou already approved order %s, it awaits the approval of a second regulator.", orderID)
+		return
+	}
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(order)
+}
+
+// Rejects an order (changes the state of all its medicine from REQUESTED to AVAILABLE).
+func rejectOrder(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Order id (e.g. ORD0001):")
+	scanner.Scan()
+	orderID := scanner.Text()
+
+	log.Println("--> Submit Transaction: RejectOrder, function that rejects an order.")
+	order, err := medstore.RejectOrder(context.Background(), orderID)
+	if err != nil {
+		failTransaction(err)
+	}
+	printOrders(order)
+}
+
+// Handling regulators wanting to see all returns and their audit records.
+func checkReturns(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckReturns, function shows all returns.")
+	returns, err := medstore.CheckReturns(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(returns), returns)
+}
+
+// Inspects a returned medicine and either restocks it or quarantines it for destruction.
+func inspectReturn(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.InspectionInput
+	log.Println("Return id (e.g. RET0001):")
+	scanner.Scan()
+	input.ReturnID = scanner.Text()
+	log.Println("Outcome (Restock or Destroy):")
+	scanner.Scan()
+	input.Outcome = scanner.Text()
+	log.Println("Refund amount, at most the price paid (e.g. $10):")
+	scanner.Scan()
+	input.Refund = scanner.Text()
+	log.Println("Inspection notes (e.g. Seal intact):")
+	scanner.Scan()
+	input.Notes = scanner.Text()
+
+	log.Println("--> Submit Transaction: InspectReturn, function that inspects a returned medicine.")
+	medicineReturn, err := medstore.InspectReturn(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(medicineReturn)
+}
+
+// Takes a medicine out of stock awaiting its destruction.
+func quarantine(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Medicine name (e.g. Aspirin):")
+	scanner.Scan()
+	medName := scanner.Text()
+	log.Println("Medicine number (e.g. 00001):")
+	scanner.Scan()
+	medNumber := scanner.Text()
+	log.Println("Reason (e.g. Recalled by manufacturer):")
+	scanner.Scan()
+	note := scanner.Text()
+
+	log.Println("--> Submit Transaction: Quarantine, function that quarantines medicine.")
+	medicine, err := medstore.Quarantine(context.Background(), medName, medNumber, note)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(medicine)
+}
+
+// Destroys a quarantined medicine and records its certificate of destruction.
+func destroy(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.DestroyInput
+	log.Println("Medicine name (e.g. Aspirin):")
+	scanner.Scan()
+	input.MedName = scanner.Text()
+	log.Println("Medicine number (e.g. 00001):")
+	scanner.Scan()
+	input.MedNumber = scanner.Text()
+	log.Println("Method of destruction (e.g. Incineration):")
+	scanner.Scan()
+	input.Method = scanner.Text()
+	log.Println("Witnesses, separated by commas (e.g. Carol, Dave):")
+	scanner.Scan()
+	for _, witness := range strings.Split(scanner.Text(), ",") {
+		input.Witnesses = append(input.Witnesses, strings.TrimSpace(witness))
+	}
+	log.Println("Date of destruction (e.g. 2022.05.09):")
+	scanner.Scan()
+	input.Date = scanner.Text()
+	log.Println("SHA-256 hash of the signed destruction document (optional):")
+	scanner.Scan()
+	input.DocumentHash = scanner.Text()
+
+	log.Println("--> Submit Transaction: Destroy, function that destroys medicine.")
+	cert, err := medstore.Destroy(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(cert)
+}
+
+// Handling regulators wanting to see all certificates of destruction.
+func checkDestructions(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckDestructions, function shows all certificates of destruction.")
+	certs, err := medstore.CheckDestructions(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(certs), certs)
+}
+
+// Adds or changes a quota rule limiting how much a single customer may request.
+func setQuotaRule(medstore *client.Client, scanner *bufio.Scanner) {
+	var rule client.QuotaRule
+	log.Println("Quota rule id (e.g. Q0001):")
+	scanner.Scan()
+	rule.RuleID = scanner.Text()
+	log.Println("Scope (Medicine, Category or Schedule):")
+	scanner.Scan()
+	rule.Scope = scanner.Text()
+	log.Println("Medicine name, category or schedule (e.g. Vicodin, Pain management or II):")
+	scanner.Scan()
+	rule.Target = scanner.Text()
+	log.Println("Maximum units per customer (e.g. 2):")
+	rule.MaxUnits = readNumber(scanner, "maximum units")
+	log.Println("Period in days (e.g. 30):")
+	rule.PeriodDays = readNumber(scanner, "period")
+
+	log.Println("--> Submit Transaction: SetQuotaRule, function that sets a quota rule.")
+	changed, err := medstore.SetQuotaRule(context.Background(), rule)
+	if err != nil {
+		failTransaction(err)
+	}
+	prettyPrint(changed)
+}
+
+// Removes a quota rule.
+func removeQuotaRule(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Quota rule id (e.g. Q0001):")
+	scanner.Scan()
+	ruleID := scanner.Text()
+
+	log.Println("--> Submit Transaction: RemoveQuotaRule, function that removes a quota rule.")
+	err := medstore.RemoveQuotaRule(context.Background(), ruleID)
+	if err != nil {
+		failTransaction(err)
+	} else {
+		log.Println("Removing quota rule was succesful.")
+	}
+}
+
+// Handling regulators wanting to see all quota rules.
+func checkQuotaRules(medstore *client.Client) {
+	log.Println("--> Evaluate Transaction: CheckQuotaRules, function shows all quota rules.")
+	rules, err := medstore.CheckQuotaRules(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(rules), rules)
+}
+
+// Handling regulators wanting to see which customers are near their quota.
+func checkQuotaUsage(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Minimum percentage of the quota used (e.g. 80):")
+	threshold := readNumber(scanner, "percentage")
+
+	log.Println("--> Evaluate Transaction: CheckQuotaUsage, function shows customers near their quota.")
+	usages, err := medstore.CheckQuotaUsage(context.Background(), threshold)
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(usages), usages)
+}
+
+// Handling regulators exporting the supply-chain history for trading partners outside the network.
+func exportEPCIS(medstore *client.Client, scanner *bufio.Scanner) {
+	var input client.EPCISInput
+	log.Println("Start of time window (e.g. 2022-02-22T00:00:00Z, leave empty for no start):")
+	scanner.Scan()
+	input.From = scanner.Text()
+	log.Println("End of time window (e.g. 2022-03-22T00:00:00Z, leave empty for no end):")
+	scanner.Scan()
+	input.To = scanner.Text()
+	log.Println("Medicine name or GTIN (e.g. Aspirin, leave empty for all medicine):")
+	scanner.Scan()
+	input.Product = scanner.Text()
+	log.Println("File to write the EPCIS document to (e.g. epcis.json):")
+	scanner.Scan()
+	filename := scanner.Text()
+
+	log.Println("--> Evaluate Transaction: ExportEPCIS, function exports the supply-chain history as EPCIS events.")
+	document, err := medstore.ExportEPCIS(context.Background(), input)
+	if err != nil {
+		failTransaction(err)
+	}
+
+	if filename == "" {
+		prettyPrint(json.RawMessage(document))
+		return
+	}
+	err = ioutil.WriteFile(filename, document, 0644)
+	if err != nil {
+		log.Fatalf("\nFailed to write EPCIS document: %v", err)
+	}
+	log.Printf("EPCIS document written to %s", filename)
+}
+
+// Handling regulators searching medicine on state, holder, disease, expiry (e.g. 2022.05.09) and price (e.g. $10).
+func queryMedicines(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println(`Filter (e.g. {"state":"AVAILABLE","disease":"pain","expiryTo":"2022.12.31","priceMax":"$10","sort":"-expiration"}):`)
+	scanner.Scan()
+	filter := scanner.Text()
+
+	log.Println("--> Evaluate Transaction: QueryMedicines, function shows the medicine matching the filter.")
+	medicines, err := medstore.QueryMedicines(context.Background(), filter)
+	if err != nil {
+		failTransaction(err)
+	}
+	printArray(len(medicines), medicines)
+}
+
+// Handling regulators adding medicine issued before the search index existed to the index.
+func rebuildSearchIndex(medstore *client.Client) {
+	log.Println("--> Submit Transaction: RebuildSearchIndex, function adds all medicine to the search index.")
+	indexed, err := medstore.RebuildSearchIndex(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+	log.Printf("%d available medicine can be found by searching", indexed)
+}
+
+// Handling regulators migrating the ledger records to the latest schema, a page of records per transaction.
+func migrateStates(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Records per transaction (default 100):")
+	scanner.Scan()
+	pageSize := 100
+	if scanner.Text() != "" {
+		var err error
+		pageSize, err = strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Fatalf("\nInvalid records per transaction: %v", err)
+		}
+	}
+
+	total := client.MigrationProgress{}
+	bookmark := ""
+	for page := 1; ; page++ {
+		log.Println("--> Submit Transaction: MigrateStates, function rewrites a page of records to the latest schema.")
+		progress, err := medstore.MigrateStates(context.Background(), bookmark, pageSize)
+		if err != nil {
+			failTransaction(err)
+		}
+
+		total.Scanned += progress.Scanned
+		total.Migrated += progress.Migrated
+		total.Skipped += progress.Skipped
+		log.Printf("Page %d: %d record(s) scanned, %d migrated, %d skipped (total %d scanned, %d migrated, %d skipped)",
+			page, progress.Scanned, progress.Migrated, progress.Skipped, total.Scanned, total.Migrated, total.Skipped)
+
+		if progress.Done {
+			break
+		}
+		bookmark = progress.Bookmark
+	}
+	log.Println("Migration done.")
+}
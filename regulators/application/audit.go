@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"medical-supply/audit"
+	"medical-supply/profile"
+)
+
+// Usage of the verify-audit-log command, which runs instead of the menu when passed as argument.
+const auditUsage = `Audit log:
+  verify-audit-log [file]                   Check that no transaction was changed, removed or truncated from the
+                                            audit log of the profile (or file) and print its head. To start a new
+                                            log, move the log and its .head file away once verified`
+
+// Opens the audit log of the profile, which every transaction submitted by the user is appended to.
+func openAuditLog(settings *profile.Profile, user string) *audit.Log {
+	if settings.AuditLog == "" {
+		log.Fatalf("\nProfile %s has no audit log, set auditLog or %sAUDIT_LOG.", settings.Name, profile.EnvPrefix)
+	}
+	auditLog, err := audit.Open(settings.AuditLog, user)
+	if err != nil {
+		log.Fatalf("\nFailed to open audit log: %v", err)
+	}
+	log.Printf("Auditing transactions in %s", settings.AuditLog)
+	return auditLog
+}
+
+// Verifies the audit log of the profile, or the file passed to the verify-audit-log command.
+func verifyAuditLog(settings *profile.Profile, args []string) error {
+	flags := flag.NewFlagSet("verify-audit-log", flag.ContinueOnError)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	filename := settings.AuditLog
+	switch {
+	case flags.NArg() == 1:
+		filename = flags.Arg(0)
+	case flags.NArg() > 1:
+		return fmt.Errorf("unexpected arguments %v\n%s", flags.Args()[1:], auditUsage)
+	case filename == "":
+		return fmt.Errorf("profile %s has no audit log, set auditLog or %sAUDIT_LOG", settings.Name, profile.EnvPrefix)
+	}
+
+	head, err := audit.Verify(filename)
+	if err != nil {
+		return fmt.Errorf("audit log %s was tampered with: %w", filename, err)
+	}
+	log.Printf("Audit log %s is intact: %d entries, head %s", filename, head.Sequence, head)
+	return nil
+}
@@ -0,0 +1,294 @@
+// Package audit keeps a local, append-only log of the transactions operators submit: who invoked which function with
+// which arguments, its transaction ID and result. Every entry holds the hash of the previous one, and the hash of the
+// last entry is kept in a head file next to the log, so Verify detects edited, removed and truncated entries.
+//
+// The log is tamper-evident, not tamper-proof: whoever can write both files can rewrite the whole chain. Recording the
+// head printed by Verify elsewhere, e.g. in a ticket, detects that as well.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"medical-supply/client"
+)
+
+// Genesis - Previous hash of the first entry.
+var Genesis = strings.Repeat("0", sha256.Size*2)
+
+// Results of the transactions.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Entry - Transaction submitted by an operator, chained to the previous entry by its hash.
+type Entry struct {
+	Sequence int       `json:"sequence"`
+	Time     time.Time `json:"time"`
+	Operator string    `json:"operator"`
+	Function string    `json:"function"`
+	Args     []string  `json:"args"`
+	TxID     string    `json:"txID,omitempty"`
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+	Previous string    `json:"previous"`
+	Hash     string    `json:"hash"`
+}
+
+// Head - Sequence and hash of the last entry of a log.
+type Head struct {
+	Sequence int    `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// String - Returns the head as <sequence>:<hash>.
+func (h Head) String() string {
+	return fmt.Sprintf("%d:%s", h.Sequence, h.Hash)
+}
+
+// Log - Audit log of the transactions of an operator, safe for concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	filename string
+	operator string
+	head     Head
+}
+
+// hash - Returns the hash of the entry, which covers every field but the hash itself.
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// headFile - Returns the file holding the head of the log.
+func headFile(filename string) string {
+	return filename + ".head"
+}
+
+// Open - Opens the log to append the transactions of the operator, creating it when missing. A log which doesn't end
+// with its head is not appended to, as new entries would hide the entries which were removed.
+func Open(filename string, operator string) (*Log, error) {
+	l := &Log{filename: filename, operator: operator, head: Head{Hash: Genesis}}
+	head, err := readHead(filename)
+	if os.IsNotExist(err) {
+		_, err = os.Stat(filename)
+		if err == nil {
+			return nil, fmt.Errorf("audit log %s has no head %s, run verify-audit-log", filename, headFile(filename))
+		}
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := lastEntry(filename)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("audit log %s is missing, its head is %s", filename, head)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if last == nil || last.Sequence != head.Sequence || last.Hash != head.Hash {
+		return nil, fmt.Errorf("audit log %s does not end with its head %s, run verify-audit-log", filename, head)
+	}
+	l.head = head
+	return l, nil
+}
+
+// readHead - Reads the head of the log.
+func readHead(filename string) (Head, error) {
+	var head Head
+	data, err := ioutil.ReadFile(filepath.Clean(headFile(filename)))
+	if err != nil {
+		return head, err
+	}
+	err = json.Unmarshal(data, &head)
+	if err != nil {
+		return head, fmt.Errorf("invalid head of audit log %s: %w", filename, err)
+	}
+	return head, nil
+}
+
+// lastEntry - Returns the last entry of the log, nil when it's empty.
+func lastEntry(filename string) (*Entry, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	line := lines[len(lines)-1]
+	if len(line) == 0 {
+		return nil, nil
+	}
+	var entry Entry
+	err = json.Unmarshal(line, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last entry of audit log %s: %w", filename, err)
+	}
+	return &entry, nil
+}
+
+// Append - Appends a transaction to the log and moves its head to the new entry.
+func (l *Log) Append(function string, args []string, txID string, err error) (*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := Entry{
+		Sequence: l.head.Sequence + 1,
+		Time:     time.Now().UTC(),
+		Operator: l.operator,
+		Function: function,
+		Args:     args,
+		TxID:     txID,
+		Result:   ResultSuccess,
+		Previous: l.head.Hash,
+	}
+	if err != nil {
+		entry.Result = ResultFailure
+		entry.Error = err.Error()
+	}
+	hash, err := entry.hash()
+	if err != nil {
+		return nil, err
+	}
+	entry.Hash = hash
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(l.filename), 0700)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(l.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not append to audit log %s: %w", l.filename, err)
+	}
+
+	head := Head{Sequence: entry.Sequence, Hash: entry.Hash}
+	err = writeHead(l.filename, head)
+	if err != nil {
+		return nil, err
+	}
+	l.head = head
+	return &entry, nil
+}
+
+// writeHead - Replaces the head of the log, through a temporary file so a crash never leaves half a head.
+func writeHead(filename string, head Head) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	tmp := headFile(filename) + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("could not write head of audit log %s: %w", filename, err)
+	}
+	return os.Rename(tmp, headFile(filename))
+}
+
+// ObserveTransaction - Appends the submitted transactions of the client, queries change nothing and are not logged.
+func (l *Log) ObserveTransaction(outcome client.Outcome) {
+	if !outcome.Submitted {
+		return
+	}
+	_, err := l.Append(outcome.Function, outcome.Args, outcome.TxID, outcome.Err)
+	if err != nil {
+		log.Printf("Failed to audit transaction %s: %v", outcome.Function, err)
+	}
+}
+
+// Verify - Checks that the entries of the log are chained from the first to its head, and returns the head.
+func Verify(filename string) (Head, error) {
+	head, err := readHead(filename)
+	if os.IsNotExist(err) {
+		return Head{}, fmt.Errorf("audit log %s has no head %s", filename, headFile(filename))
+	}
+	if err != nil {
+		return Head{}, err
+	}
+
+	f, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		return Head{}, err
+	}
+	defer f.Close()
+
+	previous := Head{Hash: Genesis}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return Head{}, fmt.Errorf("line %d is not an audit entry: %w", line, err)
+		}
+		err = verifyEntry(entry, previous)
+		if err != nil {
+			return Head{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		previous = Head{Sequence: entry.Sequence, Hash: entry.Hash}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return Head{}, err
+	}
+
+	switch {
+	case previous.Sequence < head.Sequence:
+		return Head{}, fmt.Errorf("log is truncated: it ends at entry %d, its head is entry %d", previous.Sequence, head.Sequence)
+	case previous.Sequence > head.Sequence:
+		return Head{}, fmt.Errorf("log continues after its head: entries %d to %d were appended without moving the head",
+			head.Sequence+1, previous.Sequence)
+	case previous.Hash != head.Hash:
+		return Head{}, fmt.Errorf("entry %d does not match the head: hash %s, expected %s", head.Sequence, previous.Hash, head.Hash)
+	}
+	return head, nil
+}
+
+// verifyEntry - Checks that the entry follows the previous one and that its hash matches its fields.
+func verifyEntry(entry Entry, previous Head) error {
+	if entry.Sequence != previous.Sequence+1 {
+		return fmt.Errorf("entry %d follows entry %d, entries were removed or reordered", entry.Sequence, previous.Sequence)
+	}
+	if entry.Previous != previous.Hash {
+		return fmt.Errorf("entry %d is not chained to entry %d, the previous entry was changed", entry.Sequence, previous.Sequence)
+	}
+	hash, err := entry.hash()
+	if err != nil {
+		return err
+	}
+	if hash != entry.Hash {
+		return fmt.Errorf("entry %d was changed, its hash is %s instead of %s", entry.Sequence, hash, entry.Hash)
+	}
+	return nil
+}
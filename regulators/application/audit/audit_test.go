@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+// newTestLog - Returns a log of three transactions.
+func newTestLog(t *testing.T) string {
+	filename := filepath.Join(t.TempDir(), "audit", "audit.log")
+	l, err := Open(filename, "bob")
+	assert.Nil(t, err, "should create the log")
+	l.ObserveTransaction(client.Outcome{Function: "Delete", Args: []string{"aspirin", "00001", "bob", client.Redacted},
+		Submitted: true, TxID: "tx1"})
+	l.ObserveTransaction(client.Outcome{Function: "CheckHistory", Args: []string{"bob", client.Redacted}})
+	l.ObserveTransaction(client.Outcome{Function: "ChangeHolder", Args: []string{"vicodin", "00002", "alice", "bob", client.Redacted},
+		Submitted: true, Err: &client.ContractError{Code: client.CodeNotFound, Message: "medicine vicodin:00002 not found"}})
+	_, err = l.Append("ChangeStatus", []string{"vicodin", "00003", "available", "bob", client.Redacted}, "tx3", nil)
+	assert.Nil(t, err, "should append an entry")
+	return filename
+}
+
+func readLines(t *testing.T, filename string) [][]byte {
+	data, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err, "should read the log")
+	return bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+}
+
+func writeLines(t *testing.T, filename string, lines [][]byte) {
+	assert.Nil(t, ioutil.WriteFile(filename, bytes.Join(lines, nil), 0600), "should write the log")
+}
+
+func TestAppend(t *testing.T) {
+	filename := newTestLog(t)
+	head, err := Verify(filename)
+	assert.Nil(t, err, "should verify the log")
+	assert.Equal(t, 3, head.Sequence, "should only log submitted transactions")
+
+	last, err := lastEntry(filename)
+	assert.Nil(t, err, "should read the last entry")
+	assert.Equal(t, "ChangeStatus", last.Function, "should log the function")
+	assert.Equal(t, []string{"vicodin", "00003", "available", "bob", client.Redacted}, last.Args, "should log the arguments as redacted")
+
+	l, err := Open(filename, "bob")
+	assert.Nil(t, err, "should reopen the log")
+	entry, err := l.Append("Delete", nil, "", errors.New("connection refused"))
+	assert.Nil(t, err, "should append to the reopened log")
+	assert.Equal(t, 4, entry.Sequence, "should continue the sequence")
+	assert.Equal(t, last.Hash, entry.Previous, "should chain the entry to the last one")
+	assert.Equal(t, ResultFailure, entry.Result, "should log the result")
+	assert.Equal(t, "connection refused", entry.Error, "should log the error")
+	_, err = Verify(filename)
+	assert.Nil(t, err, "should verify the appended log")
+}
+
+func TestVerify(t *testing.T) {
+	filename := newTestLog(t)
+	lines := readLines(t, filename)
+
+	edited := append([][]byte(nil), lines...)
+	edited[1] = bytes.Replace(lines[1], []byte("vicodin"), []byte("aspirin"), 1)
+	writeLines(t, filename, edited)
+	_, err := Verify(filename)
+	assert.EqualError(t, err, "line 2: entry 2 was changed, its hash is "+mustHash(t, edited[1])+" instead of "+mustEntry(t, lines[1]).Hash,
+		"should detect edited entries")
+
+	writeLines(t, filename, [][]byte{lines[0], lines[2]})
+	_, err = Verify(filename)
+	assert.EqualError(t, err, "line 2: entry 3 follows entry 1, entries were removed or reordered", "should detect removed entries")
+
+	writeLines(t, filename, lines[1:])
+	_, err = Verify(filename)
+	assert.EqualError(t, err, "line 1: entry 2 follows entry 0, entries were removed or reordered", "should detect removed first entries")
+
+	writeLines(t, filename, lines[:2])
+	_, err = Verify(filename)
+	assert.EqualError(t, err, "log is truncated: it ends at entry 2, its head is entry 3", "should detect truncation")
+	_, err = Open(filename, "bob")
+	assert.Contains(t, err.Error(), "does not end with its head", "should not append to a truncated log")
+
+	writeLines(t, filename, lines)
+	_, err = Verify(filename)
+	assert.Nil(t, err, "should verify the restored log")
+	assert.Nil(t, os.Remove(headFile(filename)), "should remove the head")
+	_, err = Open(filename, "bob")
+	assert.Contains(t, err.Error(), "has no head", "should not append to a log without head")
+}
+
+func mustEntry(t *testing.T, line []byte) Entry {
+	var entry Entry
+	assert.Nil(t, json.Unmarshal(line, &entry), "should decode the entry")
+	return entry
+}
+
+func mustHash(t *testing.T, line []byte) string {
+	hash, err := mustEntry(t, line).hash()
+	assert.Nil(t, err, "should hash the entry")
+	return hash
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatewayError - Transactor failing like the gateway, which embeds the chaincode error payload in its own message.
+type gatewayError struct {
+	Fake
+	err error
+}
+
+func (ge *gatewayError) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return nil, ge.err
+}
+
+func TestDecodeError(t *testing.T) {
+	// Error as returned by the gateway when the endorsement fails.
+	err := errors.New(`Failed to submit: Multiple errors occurred: - Transaction processing for endorser [localhost:9051]: Chaincode status Code: (500) UNKNOWN. Description: {"code":"ALREADY_REQUESTED","message":"medicine aspirin:00001 has already been bought","details":{"medName":"aspirin","medNumber":"00001","state":"REQUESTED"}} - Transaction processing for endorser [localhost:7051]: Chaincode status Code: (500) UNKNOWN. Description: {"code":"ALREADY_REQUESTED","message":"medicine aspirin:00001 has already been bought"}`)
+	ce, ok := DecodeError(err)
+	assert.True(t, ok, "should find the contract error in the gateway error")
+	assert.Equal(t, CodeAlreadyRequested, ce.Code, "should decode the code")
+	assert.Equal(t, "medicine aspirin:00001 has already been bought", ce.Message, "should decode the message")
+	assert.Equal(t, "REQUESTED", ce.Details["state"], "should decode the details")
+	assert.Equal(t, CodeAlreadyRequested, ErrorCode(err), "should return the code")
+
+	_, ok = DecodeError(errors.New(`Failed to connect: {"code" missing`))
+	assert.False(t, ok, "should not decode other errors")
+	assert.Equal(t, "", ErrorCode(errors.New("connection refused")), "should return no code for other errors")
+	assert.Equal(t, "", ErrorCode(nil), "should return no code without error")
+}
+
+func TestClientErrors(t *testing.T) {
+	failing := &gatewayError{err: errors.New(`Description: {"code":"NOT_FOUND","message":"could not retrieve medicine from ledger: No state found"}`)}
+	c := New(failing, failing, failing, "alice")
+
+	_, err := c.Request(context.Background(), "aspirin", "00001")
+	ce, ok := err.(*ContractError)
+	assert.True(t, ok, "should return contract errors as *ContractError")
+	assert.Equal(t, CodeNotFound, ce.Code, "should decode the contract error")
+	assert.Equal(t, "could not retrieve medicine from ledger: No state found (NOT_FOUND)", err.Error(), "should add the code to the message")
+
+	connection := errors.New("connection refused")
+	failing.err = connection
+	_, err = c.Request(context.Background(), "aspirin", "00001")
+	assert.True(t, errors.Is(err, connection), "should wrap other errors")
+	assert.Equal(t, "transaction Request failed: connection refused", err.Error(), "should name the transaction")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake := NewFake()
+	_, err = New(fake, fake, fake, "alice").CheckAvailableMedicine(ctx)
+	assert.Equal(t, context.Canceled, err, "should not invoke transactions when the context is done")
+	assert.Empty(t, fake.Calls(), "should not call the contract")
+}
+
+func TestClientArguments(t *testing.T) {
+	fake := NewFake()
+	c := New(fake, fake, fake, "bob")
+	c.SetTPMKey("secret")
+	fake.Handle("Destroy", func(args []string) ([]byte, error) {
+		return []byte(`{"certificateID":"DESTROY:aspirin:00001","witnesses":["carol","dave"]}`), nil
+	})
+	fake.Handle("InventoryReport", func(args []string) ([]byte, error) {
+		return []byte(`{"generatedAt":"2022-02-21T09:00:00Z","total":{"count":2,"totalValue":"$12.50","distinctHolders":1}}`), nil
+	})
+
+	cert, err := c.Destroy(context.Background(), DestroyInput{MedName: "aspirin", MedNumber: "00001", Method: "incineration",
+		Witnesses: []string{"carol", "dave"}, Date: "2022.05.09"})
+	assert.Nil(t, err, "should destroy the medicine")
+	assert.Equal(t, []string{"carol", "dave"}, cert.Witnesses, "should decode the certificate")
+	report, err := c.InventoryReport(context.Background())
+	assert.Nil(t, err, "should return the report")
+	assert.Equal(t, 2, report.Total.Count, "should decode the report")
+	fake.Handle("RaiseExpiryAlert", func(args []string) ([]byte, error) { return nil, nil })
+	err = c.RaiseExpiryAlert(context.Background(), ExpiryAlert{MedName: "aspirin", Count: 3, Threshold: 3, Days: 30, EarliestExpiry: "2022.03.01"})
+	assert.Nil(t, err, "should raise the alert")
+
+	assert.Equal(t, []Call{
+		{Name: "Destroy", Args: []string{"aspirin", "00001", "incineration", "carol,dave", "2022.05.09", "", "bob", "secret"}, Submitted: true},
+		{Name: "InventoryReport", Args: []string{"bob", "secret"}, Submitted: false},
+		{Name: "RaiseExpiryAlert", Args: []string{`{"medName":"aspirin","count":3,"threshold":3,"days":30,"earliestExpiry":"2022.03.01"}`, "bob", "secret"}, Submitted: true},
+	}, fake.Calls(), "should pass the arguments in order followed by the credentials, and only evaluate queries")
+}
+
+// recorder - Observer keeping the outcomes of the transactions.
+type recorder struct {
+	outcomes []Outcome
+}
+
+func (r *recorder) ObserveTransaction(outcome Outcome) {
+	outcome.Duration = 0
+	r.outcomes = append(r.outcomes, outcome)
+}
+
+// conflicting - Transactor committing every submitted transaction with a read conflict, as the gateway reports it.
+type conflicting struct {
+	Fake
+}
+
+func (cf *conflicting) SubmitAndCommit(name string, args ...string) ([]byte, Commit, error) {
+	return nil, Commit{TxID: "tx1", ValidationCode: "MVCC_READ_CONFLICT", Duration: time.Second},
+		errors.New("received invalid transaction")
+}
+
+func TestObserver(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	observer := &recorder{}
+	c := New(fake, fake, fake, "bob")
+	c.SetObserver(observer)
+
+	tpmkey, _ := c.TPMKeyGen(ctx)
+	_, err := c.Request(ctx, "aspirin", "00001")
+	c.CheckAvailableMedicine(ctx)
+	assert.Equal(t, []Outcome{
+		{Function: "TPMKeyGen", Args: []string{"bob"}, Submitted: true},
+		{Function: "Request", Args: []string{"aspirin", "00001", "bob", Redacted}, Submitted: true, Err: err},
+		{Function: "CheckAvailableMedicine", Args: []string{}},
+	}, observer.outcomes, "should observe every transaction with its error and without the TPM key")
+	assert.NotEqual(t, Redacted, tpmkey, "should only redact the arguments")
+
+	conflict := &conflicting{}
+	c = New(conflict, conflict, conflict, "bob")
+	other := &recorder{}
+	c.SetObserver(Observers{observer, other})
+	_, err = c.Request(ctx, "aspirin", "00001")
+	assert.Equal(t, Outcome{Function: "Request", Args: []string{"aspirin", "00001", "bob", ""}, Submitted: true, TxID: "tx1",
+		ValidationCode: "MVCC_READ_CONFLICT", CommitDuration: time.Second, Err: err}, observer.outcomes[3], "should observe how the transaction was committed")
+	assert.Equal(t, observer.outcomes[3:], other.outcomes, "should pass the outcome to all observers")
+
+	assert.EqualError(t, c.Ping(ctx), "no system contract to check the chaincode with", "should need the system contract")
+	fake.Handle("GetMetadata", func(args []string) ([]byte, error) { return []byte(`{}`), nil })
+	c.SetSystemContract(fake)
+	assert.Nil(t, c.Ping(ctx), "should evaluate GetMetadata")
+	assert.Len(t, observer.outcomes, 4, "should not observe pings")
+}
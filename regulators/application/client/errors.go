@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Error codes returned by the smart contract.
+const (
+	CodeInvalidArgument      = "INVALID_ARGUMENT"
+	CodeUnauthenticated      = "UNAUTHENTICATED"
+	CodeUnauthorizedOrg      = "UNAUTHORIZED_ORG"
+	CodeMissingRole          = "MISSING_ROLE"
+	CodeNotFound             = "NOT_FOUND"
+	CodeAlreadyExists        = "ALREADY_EXISTS"
+	CodeAlreadyRequested     = "ALREADY_REQUESTED"
+	CodeNotAvailable         = "NOT_AVAILABLE"
+	CodeInvalidState         = "INVALID_STATE"
+	CodeChecksumMismatch     = "CHECKSUM_MISMATCH"
+	CodeLotMismatch          = "LOT_MISMATCH"
+	CodePrescriptionRequired = "PRESCRIPTION_REQUIRED"
+	CodeQuotaExceeded        = "QUOTA_EXCEEDED"
+	CodeInsufficientStock    = "INSUFFICIENT_STOCK"
+	CodeSecondApproval       = "SECOND_APPROVAL_REQUIRED"
+	CodeLedger               = "LEDGER_ERROR"
+	CodeInternal             = "INTERNAL"
+)
+
+// ContractError - Error returned by the smart contract, as serialized into the chaincode error payload.
+type ContractError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	Fields  []FieldError      `json:"fields,omitempty"`
+}
+
+// FieldError - Invalid argument of a transaction.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error - Returns the message followed by the code.
+func (ce *ContractError) Error() string {
+	return ce.Message + " (" + ce.Code + ")"
+}
+
+// DecodeError - Returns the contract error of an error returned by a transaction. Errors of the gateway embed the
+// payload in their own message, which may hold the payload of several endorsers.
+func DecodeError(err error) (*ContractError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var ce *ContractError
+	if errors.As(err, &ce) {
+		return ce, true
+	}
+
+	message := err.Error()
+	for start := strings.Index(message, `{"code":`); start >= 0; {
+		// The decoder stops at the end of the JSON object, ignoring what the gateway added after it.
+		var decoded ContractError
+		if json.NewDecoder(strings.NewReader(message[start:])).Decode(&decoded) == nil && decoded.Code != "" {
+			return &decoded, true
+		}
+		next := strings.Index(message[start+1:], `{"code":`)
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return nil, false
+}
+
+// ErrorCode - Returns the code of a contract error, or an empty string for other errors (e.g. connection errors).
+func ErrorCode(err error) string {
+	if ce, ok := DecodeError(err); ok {
+		return ce.Code
+	}
+	return ""
+}
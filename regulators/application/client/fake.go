@@ -0,0 +1,368 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler - Answers a transaction of the fake with its JSON result.
+type Handler func(args []string) ([]byte, error)
+
+// Call - Transaction invoked on the fake.
+type Call struct {
+	Name      string
+	Args      []string
+	Submitted bool
+}
+
+// Fake - In-memory stand-in for the contracts of the chaincode, to run the applications and their tests without a
+// Fabric network. It implements TPM authentication and the request and approval of medicine, other transactions are
+// answered by the handlers set with Handle. Like queries on a peer, evaluated transactions never change its state.
+type Fake struct {
+	mu       sync.Mutex
+	state    *fakeState
+	handlers map[string]Handler
+	calls    []Call
+}
+
+// fakeState - Ledger of the fake, users are stored lowercased as the contract does without a TPM.
+type fakeState struct {
+	tpmkeys   map[string]string
+	medicines map[string]*MedicalSupply
+}
+
+// fakeTransaction - Transaction implemented by the fake, authenticated transactions end with the user and TPM key.
+type fakeTransaction struct {
+	args          int
+	authenticated bool
+	invoke        func(state *fakeState, user string, args []string) (interface{}, error)
+}
+
+var fakeTransactions = map[string]fakeTransaction{
+	"TPMKeyGen":              {1, false, (*fakeState).tpmKeyGen},
+	"InitLedger":             {0, true, (*fakeState).initLedger},
+	"Issue":                  {7, true, (*fakeState).issue},
+	"Delete":                 {2, true, (*fakeState).delete},
+	"Request":                {2, true, (*fakeState).request},
+	"CancelRequest":          {2, true, (*fakeState).cancelRequest},
+	"ApproveRequest":         {2, true, (*fakeState).approveRequest},
+	"RejectRequest":          {2, true, (*fakeState).rejectRequest},
+	"SearchMedicineByName":   {1, false, (*fakeState).searchMedicineByName},
+	"CheckAvailableMedicine": {0, false, (*fakeState).checkAvailableMedicine},
+	"CheckHistory":           {0, true, (*fakeState).checkHistory},
+	"CheckRequestedMedicine": {0, true, (*fakeState).checkRequestedMedicine},
+	"CheckUserHistory":       {0, true, (*fakeState).checkUserHistory},
+}
+
+// NewFake - Creates a fake with an empty ledger.
+func NewFake() *Fake {
+	return &Fake{
+		state:    &fakeState{tpmkeys: make(map[string]string), medicines: make(map[string]*MedicalSupply)},
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle - Answers the transaction with the handler instead of the implementation of the fake.
+func (f *Fake) Handle(name string, handler Handler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[name] = handler
+}
+
+// Calls - Returns the transactions invoked on the fake in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// SubmitTransaction - Invokes the transaction and keeps the changes to the ledger.
+func (f *Fake) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.invoke(f.state, name, args, true)
+}
+
+// EvaluateTransaction - Invokes the transaction on a copy of the ledger, which is discarded.
+func (f *Fake) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.invoke(f.state.clone(), name, args, false)
+}
+
+// invoke - Records the call and answers it by its handler or the implementation of the fake.
+func (f *Fake) invoke(state *fakeState, name string, args []string, submitted bool) ([]byte, error) {
+	// Contract names may prefix the transaction name, as in org.medstore.customer:Request.
+	name = name[strings.LastIndex(name, ":")+1:]
+	f.calls = append(f.calls, Call{Name: name, Args: append([]string(nil), args...), Submitted: submitted})
+
+	if handler, ok := f.handlers[name]; ok {
+		return handler(args)
+	}
+	transaction, ok := fakeTransactions[name]
+	if !ok {
+		return nil, fakeError(CodeInternal, "transaction %s is not supported by the fake, set a handler with Handle", name)
+	}
+
+	expected := transaction.args
+	if transaction.authenticated {
+		expected += 2
+	}
+	if len(args) != expected {
+		return nil, fakeError(CodeInvalidArgument, "%s takes %d arguments, got %d", name, expected, len(args))
+	}
+
+	user := ""
+	if transaction.authenticated {
+		user = strings.ToLower(args[len(args)-2])
+		tpmkey, ok := state.tpmkeys[user]
+		if !ok {
+			return nil, fakeError(CodeUnauthenticated, "user has not authenticated yet. Please invoke TPMKeyGen first")
+		}
+		if tpmkey != args[len(args)-1] {
+			return nil, fakeError(CodeUnauthenticated, "provided tpm key does not match with registered authentication")
+		}
+	}
+
+	result, err := transaction.invoke(state, user, args)
+	if err != nil {
+		return nil, err
+	}
+	switch result := result.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(result), nil
+	}
+	return json.Marshal(result)
+}
+
+// fakeError - Creates a contract error as the fake returns it.
+func fakeError(code string, format string, args ...interface{}) *ContractError {
+	return &ContractError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// medicineError - Creates a contract error about a medicine.
+func medicineError(code string, medicine *MedicalSupply, format string, args ...interface{}) *ContractError {
+	ce := fakeError(code, format, args...)
+	ce.Details = map[string]string{"medName": medicine.MedName, "medNumber": medicine.MedNumber, "state": medicine.State.String()}
+	return ce
+}
+
+// clone - Copies the ledger, medicine is copied on write so sharing it is safe.
+func (state *fakeState) clone() *fakeState {
+	copied := &fakeState{tpmkeys: make(map[string]string), medicines: make(map[string]*MedicalSupply)}
+	for user, tpmkey := range state.tpmkeys {
+		copied.tpmkeys[user] = tpmkey
+	}
+	for key, medicine := range state.medicines {
+		copied.medicines[key] = medicine
+	}
+	return copied
+}
+
+// get - Returns a copy of the medicine to change.
+func (state *fakeState) get(medName string, medNumber string) (*MedicalSupply, error) {
+	medicine, ok := state.medicines[strings.ToLower(medName)+":"+medNumber]
+	if !ok {
+		return nil, fakeError(CodeNotFound, "could not retrieve medicine from ledger: No state found for %s:%s", strings.ToLower(medName), medNumber)
+	}
+	copied := *medicine
+	return &copied, nil
+}
+
+// put - Stores the medicine.
+func (state *fakeState) put(medicine *MedicalSupply) {
+	state.medicines[medicine.MedName+":"+medicine.MedNumber] = medicine
+}
+
+// list - Returns the medicine matching the filter, ordered by key.
+func (state *fakeState) list(match func(*MedicalSupply) bool) []*MedicalSupply {
+	keys := make([]string, 0, len(state.medicines))
+	for key := range state.medicines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	medicines := []*MedicalSupply{}
+	for _, key := range keys {
+		if match(state.medicines[key]) {
+			medicines = append(medicines, state.medicines[key])
+		}
+	}
+	return medicines
+}
+
+func (state *fakeState) tpmKeyGen(_ string, args []string) (interface{}, error) {
+	user := strings.ToLower(args[0])
+	if _, ok := state.tpmkeys[user]; ok {
+		return nil, fakeError(CodeAlreadyExists, "user %s has already created a TPM authentication", args[0])
+	}
+	tpmkey := "fake-tpmkey-" + strconv.Itoa(len(state.tpmkeys)+1)
+	state.tpmkeys[user] = tpmkey
+	return tpmkey, nil
+}
+
+func (state *fakeState) initLedger(_ string, _ []string) (interface{}, error) {
+	for _, medicine := range []MedicalSupply{
+		{MedName: "aspirin", MedNumber: "00001", Disease: "pain management", Expiration: "2022.05.09", Price: "$10"},
+		{MedName: "vicodin", MedNumber: "00002", Disease: "pain management", Expiration: "2022.07.01", Price: "$14", RxOnly: true, Schedule: "II"},
+		{MedName: "ibuprofen", MedNumber: "00011", Disease: "fever", Expiration: "2022.02.28", Price: "$12"},
+	} {
+		medicine := medicine
+		medicine.Holder = "MedStore"
+		medicine.State = Available
+		state.put(&medicine)
+	}
+	return nil, nil
+}
+
+func (state *fakeState) issue(_ string, args []string) (interface{}, error) {
+	medName, medNumber := strings.ToLower(args[0]), args[1]
+	if _, ok := state.medicines[medName+":"+medNumber]; ok {
+		ce := fakeError(CodeAlreadyExists, "invalid Issue: medNumber is already in use by medicine %s:%s", medName, medNumber)
+		ce.Fields = []FieldError{{Field: "medNumber", Message: fmt.Sprintf("is already in use by medicine %s:%s", medName, medNumber)}}
+		return nil, ce
+	}
+	rxOnly, err := strconv.ParseBool(args[5])
+	if err != nil {
+		ce := fakeError(CodeInvalidArgument, "invalid Issue: rxOnly should be true or false")
+		ce.Fields = []FieldError{{Field: "rxOnly", Message: "should be true or false"}}
+		return nil, ce
+	}
+
+	medicine := &MedicalSupply{
+		MedName:    medName,
+		MedNumber:  medNumber,
+		Disease:    strings.ToLower(args[2]),
+		Expiration: args[3],
+		Price:      args[4],
+		Holder:     "MedStore",
+		RxOnly:     rxOnly,
+		Schedule:   strings.ToUpper(args[6]),
+		State:      Available,
+	}
+	state.put(medicine)
+	return medicine, nil
+}
+
+func (state *fakeState) delete(_ string, args []string) (interface{}, error) {
+	medicine, err := state.get(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if medicine.State != Available && medicine.State != Send {
+		return nil, medicineError(CodeInvalidState, medicine, "medicine %s:%s is %s and can't be deleted from ledger", medicine.MedName, medicine.MedNumber, medicine.State)
+	}
+	delete(state.medicines, medicine.MedName+":"+medicine.MedNumber)
+	return nil, nil
+}
+
+func (state *fakeState) request(user string, args []string) (interface{}, error) {
+	medicine, err := state.get(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if medicine.Holder != "MedStore" {
+		return nil, medicineError(CodeAlreadyRequested, medicine, "medicine %s:%s has already been bought", medicine.MedName, medicine.MedNumber)
+	}
+	if medicine.State != Available {
+		return nil, medicineError(CodeNotAvailable, medicine, "medicine %s:%s is currently not available at MedStore", medicine.MedName, medicine.MedNumber)
+	}
+	// The fake has no prescriptions to dispense from.
+	if medicine.RxOnly {
+		return nil, fakeError(CodePrescriptionRequired, "medicine %s requires a valid prescription for 1 unit(s)", medicine.MedName)
+	}
+
+	medicine.State = Requested
+	medicine.Holder = user
+	medicine.RequestDate = time.Now().UTC().Format(time.RFC3339)
+	state.put(medicine)
+	return medicine, nil
+}
+
+func (state *fakeState) cancelRequest(user string, args []string) (interface{}, error) {
+	medicine, err := state.get(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if medicine.State != Requested || medicine.Holder != user {
+		return nil, medicineError(CodeInvalidState, medicine, "cannot cancel because medicine has not been requested")
+	}
+
+	medicine.State = Available
+	medicine.Holder = "MedStore"
+	medicine.RequestDate = ""
+	state.put(medicine)
+	return medicine, nil
+}
+
+func (state *fakeState) approveRequest(user string, args []string) (interface{}, error) {
+	medicine, err := state.get(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	// Scheduled medicine needs a second approval of a different regulator before it is send.
+	switch {
+	case medicine.State == Requested && medicine.Schedule != "":
+		medicine.State = PendingSecondApproval
+		medicine.FirstApprover = user
+	case medicine.State == Requested:
+		medicine.State = Send
+	case medicine.State == PendingSecondApproval:
+		if medicine.FirstApprover == user {
+			return nil, medicineError(CodeSecondApproval, medicine, "medicine %s:%s has already been approved by you, a second regulator has to approve it", medicine.MedName, medicine.MedNumber)
+		}
+		medicine.State = Send
+	default:
+		return nil, medicineError(CodeInvalidState, medicine, "cannot approve medicine that has not been requested")
+	}
+	state.put(medicine)
+	return medicine, nil
+}
+
+func (state *fakeState) rejectRequest(_ string, args []string) (interface{}, error) {
+	medicine, err := state.get(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if medicine.State != Requested && medicine.State != PendingSecondApproval {
+		return nil, medicineError(CodeInvalidState, medicine, "cannot disapprove medicine that has not been requested")
+	}
+
+	medicine.State = Available
+	medicine.Holder = "MedStore"
+	medicine.RequestDate = ""
+	medicine.FirstApprover = ""
+	state.put(medicine)
+	return medicine, nil
+}
+
+func (state *fakeState) searchMedicineByName(_ string, args []string) (interface{}, error) {
+	medName := strings.ToLower(args[0])
+	return state.list(func(medicine *MedicalSupply) bool {
+		return medicine.MedName == medName && medicine.State == Available
+	}), nil
+}
+
+func (state *fakeState) checkAvailableMedicine(_ string, _ []string) (interface{}, error) {
+	return state.list(func(medicine *MedicalSupply) bool { return medicine.State == Available }), nil
+}
+
+func (state *fakeState) checkHistory(_ string, _ []string) (interface{}, error) {
+	return state.list(func(*MedicalSupply) bool { return true }), nil
+}
+
+func (state *fakeState) checkRequestedMedicine(_ string, _ []string) (interface{}, error) {
+	return state.list(func(medicine *MedicalSupply) bool { return medicine.State == Requested }), nil
+}
+
+func (state *fakeState) checkUserHistory(user string, _ []string) (interface{}, error) {
+	return state.list(func(medicine *MedicalSupply) bool { return medicine.Holder == user }), nil
+}
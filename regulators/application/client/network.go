@@ -0,0 +1,242 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// timeout - Time transactions have to be evaluated, or endorsed and committed, as in the gateway of the SDK.
+const timeout = 5 * time.Minute
+
+// Connect - Creates a client of the contracts of the chaincode on a channel of the network of the connection profile,
+// invoking the transactions with the identity of the user. The profile is read as by the gateway of the SDK, see
+// profileConfig.
+func Connect(profile core.ConfigProvider, channelID string, chaincodeName string, user string, id *gateway.X509Identity) (*Client, error) {
+	sdk, err := fabsdk.New(profileConfig(profile, user, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the connection profile: %w", err)
+	}
+	channelClient, err := channel.New(sdk.ChannelContext(channelID, fabsdk.WithUser(user)))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to channel %s: %w", channelID, err)
+	}
+
+	contract := func(name string) *channelContract {
+		return &channelContract{client: channelClient, chaincodeID: chaincodeName, name: name}
+	}
+	c := New(contract(CustomerContract), contract(RegulatorContract), contract(AuthContract), user)
+	c.system = contract(SystemContract)
+	return c, nil
+}
+
+// channelContract - Contract of the chaincode, invoked with the channel client of the SDK. Transactions are submitted
+// like the gateway submits them, but the commit is timed apart from the endorsement.
+type channelContract struct {
+	client      *channel.Client
+	chaincodeID string
+	name        string
+}
+
+// request - Returns the request of the transaction of the contract.
+func (cc *channelContract) request(name string, args []string) channel.Request {
+	bytes := make([][]byte, len(args))
+	for i, arg := range args {
+		bytes[i] = []byte(arg)
+	}
+	return channel.Request{ChaincodeID: cc.chaincodeID, Fcn: cc.name + ":" + name, Args: bytes}
+}
+
+// EvaluateTransaction - Evaluates the transaction on a peer, without ordering it.
+func (cc *channelContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	response, err := cc.client.Query(cc.request(name, args), channel.WithTimeout(fab.Query, timeout))
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
+// SubmitTransaction - Submits the transaction and waits until it's committed.
+func (cc *channelContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	result, _, err := cc.SubmitAndCommit(name, args...)
+	return result, err
+}
+
+// SubmitAndCommit - Submits the transaction: it's endorsed by the peers the SDK selects, the endorsements are
+// validated, and the commitHandler sends it to the orderer and waits for its commit event. The channel client retries
+// transactions which failed transiently, e.g. with an MVCC read conflict, so only the commit of the last attempt is
+// returned.
+func (cc *channelContract) SubmitAndCommit(name string, args ...string) ([]byte, Commit, error) {
+	committer := &commitHandler{}
+	handler := invoke.NewSelectAndEndorseHandler(
+		invoke.NewEndorsementValidationHandler(
+			invoke.NewSignatureValidationHandler(committer),
+		),
+	)
+	response, err := cc.client.InvokeHandler(handler, cc.request(name, args),
+		channel.WithTimeout(fab.Execute, timeout),
+		channel.WithRetry(retry.DefaultChannelOpts),
+		channel.WithBeforeRetry(func(error) { committer.commit = Commit{} }),
+	)
+	if err != nil {
+		return nil, committer.commit, err
+	}
+	return response.Payload, committer.commit, nil
+}
+
+// commitHandler - Last handler of a submission, as the commit handler of the SDK it sends the endorsed transaction to
+// the orderer and waits for its commit event. It times the commit from sending the transaction.
+type commitHandler struct {
+	commit Commit
+}
+
+// Handle - Sends the transaction and waits until it's committed or the request times out.
+func (h *commitHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	txID := string(requestContext.Response.TransactionID)
+	registration, events, err := clientContext.EventService.RegisterTxStatusEvent(txID)
+	if err != nil {
+		requestContext.Error = fmt.Errorf("could not register for the commit event of transaction %s: %w", txID, err)
+		return
+	}
+	defer clientContext.EventService.Unregister(registration)
+
+	tx, err := clientContext.Transactor.CreateTransaction(fab.TransactionRequest{
+		Proposal:          requestContext.Response.Proposal,
+		ProposalResponses: requestContext.Response.Responses,
+	})
+	if err != nil {
+		requestContext.Error = fmt.Errorf("could not create transaction %s: %w", txID, err)
+		return
+	}
+	sent := time.Now()
+	_, err = clientContext.Transactor.SendTransaction(tx)
+	if err != nil {
+		requestContext.Error = fmt.Errorf("could not send transaction %s to the orderer: %w", txID, err)
+		return
+	}
+
+	select {
+	case event := <-events:
+		h.commit = Commit{TxID: event.TxID, ValidationCode: event.TxValidationCode.String(), Duration: time.Since(sent)}
+		requestContext.Response.TxValidationCode = event.TxValidationCode
+		if event.TxValidationCode != peer.TxValidationCode_VALID {
+			// The status lets the channel client retry transient failures, e.g. MVCC read conflicts.
+			requestContext.Error = status.New(status.EventServerStatus, int32(event.TxValidationCode),
+				"received invalid transaction", nil)
+		}
+	case <-requestContext.Ctx.Done():
+		requestContext.Error = status.New(status.ClientStatus, status.Timeout.ToInt32(),
+			"Execute didn't receive block event", nil)
+	}
+}
+
+// profileConfig - Returns the connection profile with the additions of the gateway of the SDK: when the profile has no
+// channels, the peers of the organisation of the client serve every channel, and with DISCOVERY_AS_LOCALHOST=true
+// the discovered peers and orderers are reached on localhost, as in the test network. The identity of the user is
+// added to the organisation of the client, so the SDK signs with it without a credential store.
+func profileConfig(profile core.ConfigProvider, user string, id *gateway.X509Identity) core.ConfigProvider {
+	return func() ([]core.ConfigBackend, error) {
+		backends, err := profile()
+		if err != nil {
+			return nil, err
+		}
+		if len(backends) != 1 {
+			return nil, fmt.Errorf("expected a single connection profile, got %d", len(backends))
+		}
+		backend := backends[0]
+
+		org, _ := backend.Lookup("client.organization")
+		config := &connectionProfile{ConfigBackend: backend, org: fmt.Sprint(org), user: user, id: id}
+		if strings.ToUpper(os.Getenv("DISCOVERY_AS_LOCALHOST")) == "TRUE" {
+			localhost := []map[string]string{{
+				"pattern":                             "([^:]+):(\\d+)",
+				"urlSubstitutionExp":                  "localhost:${2}",
+				"sslTargetOverrideUrlSubstitutionExp": "${1}",
+				"mappedHost":                          "${1}",
+			}}
+			config.matchers = map[string][]map[string]string{"peer": localhost, "orderer": localhost}
+		}
+		if _, ok := backend.Lookup("channels"); !ok {
+			config.channels = defaultChannel(backend, config.org)
+		}
+		return []core.ConfigBackend{config}, nil
+	}
+}
+
+// defaultChannel - Returns the _default channel the SDK uses for channels missing in the profile, served by the peers
+// of the organisation.
+func defaultChannel(backend core.ConfigBackend, org string) map[string]interface{} {
+	value, ok := backend.Lookup("organizations." + org + ".peers")
+	if !ok {
+		return nil
+	}
+	peers, _ := value.([]interface{})
+	roles := map[string]bool{"endorsingPeer": true, "chaincodeQuery": true, "ledgerQuery": true, "eventSource": true}
+	channelPeers := make(map[string]interface{}, len(peers))
+	for _, p := range peers {
+		channelPeers[fmt.Sprint(p)] = roles
+	}
+	return map[string]interface{}{"_default": map[string]interface{}{"peers": channelPeers}}
+}
+
+// connectionProfile - Connection profile with the entity matchers, channels and user added by profileConfig.
+type connectionProfile struct {
+	core.ConfigBackend
+	org      string
+	user     string
+	id       *gateway.X509Identity
+	matchers map[string][]map[string]string
+	channels map[string]interface{}
+}
+
+// Lookup - Returns the value of the key in the profile, or the one added to it.
+func (cp *connectionProfile) Lookup(key string) (interface{}, bool) {
+	switch {
+	case key == "entityMatchers" && cp.matchers != nil:
+		return cp.matchers, true
+	case key == "channels" && cp.channels != nil:
+		return cp.channels, true
+	case key == "organizations":
+		return cp.organizations()
+	}
+	return cp.ConfigBackend.Lookup(key)
+}
+
+// organizations - Returns the organisations of the profile, the one of the client embedding the user.
+func (cp *connectionProfile) organizations() (interface{}, bool) {
+	value, ok := cp.ConfigBackend.Lookup("organizations")
+	orgs, isMap := value.(map[string]interface{})
+	if !ok || !isMap {
+		return value, ok
+	}
+
+	added := make(map[string]interface{}, len(orgs))
+	for name, org := range orgs {
+		config, isMap := org.(map[string]interface{})
+		if strings.EqualFold(name, cp.org) && isMap {
+			withUser := make(map[string]interface{}, len(config)+1)
+			for k, v := range config {
+				withUser[k] = v
+			}
+			// The SDK looks the users up by their lower case name.
+			withUser["users"] = map[string]interface{}{strings.ToLower(cp.user): map[string]interface{}{
+				"cert": map[string]interface{}{"pem": cp.id.Certificate()},
+				"key":  map[string]interface{}{"pem": cp.id.Key()},
+			}}
+			org = withUser
+		}
+		added[name] = org
+	}
+	return added, true
+}
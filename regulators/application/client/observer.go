@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SystemContract - Contract contractapi adds to every chaincode, its GetMetadata describes the contracts.
+const SystemContract = "org.hyperledger.fabric"
+
+// Validation codes of committed transactions.
+const (
+	// ValidationValid - The transaction changed the ledger.
+	ValidationValid = "VALID"
+	// ValidationMVCCConflict - Another transaction changed the keys the transaction read since its endorsement.
+	ValidationMVCCConflict = "MVCC_READ_CONFLICT"
+)
+
+// Redacted - Replaces the TPM key in the arguments passed to observers.
+const Redacted = "[REDACTED]"
+
+// Outcome - Outcome of a transaction invoked by a client. The TPM key of the user is redacted from its arguments.
+type Outcome struct {
+	Function  string
+	Args      []string
+	Submitted bool
+	Duration  time.Duration
+	// TxID, ValidationCode and CommitDuration are set when a submitted transaction reached the ledger, as far as the
+	// transactor reports it. A transaction failing its validation, e.g. with MVCC_READ_CONFLICT, reached the ledger
+	// but did not change it. CommitDuration is the part of Duration after the endorsement, see Commit.
+	TxID           string
+	ValidationCode string
+	CommitDuration time.Duration
+	Err            error
+}
+
+// Observer - Receives the outcome of every transaction of a client, e.g. to export metrics. Clients are shared by
+// goroutines, so observers have to be safe for concurrent use.
+type Observer interface {
+	ObserveTransaction(outcome Outcome)
+}
+
+// Observers - Observer passing the outcomes to each of the observers in order.
+type Observers []Observer
+
+// ObserveTransaction - Passes the outcome to every observer.
+func (observers Observers) ObserveTransaction(outcome Outcome) {
+	for _, observer := range observers {
+		observer.ObserveTransaction(outcome)
+	}
+}
+
+// SetObserver - Sets the observer of the transactions of the client.
+func (c *Client) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// SetSystemContract - Sets the system contract of the chaincode which Ping checks, Connect sets it.
+func (c *Client) SetSystemContract(system Transactor) {
+	c.system = system
+}
+
+// Ping - Checks that the chaincode answers, by evaluating GetMetadata of the system contract. It's not observed, as
+// it's no transaction of the application.
+func (c *Client) Ping(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	if c.system == nil {
+		return errors.New("no system contract to check the chaincode with")
+	}
+	_, err = c.system.EvaluateTransaction("GetMetadata")
+	return err
+}
+
+// Commit - How a submitted transaction was committed. Duration is the time from sending the endorsed transaction to
+// the orderer until its commit event arrived, so it doesn't include the endorsement.
+type Commit struct {
+	TxID           string
+	ValidationCode string
+	Duration       time.Duration
+}
+
+// Committer - Transactor which reports how its submitted transactions were committed, implemented by the contracts
+// of Connect and of the simulator. The commit is empty when the transaction was not ordered, e.g. when its
+// endorsement failed.
+type Committer interface {
+	SubmitAndCommit(name string, args ...string) (result []byte, commit Commit, err error)
+}
+
+// redact - Returns the arguments with the TPM key of the user redacted.
+func (c *Client) redact(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if c.tpmkey != "" && arg == c.tpmkey {
+			arg = Redacted
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// IssueInput - Arguments of Issue, the schedule is only set for controlled substances.
+type IssueInput struct {
+	MedName    string
+	MedNumber  string
+	Disease    string
+	Expiration string
+	Price      string
+	RxOnly     bool
+	Schedule   string
+}
+
+// IssueFromGS1Input - Arguments of IssueFromGS1, the number and expiry are taken from the element string.
+type IssueFromGS1Input struct {
+	ElementString string
+	MedName       string
+	Disease       string
+	Price         string
+	RxOnly        bool
+	Schedule      string
+}
+
+// InspectionInput - Arguments of InspectReturn, the outcome is either restock or destroy and the refund at most the price paid.
+type InspectionInput struct {
+	ReturnID string
+	Outcome  string
+	Refund   string
+	Notes    string
+}
+
+// DestroyInput - Arguments of Destroy, the document hash is optional.
+type DestroyInput struct {
+	MedName      string
+	MedNumber    string
+	Method       string
+	Witnesses    []string
+	Date         string
+	DocumentHash string
+}
+
+// EPCISInput - Arguments of ExportEPCIS, empty values don't limit the export.
+type EPCISInput struct {
+	From    string
+	To      string
+	Product string
+}
+
+// InitLedger - Adds a base set of medicine to the ledger.
+func (c *Client) InitLedger(ctx context.Context) error {
+	_, err := c.submit(ctx, c.regulator, "InitLedger", c.credentials()...)
+	return err
+}
+
+// Issue - Adds new medicine to the ledger.
+func (c *Client) Issue(ctx context.Context, input IssueInput) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "Issue", input.MedName, input.MedNumber, input.Disease, input.Expiration,
+		input.Price, strconv.FormatBool(input.RxOnly), input.Schedule)
+}
+
+// IssueFromGS1 - Adds the medicine of a scanned pack to the ledger.
+func (c *Client) IssueFromGS1(ctx context.Context, input IssueFromGS1Input) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "IssueFromGS1", input.ElementString, input.MedName, input.Disease,
+		input.Price, strconv.FormatBool(input.RxOnly), input.Schedule)
+}
+
+// Delete - Removes a medicine from the ledger, which fails while it is requested, returned, quarantined or destroyed.
+func (c *Client) Delete(ctx context.Context, medName string, medNumber string) error {
+	_, err := c.submit(ctx, c.regulator, "Delete", c.credentials(medName, medNumber)...)
+	return err
+}
+
+// CheckHistory - Returns all medicine on the ledger.
+func (c *Client) CheckHistory(ctx context.Context) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.regulator, &medicines, "CheckHistory", c.credentials()...)
+	return medicines, err
+}
+
+// CheckRequestedMedicine - Returns all requested medicine.
+func (c *Client) CheckRequestedMedicine(ctx context.Context) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.regulator, &medicines, "CheckRequestedMedicine", c.credentials()...)
+	return medicines, err
+}
+
+// ApproveRequest - Approves a requested medicine, scheduled medicine needs the approval of a second regulator.
+func (c *Client) ApproveRequest(ctx context.Context, medName string, medNumber string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "ApproveRequest", medName, medNumber)
+}
+
+// RejectRequest - Rejects a requested medicine, it becomes available again.
+func (c *Client) RejectRequest(ctx context.Context, medName string, medNumber string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "RejectRequest", medName, medNumber)
+}
+
+// ChangeStatus - Changes the state of a medicine to available, requested or send.
+func (c *Client) ChangeStatus(ctx context.Context, medName string, medNumber string, status string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "ChangeStatus", medName, medNumber, status)
+}
+
+// ChangeHolder - Changes the holder of a medicine.
+func (c *Client) ChangeHolder(ctx context.Context, medName string, medNumber string, customer string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "ChangeHolder", medName, medNumber, customer)
+}
+
+// CheckPrescriptions - Returns all prescriptions.
+func (c *Client) CheckPrescriptions(ctx context.Context) ([]*Prescription, error) {
+	var prescriptions []*Prescription
+	err := c.query(ctx, c.regulator, &prescriptions, "CheckPrescriptions", c.credentials()...)
+	return prescriptions, err
+}
+
+// CheckOrders - Returns all orders.
+func (c *Client) CheckOrders(ctx context.Context) ([]*Order, error) {
+	var orders []*Order
+	err := c.query(ctx, c.regulator, &orders, "CheckOrders", c.credentials()...)
+	return orders, err
+}
+
+// ApproveOrder - Approves an order, orders with scheduled medicine need the approval of a second regulator.
+func (c *Client) ApproveOrder(ctx context.Context, orderID string) (*Order, error) {
+	var order Order
+	err := c.transact(ctx, c.regulator, &order, "ApproveOrder", c.credentials(orderID)...)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// RejectOrder - Rejects an order, all its medicine becomes available again.
+func (c *Client) RejectOrder(ctx context.Context, orderID string) (*Order, error) {
+	var order Order
+	err := c.transact(ctx, c.regulator, &order, "RejectOrder", c.credentials(orderID)...)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// InspectReturn - Inspects a returned medicine and either restocks it or quarantines it for Destroy.
+func (c *Client) InspectReturn(ctx context.Context, input InspectionInput) (*MedicineReturn, error) {
+	var medicineReturn MedicineReturn
+	args := c.credentials(input.ReturnID, input.Outcome, input.Refund, input.Notes)
+	err := c.transact(ctx, c.regulator, &medicineReturn, "InspectReturn", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &medicineReturn, nil
+}
+
+// CheckReturns - Returns all returns.
+func (c *Client) CheckReturns(ctx context.Context) ([]*MedicineReturn, error) {
+	var returns []*MedicineReturn
+	err := c.query(ctx, c.regulator, &returns, "CheckReturns", c.credentials()...)
+	return returns, err
+}
+
+// Quarantine - Takes available medicine out of stock awaiting its destruction.
+func (c *Client) Quarantine(ctx context.Context, medName string, medNumber string, note string) (*MedicalSupply, error) {
+	return c.submitMedicine(ctx, c.regulator, "Quarantine", medName, medNumber, note)
+}
+
+// Destroy - Destroys quarantined medicine and records its certificate of destruction.
+func (c *Client) Destroy(ctx context.Context, input DestroyInput) (*DestructionCertificate, error) {
+	var cert DestructionCertificate
+	args := c.credentials(input.MedName, input.MedNumber, input.Method, strings.Join(input.Witnesses, ","), input.Date, input.DocumentHash)
+	err := c.transact(ctx, c.regulator, &cert, "Destroy", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// GetDestructionCertificate - Returns the certificate of destruction of a medicine.
+func (c *Client) GetDestructionCertificate(ctx context.Context, medName string, medNumber string) (*DestructionCertificate, error) {
+	var cert DestructionCertificate
+	err := c.query(ctx, c.regulator, &cert, "GetDestructionCertificate", c.credentials(medName, medNumber)...)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// CheckDestructions - Returns all certificates of destruction.
+func (c *Client) CheckDestructions(ctx context.Context) ([]*DestructionCertificate, error) {
+	var certs []*DestructionCertificate
+	err := c.query(ctx, c.regulator, &certs, "CheckDestructions", c.credentials()...)
+	return certs, err
+}
+
+// SetQuotaRule - Adds or changes a quota rule.
+func (c *Client) SetQuotaRule(ctx context.Context, rule QuotaRule) (*QuotaRule, error) {
+	var changed QuotaRule
+	args := c.credentials(rule.RuleID, rule.Scope, rule.Target, strconv.Itoa(rule.MaxUnits), strconv.Itoa(rule.PeriodDays))
+	err := c.transact(ctx, c.regulator, &changed, "SetQuotaRule", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &changed, nil
+}
+
+// RemoveQuotaRule - Removes a quota rule.
+func (c *Client) RemoveQuotaRule(ctx context.Context, ruleID string) error {
+	_, err := c.submit(ctx, c.regulator, "RemoveQuotaRule", c.credentials(ruleID)...)
+	return err
+}
+
+// CheckQuotaRules - Returns all quota rules.
+func (c *Client) CheckQuotaRules(ctx context.Context) ([]*QuotaRule, error) {
+	var rules []*QuotaRule
+	err := c.query(ctx, c.regulator, &rules, "CheckQuotaRules", c.credentials()...)
+	return rules, err
+}
+
+// CheckQuotaUsage - Returns the customers which used at least the threshold percentage of a quota.
+func (c *Client) CheckQuotaUsage(ctx context.Context, threshold int) ([]*QuotaUsage, error) {
+	var usages []*QuotaUsage
+	err := c.query(ctx, c.regulator, &usages, "CheckQuotaUsage", c.credentials(strconv.Itoa(threshold))...)
+	return usages, err
+}
+
+// ExportEPCIS - Returns the supply-chain history as an EPCIS 2.0 JSON document.
+func (c *Client) ExportEPCIS(ctx context.Context, input EPCISInput) ([]byte, error) {
+	return c.evaluate(ctx, c.regulator, "ExportEPCIS", c.credentials(input.From, input.To, input.Product)...)
+}
+
+// InventoryReport - Returns the stock by medicine name and state.
+func (c *Client) InventoryReport(ctx context.Context) (*InventoryReport, error) {
+	var report InventoryReport
+	err := c.query(ctx, c.regulator, &report, "InventoryReport", c.credentials()...)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExpiryForecast - Returns the available medicine expiring within the amount of days, grouped by name.
+func (c *Client) ExpiryForecast(ctx context.Context, days int) ([]*ExpiryGroup, error) {
+	var forecast []*ExpiryGroup
+	err := c.query(ctx, c.regulator, &forecast, "ExpiryForecast", c.credentials(strconv.Itoa(days))...)
+	return forecast, err
+}
+
+// RaiseExpiryAlert - Emits the alert as ExpiryAlert chaincode event.
+func (c *Client) RaiseExpiryAlert(ctx context.Context, alert ExpiryAlert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	_, err = c.submit(ctx, c.regulator, "RaiseExpiryAlert", c.credentials(string(payload))...)
+	return err
+}
+
+// QueryMedicines - Returns the medicine matching a JSON filter, e.g. {"state":"AVAILABLE","sort":"-expiration"}.
+func (c *Client) QueryMedicines(ctx context.Context, filter string) ([]*MedicalSupply, error) {
+	var medicines []*MedicalSupply
+	err := c.query(ctx, c.regulator, &medicines, "QueryMedicines", c.credentials(filter)...)
+	return medicines, err
+}
+
+// RebuildSearchIndex - Adds all medicine to the search index, returns the amount of available medicine indexed.
+func (c *Client) RebuildSearchIndex(ctx context.Context) (int, error) {
+	var indexed int
+	err := c.transact(ctx, c.regulator, &indexed, "RebuildSearchIndex", c.credentials()...)
+	return indexed, err
+}
+
+// MigrateStates - Rewrites a page of ledger records to the latest schema, continue with the returned bookmark until done.
+func (c *Client) MigrateStates(ctx context.Context, bookmark string, pageSize int) (*MigrationProgress, error) {
+	var progress MigrationProgress
+	err := c.transact(ctx, c.regulator, &progress, "MigrateStates", c.credentials(bookmark, strconv.Itoa(pageSize))...)
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
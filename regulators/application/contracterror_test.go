@@ -0,0 +1,14 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+func TestDescribeContractError(t *testing.T) {
+	ce, _ := client.DecodeError(errors.New(`Description: {"code":"INVALID_ARGUMENT","message":"invalid Issue: medName is required","fields":[{"field":"medName","message":"is required"}]}`))
+	assert.Equal(t, "invalid Issue: medName is required (INVALID_ARGUMENT)\n  - medName is required\nCheck the values you entered and try again.", describe(ce), "should list the fields and a hint")
+}
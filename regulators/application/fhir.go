@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"medical-supply/client"
+)
+
+// FHIR R4 resources and data types, limited to the elements the export uses.
+type fhirCoding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type fhirCodeableConcept struct {
+	Coding []fhirCoding `json:"coding,omitempty"`
+	Text   string       `json:"text,omitempty"`
+}
+
+type fhirIdentifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type fhirReference struct {
+	Reference  string          `json:"reference,omitempty"`
+	Identifier *fhirIdentifier `json:"identifier,omitempty"`
+}
+
+type fhirQuantity struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+}
+
+type fhirBatch struct {
+	LotNumber      string `json:"lotNumber,omitempty"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
+}
+
+type fhirMedication struct {
+	ResourceType string               `json:"resourceType"`
+	Identifier   []fhirIdentifier     `json:"identifier,omitempty"`
+	Code         *fhirCodeableConcept `json:"code,omitempty"`
+	Status       string               `json:"status,omitempty"`
+	Batch        *fhirBatch           `json:"batch,omitempty"`
+}
+
+type fhirMedicationDispense struct {
+	ResourceType            string           `json:"resourceType"`
+	Identifier              []fhirIdentifier `json:"identifier,omitempty"`
+	Status                  string           `json:"status"`
+	MedicationReference     *fhirReference   `json:"medicationReference"`
+	Subject                 *fhirReference   `json:"subject,omitempty"`
+	AuthorizingPrescription []fhirReference  `json:"authorizingPrescription,omitempty"`
+	Quantity                *fhirQuantity    `json:"quantity,omitempty"`
+}
+
+type fhirBundleEntry struct {
+	FullURL  string      `json:"fullUrl"`
+	Resource interface{} `json:"resource"`
+}
+
+type fhirBundle struct {
+	ResourceType string            `json:"resourceType"`
+	Type         string            `json:"type"`
+	Timestamp    string            `json:"timestamp"`
+	Entry        []fhirBundleEntry `json:"entry,omitempty"`
+}
+
+// Namespace of URL names (RFC 4122), used to derive stable UUIDs for the medicine on the ledger.
+var urlNamespace = []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// Returns a name-based (version 5) UUID URN, so exports of the same ledger use the same full URLs.
+func uuidURN(name string) string {
+	hash := sha1.Sum(append(append([]byte{}, urlNamespace...), name...))
+	uuid := hash[:16]
+	uuid[6] = (uuid[6] & 0x0f) | 0x50
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// Replaces a customer identifier by a keyed hash, which is stable for the key but can't be linked back to the ledger without it.
+func pseudonym(key []byte, customer string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(customer))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Reads the pseudonymisation key from FHIR_PSEUDONYM_KEY, without it a random key is used and pseudonyms differ per run.
+func pseudonymKey() []byte {
+	if key := os.Getenv("FHIR_PSEUDONYM_KEY"); key != "" {
+		return []byte(key)
+	}
+	log.Println("FHIR_PSEUDONYM_KEY not set, pseudonyms can't be linked between exports.")
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		log.Fatalf("\nFailed to generate pseudonymisation key: %v", err)
+	}
+	return key
+}
+
+// Converts medicine into FHIR Medication resources and approved (send) medicine into MedicationDispense resources.
+func fhirBundleOf(medicines []*client.MedicalSupply, key []byte, timestamp time.Time) fhirBundle {
+	bundle := fhirBundle{ResourceType: "Bundle", Type: "collection", Timestamp: timestamp.UTC().Format(time.RFC3339)}
+
+	for _, med := range medicines {
+		medKey := med.MedName + ":" + med.MedNumber
+		medicationURL := uuidURN("urn:medstore:medicine:" + medKey)
+
+		medication := fhirMedication{
+			ResourceType: "Medication",
+			Identifier:   []fhirIdentifier{{System: "urn:medstore:medicine", Value: medKey}},
+			Code:         &fhirCodeableConcept{Text: med.MedName},
+			Status:       "active",
+		}
+		if med.GTIN != "" {
+			medication.Code.Coding = []fhirCoding{{System: "https://www.gs1.org/gtin", Code: med.GTIN, Display: med.MedName}}
+		}
+		// Quarantined and destroyed medicine may no longer be used.
+		if med.State == client.Quarantined || med.State == client.Destroyed {
+			medication.Status = "inactive"
+		}
+		if med.Expiration != "" || med.LotNumber != "" {
+			medication.Batch = &fhirBatch{LotNumber: med.LotNumber, ExpirationDate: strings.ReplaceAll(med.Expiration, ".", "-")}
+		}
+		bundle.Entry = append(bundle.Entry, fhirBundleEntry{FullURL: medicationURL, Resource: medication})
+
+		if med.State != client.Send {
+			continue
+		}
+		dispense := fhirMedicationDispense{
+			ResourceType:        "MedicationDispense",
+			Identifier:          []fhirIdentifier{{System: "urn:medstore:dispense", Value: medKey}},
+			Status:              "completed",
+			MedicationReference: &fhirReference{Reference: medicationURL},
+			Subject:             &fhirReference{Identifier: &fhirIdentifier{System: "urn:medstore:pseudonym", Value: pseudonym(key, med.Holder)}},
+			Quantity:            &fhirQuantity{Value: 1, Unit: "pack"},
+		}
+		if med.PrescriptionID != "" {
+			dispense.AuthorizingPrescription = []fhirReference{{Identifier: &fhirIdentifier{System: "urn:medstore:prescription", Value: med.PrescriptionID}}}
+		}
+		bundle.Entry = append(bundle.Entry, fhirBundleEntry{FullURL: uuidURN("urn:medstore:dispense:" + medKey), Resource: dispense})
+	}
+	return bundle
+}
+
+// HTTP handler serving the FHIR bundle of all medicine on GET.
+func fhirHandler(fetch func() ([]*client.MedicalSupply, error), key []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		medicines, err := fetch()
+		if err != nil {
+			log.Printf("Failed to retrieve medicine from ledger: %v", err)
+			http.Error(w, "could not retrieve medicine from ledger", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/fhir+json")
+		err = json.NewEncoder(w).Encode(fhirBundleOf(medicines, key, time.Now()))
+		if err != nil {
+			log.Printf("Failed to write FHIR bundle: %v", err)
+		}
+	})
+}
+
+// Handling regulators exporting medicine and dispenses as a FHIR bundle for hospitals.
+func exportFHIR(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("File to write the FHIR bundle to (e.g. bundle.json):")
+	scanner.Scan()
+	filename := scanner.Text()
+
+	log.Println("--> Evaluate Transaction: CheckHistory, function retrieves all medicine for the FHIR export.")
+	medicines, err := medstore.CheckHistory(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+
+	bundle := fhirBundleOf(medicines, pseudonymKey(), time.Now())
+	if filename == "" {
+		prettyPrint(bundle)
+		return
+	}
+	result, err := json.Marshal(bundle)
+	if err != nil {
+		log.Fatalf("\nFailed to create FHIR bundle: %v", err)
+	}
+	err = ioutil.WriteFile(filename, result, 0644)
+	if err != nil {
+		log.Fatalf("\nFailed to write FHIR bundle: %v", err)
+	}
+	log.Printf("FHIR bundle written to %s", filename)
+}
+
+// Handling regulators serving the FHIR bundle over HTTP, every GET retrieves the current medicine from the ledger.
+func serveFHIR(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Address to listen on (e.g. :8080):")
+	scanner.Scan()
+	address := scanner.Text()
+
+	key := pseudonymKey()
+	fetch := func() ([]*client.MedicalSupply, error) {
+		log.Println("--> Evaluate Transaction: CheckHistory, function retrieves all medicine for the FHIR export.")
+		return medstore.CheckHistory(context.Background())
+	}
+
+	http.Handle("/fhir/Bundle", fhirHandler(fetch, key))
+	log.Printf("Serving FHIR bundle on http://%s/fhir/Bundle", address)
+	log.Fatal(http.ListenAndServe(address, nil))
+}
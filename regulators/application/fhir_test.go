@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+// testdata/fhir.schema.json holds the definitions of the official FHIR R4 JSON schema
+// (http://hl7.org/fhir/R4/fhir.schema.json.zip) used by the export, the full schema can replace it as is.
+func validateFHIR(t *testing.T, data []byte) error {
+	schema, err := jsonschema.Compile("testdata/fhir.schema.json")
+	assert.Nil(t, err, "should compile the FHIR schema")
+
+	var document interface{}
+	err = json.Unmarshal(data, &document)
+	assert.Nil(t, err, "should be valid JSON")
+	return schema.Validate(document)
+}
+
+var testMedicine = []*client.MedicalSupply{
+	{MedName: "aspirin", MedNumber: "00001", Expiration: "2022.02.22", Holder: "MedStore", State: client.Available},
+	{MedName: "vicodin", MedNumber: "00002", Expiration: "2024.05.31", Holder: "alicehash", PrescriptionID: "RX0001",
+		GTIN: "09506000134352", LotNumber: "ABC123", State: client.Send},
+	{MedName: "ibuprofen", MedNumber: "00003", Expiration: "2022.01.01", Holder: "MedStore", State: client.Destroyed},
+}
+
+func TestFHIRBundle(t *testing.T) {
+	bundle := fhirBundleOf(testMedicine, []byte("secret"), time.Date(2022, 2, 22, 10, 0, 0, 0, time.UTC))
+	data, err := json.Marshal(bundle)
+	assert.Nil(t, err, "should not error on marshal")
+	assert.Nil(t, validateFHIR(t, data), "should match the FHIR schema")
+
+	assert.Len(t, bundle.Entry, 4, "should add a Medication per medicine and a MedicationDispense for send medicine")
+	dispense, ok := bundle.Entry[2].Resource.(fhirMedicationDispense)
+	assert.True(t, ok, "should add the MedicationDispense after the send medicine")
+	assert.Equal(t, bundle.Entry[1].FullURL, dispense.MedicationReference.Reference, "should refer to the Medication in the bundle")
+	assert.Equal(t, "RX0001", dispense.AuthorizingPrescription[0].Identifier.Value, "should refer to the prescription")
+	assert.Equal(t, "2024-05-31", bundle.Entry[1].Resource.(fhirMedication).Batch.ExpirationDate, "should convert the expiration date")
+	assert.Equal(t, "inactive", bundle.Entry[3].Resource.(fhirMedication).Status, "should mark destroyed medicine inactive")
+}
+
+func TestFHIRPseudonymisation(t *testing.T) {
+	data, _ := json.Marshal(fhirBundleOf(testMedicine, []byte("secret"), time.Now()))
+
+	assert.NotContains(t, string(data), "alicehash", "should not contain customer identifiers")
+	assert.Contains(t, string(data), pseudonym([]byte("secret"), "alicehash"), "should contain the pseudonym of the customer")
+	assert.NotEqual(t, pseudonym([]byte("secret"), "alicehash"), pseudonym([]byte("other"), "alicehash"), "should depend on the key")
+	assert.Equal(t, uuidURN("urn:medstore:medicine:aspirin:00001"), uuidURN("urn:medstore:medicine:aspirin:00001"), "should use stable full URLs")
+}
+
+func TestFHIRHandler(t *testing.T) {
+	handler := fhirHandler(func() ([]*client.MedicalSupply, error) { return testMedicine, nil }, []byte("secret"))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fhir/Bundle", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code, "should serve the bundle on GET")
+	assert.Equal(t, "application/fhir+json", recorder.Header().Get("Content-Type"), "should use the FHIR content type")
+	assert.Nil(t, validateFHIR(t, recorder.Body.Bytes()), "should match the FHIR schema")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/fhir/Bundle", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code, "should only allow GET")
+
+	failing := fhirHandler(func() ([]*client.MedicalSupply, error) { return nil, errors.New("peer unavailable") }, []byte("secret"))
+	recorder = httptest.NewRecorder()
+	failing.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fhir/Bundle", nil))
+	assert.Equal(t, http.StatusBadGateway, recorder.Code, "should fail when the ledger can't be reached")
+}
+
+func TestFHIRSchemaRejectsInvalid(t *testing.T) {
+	invalid := `{"resourceType":"Bundle","type":"collection","entry":[{"fullUrl":"urn:uuid:1","resource":{"resourceType":"Medication","batch":{"expirationDate":"2022.02.22"}}}]}`
+	assert.NotNil(t, validateFHIR(t, []byte(invalid)), "should reject dates in the ledger layout")
+}
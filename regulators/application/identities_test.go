@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+	"medical-supply/profile"
+)
+
+// Creates a self-signed identity, as the wallet commands don't depend on who issued the certificate.
+func testIdentity(t *testing.T, name string) *gateway.X509Identity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "should generate a key")
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err, "should create a certificate")
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err, "should marshal the key")
+	return gateway.NewX509Identity(defaultProfile.MSPID, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+}
+
+func TestWalletCommands(t *testing.T) {
+	wallet := gateway.NewInMemoryWallet()
+	wallet.Put("bob", testIdentity(t, "bob"))
+	mspDir := filepath.Join(t.TempDir(), "msp")
+
+	assert.Nil(t, runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "export", "bob", mspDir}), "should export an identity")
+	assert.Nil(t, runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "import", "carol", mspDir}), "should import an identity")
+	assert.True(t, wallet.Exists("carol"), "should store the imported identity under its label")
+	assert.Nil(t, runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "list"}), "should list the identities")
+
+	assert.Nil(t, runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "remove", "carol"}), "should remove an identity")
+	assert.False(t, wallet.Exists("carol"), "should no longer hold the removed identity")
+	assert.EqualError(t, runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "remove", "carol"}),
+		"identity carol is not in the wallet, enroll or import it first", "should report missing identities")
+}
+
+func TestIdentityCommandErrors(t *testing.T) {
+	wallet := gateway.NewInMemoryWallet()
+
+	err := runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"wallet", "import", "carol"})
+	assert.Contains(t, err.Error(), "unknown command wallet import or wrong number of arguments", "should check the arguments")
+	err = runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"issue"})
+	assert.Contains(t, err.Error(), identityUsage, "should show the usage of unknown commands")
+
+	err = runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"ca", "register", "carol", "prescriber"})
+	assert.EqualError(t, err, "invalid attribute prescriber, expected name=value (e.g. role=prescriber)", "should check the attributes")
+	err = runIdentityCommand(wallet, &defaultProfile, nil, "bob", []string{"ca", "register", "carol", "role=prescriber"})
+	assert.EqualError(t, err, "identity bob is not in the wallet, enroll or import it first", "should register on behalf of an enrolled identity")
+
+	wallet.Put("bob", testIdentity(t, "bob"))
+	settings := defaultProfile
+	settings.Name, settings.CA = "local", profile.CA{}
+	err = runIdentityCommand(wallet, &settings, nil, "bob", []string{"ca", "register", "carol", "role=prescriber"})
+	assert.EqualError(t, err, "profile local has no CA, set ca.url or MEDSTORE_CA_URL", "should require the CA of the profile")
+}
@@ -0,0 +1,204 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// standInCA - Local stand-in of a Fabric CA server, implementing the enroll and register endpoints.
+type standInCA struct {
+	key        *ecdsa.PrivateKey
+	cert       *x509.Certificate
+	identities map[string]*Registration
+	serial     int64
+}
+
+func newStandInCA(t *testing.T) (*standInCA, *CA) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "should generate the CA key")
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ca-org1"}, IsCA: true,
+		BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign, NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err, "should create the CA certificate")
+	cert, _ := x509.ParseCertificate(der)
+
+	standIn := &standInCA{key: key, cert: cert, serial: 1, identities: map[string]*Registration{
+		"admin": {Name: "admin", Secret: "adminpw", Attributes: []Attribute{{Name: "hf.Registrar.Roles", Value: "client"}}},
+	}}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+	return standIn, &CA{URL: server.URL, Name: "ca-org1", HTTPClient: server.Client()}
+}
+
+func (standIn *standInCA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	var result interface{}
+	var code int
+	var err error
+	switch r.URL.Path {
+	case "/api/v1/enroll":
+		result, code, err = standIn.enroll(r, body)
+	case "/api/v1/register":
+		result, code, err = standIn.register(r, body)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	response := map[string]interface{}{"success": err == nil, "result": result, "errors": []caMessage{}}
+	if err != nil {
+		response["errors"] = []caMessage{{Code: code, Message: err.Error()}}
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (standIn *standInCA) enroll(r *http.Request, body []byte) (interface{}, int, error) {
+	name, secret, ok := r.BasicAuth()
+	registration, registered := standIn.identities[name]
+	if !ok || !registered || registration.Secret != secret {
+		return nil, 20, fmt.Errorf("Authentication failure")
+	}
+
+	var request enrollmentRequest
+	json.Unmarshal(body, &request)
+	block, _ := pem.Decode([]byte(request.CertificateRequest))
+	if block == nil {
+		return nil, 0, fmt.Errorf("Invalid certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil || csr.CheckSignature() != nil {
+		return nil, 0, fmt.Errorf("Invalid certificate request")
+	}
+
+	attributes := make(map[string]string)
+	for _, attribute := range registration.Attributes {
+		if attribute.ECert {
+			attributes[attribute.Name] = attribute.Value
+		}
+	}
+	for _, requested := range request.AttrReqs {
+		found := false
+		for _, attribute := range registration.Attributes {
+			if attribute.Name == requested.Name {
+				attributes[attribute.Name] = attribute.Value
+				found = true
+			}
+		}
+		if !found {
+			return nil, 0, fmt.Errorf("Attribute '%s' was requested but the identity has no such attribute", requested.Name)
+		}
+	}
+	extension, _ := json.Marshal(map[string]interface{}{"attrs": attributes})
+
+	standIn.serial++
+	template := &x509.Certificate{SerialNumber: big.NewInt(standIn.serial), Subject: pkix.Name{CommonName: name},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: attributesOID, Value: extension}}}
+	der, err := x509.CreateCertificate(rand.Reader, template, standIn.cert, csr.PublicKey, standIn.key)
+	if err != nil {
+		return nil, 0, err
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return enrollmentResult{Cert: base64.StdEncoding.EncodeToString(cert)}, 0, nil
+}
+
+func (standIn *standInCA) register(r *http.Request, body []byte) (interface{}, int, error) {
+	parts := strings.Split(r.Header.Get("Authorization"), ".")
+	if len(parts) != 2 {
+		return nil, 0, fmt.Errorf("Invalid authorization header")
+	}
+	certPEM, _ := base64.StdEncoding.DecodeString(parts[0])
+	signature, _ := base64.StdEncoding.DecodeString(parts[1])
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, 0, fmt.Errorf("Invalid authorization header")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || cert.CheckSignatureFrom(standIn.cert) != nil {
+		return nil, 0, fmt.Errorf("Certificate not issued by this CA")
+	}
+	payload := r.Method + "." + base64.StdEncoding.EncodeToString([]byte(r.URL.RequestURI())) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." + parts[0]
+	digest := sha256.Sum256([]byte(payload))
+	if !ecdsa.VerifyASN1(cert.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		return nil, 0, fmt.Errorf("Invalid token in authorization header")
+	}
+
+	registrar := standIn.identities[cert.Subject.CommonName]
+	isRegistrar := false
+	for _, attribute := range registrar.Attributes {
+		isRegistrar = isRegistrar || attribute.Name == "hf.Registrar.Roles"
+	}
+	if !isRegistrar {
+		return nil, 71, fmt.Errorf("Authorization failure")
+	}
+
+	var request registrationRequest
+	json.Unmarshal(body, &request)
+	if _, ok := standIn.identities[request.Name]; ok {
+		return nil, 74, fmt.Errorf("Identity '%s' is already registered", request.Name)
+	}
+	if request.Secret == "" {
+		request.Secret = "generated-" + request.Name
+	}
+	standIn.identities[request.Name] = &Registration{Name: request.Name, Secret: request.Secret, Type: request.Type,
+		Affiliation: request.Affiliation, Attributes: request.Attributes}
+	return registrationResult{Secret: request.Secret}, 0, nil
+}
+
+func TestEnrollAndRegister(t *testing.T) {
+	standIn, ca := newStandInCA(t)
+
+	admin, err := ca.Enroll("Org1MSP", "admin", "adminpw")
+	assert.Nil(t, err, "should enroll the bootstrap admin")
+	assert.Equal(t, "Org1MSP", admin.MspID, "should create an identity of the MSP")
+
+	secret, err := ca.Register(admin, Registration{Name: "carol", Affiliation: "org1.department1",
+		Attributes: []Attribute{{Name: "role", Value: "prescriber", ECert: true}}})
+	assert.Nil(t, err, "should register staff on behalf of the admin")
+	assert.Equal(t, "generated-carol", secret, "should return the secret generated by the CA")
+	assert.Equal(t, "client", standIn.identities["carol"].Type, "should register clients by default")
+
+	carol, err := ca.Enroll("Org1MSP", "carol", secret, "role")
+	assert.Nil(t, err, "should enroll the registered staff")
+	attributes, err := Attributes(carol)
+	assert.Nil(t, err, "should read the attributes of the certificate")
+	assert.Equal(t, "prescriber", attributes["role"], "should add the role to the certificate")
+
+	_, err = ca.Register(admin, Registration{Name: "carol"})
+	assert.EqualError(t, err, "could not register carol: Identity 'carol' is already registered (code 74)", "should return the error of the CA")
+	_, err = ca.Register(carol, Registration{Name: "dave"})
+	assert.EqualError(t, err, "could not register dave: Authorization failure (code 71)", "should only register on behalf of registrars")
+}
+
+func TestEnrollFailures(t *testing.T) {
+	_, ca := newStandInCA(t)
+
+	_, err := ca.Enroll("Org1MSP", "admin", "wrong")
+	assert.EqualError(t, err, "could not enroll admin: Authentication failure (code 20)", "should reject a wrong secret")
+	_, err = ca.Enroll("Org1MSP", "admin", "adminpw", "role")
+	assert.EqualError(t, err, "could not enroll admin: Attribute 'role' was requested but the identity has no such attribute (code 0)",
+		"should fail when a requested attribute is missing")
+
+	ca.URL = "http://127.0.0.1:1"
+	_, err = ca.Enroll("Org1MSP", "admin", "adminpw")
+	assert.NotNil(t, err, "should fail when the CA can't be reached")
+}
@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// attributesOID - Extension in which the Fabric CA adds the attributes to the enrollment certificate.
+var attributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// Get - Returns the X.509 identity stored in the wallet under the label.
+func Get(wallet *gateway.Wallet, label string) (*gateway.X509Identity, error) {
+	if !wallet.Exists(label) {
+		return nil, fmt.Errorf("identity %s is not in the wallet, enroll or import it first", label)
+	}
+	id, err := wallet.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	x509ID, ok := id.(*gateway.X509Identity)
+	if !ok {
+		return nil, fmt.Errorf("identity %s is not an X.509 identity", label)
+	}
+	return x509ID, nil
+}
+
+// Import - Stores the identity of an MSP folder in the wallet, the folder holds the certificate in signcerts
+// and a single private key in keystore (e.g. organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp).
+func Import(wallet *gateway.Wallet, label string, mspID string, mspDir string) error {
+	certPath := filepath.Join(mspDir, "signcerts", "cert.pem")
+	// read the certificate pem
+	cert, err := ioutil.ReadFile(filepath.Clean(certPath))
+	if err != nil {
+		return err
+	}
+
+	keyDir := filepath.Join(mspDir, "keystore")
+	// there's a single file in this dir containing the private key
+	files, err := ioutil.ReadDir(keyDir)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("keystore folder should contain one file")
+	}
+	key, err := ioutil.ReadFile(filepath.Clean(filepath.Join(keyDir, files[0].Name())))
+	if err != nil {
+		return err
+	}
+
+	return wallet.Put(label, gateway.NewX509Identity(mspID, string(cert), string(key)))
+}
+
+// Export - Writes the identity of the wallet to an MSP folder, in the layout Import reads.
+func Export(wallet *gateway.Wallet, label string, mspDir string) error {
+	id, err := Get(wallet, label)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{"signcerts", "keystore"} {
+		err = os.MkdirAll(filepath.Join(mspDir, dir), 0700)
+		if err != nil {
+			return err
+		}
+	}
+	err = ioutil.WriteFile(filepath.Join(mspDir, "signcerts", "cert.pem"), []byte(id.Certificate()), 0644)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(mspDir, "keystore", "key.pem"), []byte(id.Key()), 0600)
+}
+
+// Attributes - Returns the attributes the CA added to the certificate of the identity, e.g. the role.
+func Attributes(id *gateway.X509Identity) (map[string]string, error) {
+	block, _ := pem.Decode([]byte(id.Certificate()))
+	if block == nil {
+		return nil, errors.New("no certificate found in identity")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, extension := range cert.Extensions {
+		if !extension.Id.Equal(attributesOID) {
+			continue
+		}
+		var attributes struct {
+			Attrs map[string]string `json:"attrs"`
+		}
+		err = json.Unmarshal(extension.Value, &attributes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attributes in certificate: %w", err)
+		}
+		return attributes.Attrs, nil
+	}
+	return map[string]string{}, nil
+}
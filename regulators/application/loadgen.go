@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"medical-supply/client"
+	"medical-supply/loadgen"
+)
+
+// Usage of the loadgen command, which runs instead of the menu when passed as argument.
+const loadgenUsage = `Load generator:
+  loadgen [-workload file] [-json file] [-html file]
+                                            Run the rounds of the workload (default loadgen.yaml) against the
+                                            network, or the simulator with -offline, and report the throughput
+                                            and latency of every transaction, optionally as JSON and HTML`
+
+// Runs the load generator with the arguments of the loadgen command and writes its report.
+func runLoadgen(medstore *client.Client, args []string) error {
+	flags := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	workloadFile := flags.String("workload", "loadgen.yaml", "workload with the rounds to run")
+	jsonFile := flags.String("json", "", "write the report as JSON to this file")
+	htmlFile := flags.String("html", "", "write the report as HTML to this file")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v\n%s", flags.Args(), loadgenUsage)
+	}
+
+	workload, err := loadgen.Load(*workloadFile)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C stops the run, the rounds so far are still reported.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			log.Println("Interrupted, stopping after the transactions in flight")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	report, runErr := loadgen.Run(ctx, medstore, workload)
+	if report == nil {
+		return runErr
+	}
+	err = report.WriteText(os.Stdout)
+	if err != nil {
+		return err
+	}
+	if *jsonFile != "" {
+		err = writeReport(*jsonFile, report.WriteJSON)
+		if err != nil {
+			return err
+		}
+	}
+	if *htmlFile != "" {
+		err = writeReport(*htmlFile, report.WriteHTML)
+		if err != nil {
+			return err
+		}
+	}
+	return runErr
+}
+
+// Writes a report to the file.
+func writeReport(filename string, write func(w io.Writer) error) error {
+	f, err := os.Create(filepath.Clean(filename))
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %s: %w", filename, err)
+	}
+	err = f.Close()
+	if err == nil {
+		log.Printf("Wrote the report to %s", filename)
+	}
+	return err
+}
@@ -0,0 +1,300 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"medical-supply/client"
+)
+
+// Kinds of errors which are not returned by the contract.
+const (
+	ErrorMVCCConflict = client.ValidationMVCCConflict
+	ErrorTimeout      = "TIMEOUT"
+	ErrorOther        = "OTHER"
+)
+
+// Run - Runs the rounds of the workload with the client, after every worker issued its medicine. Cancelling the context
+// stops the run after the transactions in flight, the report then holds the rounds run so far.
+func Run(ctx context.Context, medstore *client.Client, workload *Workload) (*Report, error) {
+	err := workload.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// The run is part of the medicine numbers, so runs on the same ledger never reuse keys.
+	prefix := fmt.Sprintf("lg%x", time.Now().Unix())
+	workers := make([]*worker, workload.Workers)
+	for i := range workers {
+		workers[i] = newWorker(i+1, medstore, prefix)
+	}
+
+	log.Printf("Issuing %d medicine for each of %d workers", workload.Assets, workload.Workers)
+	err = parallel(workers, func(w *worker) error {
+		for i := 0; i < workload.Assets; i++ {
+			err := w.issue(ctx)
+			if err != nil {
+				return fmt.Errorf("worker %d could not issue medicine: %w", w.index, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Started: time.Now().UTC(), Workload: *workload}
+	for i, round := range workload.Rounds {
+		if ctx.Err() != nil {
+			break
+		}
+		if round.Label == "" {
+			round.Label = fmt.Sprintf("round %d", i+1)
+		}
+		log.Printf("Running %s: %s warm-up, %s %s", round.Label, round.Warmup, round.Duration, describeRate(round.Rate))
+		result := runRound(ctx, workers, round)
+		report.Rounds = append(report.Rounds, result)
+		log.Printf("Finished %s: %d succeeded, %d failed, %d missed, %.1f TPS", round.Label, result.Succeeded,
+			result.Failed, result.Missed, result.Throughput)
+	}
+
+	if workload.Cleanup {
+		log.Println("Deleting the medicine of the workers")
+		err = parallel(workers, func(w *worker) error {
+			for len(w.medicines) > 0 {
+				// Cleanup also runs after an interrupt.
+				m := w.medicines[0]
+				if m.state == requested {
+					// Requested medicine can't be deleted until its request is resolved.
+					_, err := w.medstore.RejectRequest(context.Background(), m.name, m.number)
+					if err != nil {
+						return fmt.Errorf("worker %d could not reject %s %s: %w", w.index, m.name, m.number, err)
+					}
+				}
+				err := w.medstore.Delete(context.Background(), m.name, m.number)
+				if err != nil {
+					return fmt.Errorf("worker %d could not delete %s %s: %w", w.index, m.name, m.number, err)
+				}
+				w.remove(m)
+			}
+			return nil
+		})
+	}
+	return report, err
+}
+
+// parallel - Runs the function for every worker at the same time and returns the first error.
+func parallel(workers []*worker, run func(w *worker) error) error {
+	errs := make([]error, len(workers))
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func(i int, w *worker) {
+			defer wg.Done()
+			errs[i] = run(w)
+		}(i, w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRound - Runs the round with all workers. Only transactions sent after the warm-up are measured.
+func runRound(ctx context.Context, workers []*worker, round Round) *RoundResult {
+	start := time.Now()
+	rec := newRecorder(start.Add(round.Warmup))
+	end := start.Add(round.Warmup + round.Duration)
+	names := functions(round.Mix)
+
+	var slots *schedule
+	if round.Rate.Type == FixedRate {
+		slots = &schedule{start: start, end: end, interval: time.Duration(float64(time.Second) / round.Rate.TPS)}
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			w.run(ctx, names, round.Mix, rec, end, slots)
+		}(w)
+	}
+	wg.Wait()
+	if slots != nil && ctx.Err() == nil {
+		rec.missed += slots.untaken(rec.from)
+	}
+
+	stopped := time.Now()
+	if stopped.After(end) {
+		// Transactions sent before the end are measured, not those completing after it.
+		stopped = end
+	}
+	return rec.result(round, stopped)
+}
+
+// run - Sends transactions until the end of the round, at the times of the schedule or, without one, as soon as the
+// previous one completed. The latency of a scheduled transaction counts from the time it was due, so the time it waited
+// for the worker to complete the previous one is included.
+func (w *worker) run(ctx context.Context, names []string, mix map[string]int, rec *recorder, end time.Time,
+	slots *schedule) {
+	for {
+		var due time.Time
+		if slots != nil {
+			var ok bool
+			due, ok = slots.next()
+			if !ok {
+				return
+			}
+			if wait := time.Until(due); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			if ctx.Err() == nil && !time.Now().Before(end) {
+				// The workers fell too far behind to send it within the round.
+				rec.miss(due)
+				return
+			}
+		}
+		if ctx.Err() != nil || !time.Now().Before(end) {
+			return
+		}
+
+		function, m := w.choose(names, mix)
+		if function == "" {
+			log.Printf("Worker %d has no medicine for any transaction of the mix, add Issue or ChangeStatus", w.index)
+			rec.idle()
+			return
+		}
+		sent := time.Now()
+		if slots != nil {
+			sent = due
+		}
+		err := transactions[function].invoke(ctx, w, m)
+		if err != nil && ctx.Err() != nil {
+			// Interrupted, not failed.
+			return
+		}
+		rec.record(function, sent, time.Since(sent), err)
+	}
+}
+
+// schedule - Times at which the transactions of a round at a fixed rate are due, taken in turn by the workers. The
+// times don't depend on when earlier transactions completed, so transactions wait for a free worker when the workers
+// don't keep up.
+type schedule struct {
+	taken    int64 // First for its 64-bit alignment on 32-bit platforms, which atomic requires.
+	start    time.Time
+	end      time.Time
+	interval time.Duration
+}
+
+// next - Takes the next time a transaction is due, false once the round ended.
+func (s *schedule) next() (time.Time, bool) {
+	due := s.start.Add(s.interval * time.Duration(atomic.AddInt64(&s.taken, 1)-1))
+	return due, due.Before(s.end)
+}
+
+// untaken - Returns the number of times due from the given time on which no worker took before the round ended.
+func (s *schedule) untaken(from time.Time) int {
+	count := 0
+	due := s.start.Add(s.interval * time.Duration(atomic.LoadInt64(&s.taken)))
+	for ; due.Before(s.end); due = due.Add(s.interval) {
+		if !due.Before(from) {
+			count++
+		}
+	}
+	return count
+}
+
+// describeRate - Returns the rate control for messages.
+func describeRate(rate Rate) string {
+	if rate.Type == FixedRate {
+		return fmt.Sprintf("at %g TPS", rate.TPS)
+	}
+	return "at a fixed load"
+}
+
+// errorKind - Returns the code of a contract error, or the kind of another error to count failures by.
+func errorKind(err error) string {
+	if code := client.ErrorCode(err); code != "" {
+		return code
+	}
+	if strings.Contains(err.Error(), ErrorMVCCConflict) {
+		return ErrorMVCCConflict
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	return ErrorOther
+}
+
+// recorder - Collects the latencies and errors of the transactions of a round, shared by its workers.
+type recorder struct {
+	mu      sync.Mutex
+	from    time.Time
+	samples map[string]*samples
+	idlers  int
+	missed  int
+}
+
+// samples - Latencies of the successful transactions of a function and the errors of the others.
+type samples struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+func newRecorder(from time.Time) *recorder {
+	return &recorder{from: from, samples: make(map[string]*samples)}
+}
+
+// record - Records a transaction sent after the warm-up.
+func (r *recorder) record(function string, sent time.Time, latency time.Duration, err error) {
+	if sent.Before(r.from) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.samples[function]
+	if !ok {
+		s = &samples{errors: make(map[string]int)}
+		r.samples[function] = s
+	}
+	if err != nil {
+		s.errors[errorKind(err)]++
+	} else {
+		s.latencies = append(s.latencies, latency)
+	}
+}
+
+// miss - Records a transaction due after the warm-up which wasn't sent before the round ended.
+func (r *recorder) miss(due time.Time) {
+	if due.Before(r.from) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.missed++
+}
+
+// idle - Records a worker which stopped as it had no medicine for the mix.
+func (r *recorder) idle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idlers++
+}
@@ -0,0 +1,152 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "loadgen.yaml")
+	ioutil.WriteFile(filename, []byte(`
+workers: 2
+assets: 5
+rounds:
+  - label: request
+    warmup: 1s
+    duration: 10s
+    rate: {type: fixed-rate, tps: 20}
+    mix: {Request: 3, ApproveRequest: 1}
+`), 0600)
+	workload, err := Load(filename)
+	assert.Nil(t, err, "should load the workload")
+	assert.Equal(t, Round{Label: "request", Warmup: time.Second, Duration: 10 * time.Second,
+		Rate: Rate{Type: FixedRate, TPS: 20}, Mix: map[string]int{"Request": 3, "ApproveRequest": 1}}, workload.Rounds[0],
+		"should read the durations, rate and mix")
+
+	invalid := Workload{Rounds: []Round{{Rate: Rate{Type: FixedRate}, Mix: map[string]int{"Approve": 1, "Issue": 0}}}}
+	assert.EqualError(t, invalid.Validate(), "workers should be at least 1, "+
+		"round 1: duration should be positive (e.g. 60s), round 1: tps of fixed-rate should be positive, "+
+		"round 1: unknown transaction Approve, choose one of ApproveRequest, CancelRequest, ChangeHolder, ChangeStatus, "+
+		"CheckAvailableMedicine, CheckHistory, CheckRequestedMedicine, CheckUserHistory, Delete, InventoryReport, Issue, "+
+		"RejectRequest, Request, SearchMedicineByName, round 1: weight of Issue should be at least 1",
+		"should report every problem of the workload")
+}
+
+func newFakeClient(t *testing.T) (*client.Fake, *client.Client) {
+	fake := client.NewFake()
+	medstore := client.New(fake, fake, fake, "bob")
+	tpmkey, err := medstore.TPMKeyGen(context.Background())
+	assert.Nil(t, err, "should generate a TPM key")
+	medstore.SetTPMKey(tpmkey)
+	return fake, medstore
+}
+
+func TestRun(t *testing.T) {
+	fake, medstore := newFakeClient(t)
+	workload := &Workload{Workers: 3, Assets: 4, Cleanup: true, Rounds: []Round{
+		{Label: "lifecycle", Warmup: 20 * time.Millisecond, Duration: 200 * time.Millisecond, Rate: Rate{Type: FixedLoad},
+			Mix: map[string]int{"Request": 4, "ApproveRequest": 1, "RejectRequest": 1, "CancelRequest": 1, "Issue": 1}},
+		{Label: "queries", Duration: 200 * time.Millisecond, Rate: Rate{Type: FixedRate, TPS: 50},
+			Mix: map[string]int{"CheckAvailableMedicine": 1, "CheckRequestedMedicine": 1}},
+	}}
+
+	report, err := Run(context.Background(), medstore, workload)
+	assert.Nil(t, err, "should run the workload")
+	assert.Len(t, report.Rounds, 2, "should report every round")
+
+	lifecycle := report.Rounds[0]
+	assert.Greater(t, lifecycle.Succeeded, 0, "should run the transactions of the mix")
+	assert.Equal(t, 0, lifecycle.Failed, "should only invoke transactions on medicine in the right state")
+	for _, f := range lifecycle.Functions {
+		assert.Contains(t, workload.Rounds[0].Mix, f.Function, "should only run the transactions of the mix")
+		assert.LessOrEqual(t, f.Latency.P50, f.Latency.P99, "should order the percentiles")
+	}
+
+	queries := report.Rounds[1]
+	assert.InDelta(t, 50, queries.Throughput, 15, "should send transactions at the fixed rate")
+
+	available, err := medstore.CheckAvailableMedicine(context.Background())
+	assert.Nil(t, err, "should list the medicine")
+	assert.Empty(t, available, "should delete the medicine of the workers")
+	for _, call := range fake.Calls() {
+		if call.Name == "Request" || call.Name == "ApproveRequest" {
+			assert.Regexp(t, `^lg[0-9a-f]+-[1-3]-[0-9]+$`, call.Args[1], "should only use the medicine of the workers")
+		}
+	}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, report.WriteJSON(&buffer), "should write the report as JSON")
+	var decoded Report
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &decoded), "should write valid JSON")
+	assert.Equal(t, lifecycle.Succeeded, decoded.Rounds[0].Succeeded, "should write the results")
+	buffer.Reset()
+	assert.Nil(t, report.WriteHTML(&buffer), "should write the report as HTML")
+	assert.Contains(t, buffer.String(), "<h2>lifecycle</h2>", "should write a section per round")
+}
+
+func TestRunIdle(t *testing.T) {
+	_, medstore := newFakeClient(t)
+	workload := &Workload{Workers: 2, Assets: 0, Rounds: []Round{
+		{Duration: 50 * time.Millisecond, Rate: Rate{Type: FixedLoad}, Mix: map[string]int{"ApproveRequest": 1}},
+	}}
+
+	report, err := Run(context.Background(), medstore, workload)
+	assert.Nil(t, err, "should run the workload")
+	assert.Equal(t, "round 1", report.Rounds[0].Label, "should name unlabelled rounds")
+	assert.Equal(t, 2, report.Rounds[0].IdleWorkers, "should stop workers without medicine for the mix")
+}
+
+// slowTransactor - Transactor taking the delay for every transaction.
+type slowTransactor struct {
+	client.Transactor
+	delay time.Duration
+}
+
+func (s slowTransactor) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.Transactor.EvaluateTransaction(name, args...)
+}
+
+func TestRunFixedRateBehind(t *testing.T) {
+	fake := client.NewFake()
+	slow := slowTransactor{Transactor: fake, delay: 50 * time.Millisecond}
+	medstore := client.New(slow, slow, slow, "bob")
+	workload := &Workload{Workers: 1, Assets: 0, Rounds: []Round{
+		{Duration: 400 * time.Millisecond, Rate: Rate{Type: FixedRate, TPS: 50},
+			Mix: map[string]int{"CheckAvailableMedicine": 1}},
+	}}
+
+	report, err := Run(context.Background(), medstore, workload)
+	assert.Nil(t, err, "should run the workload")
+	round := report.Rounds[0]
+	assert.Greater(t, round.Missed, 0, "should report the transactions the worker couldn't send in time")
+	assert.Greater(t, round.Functions[0].Latency.Max, 150.0, "should count the latency from when transactions were due")
+	assert.InDelta(t, 20, round.Succeeded+round.Missed, 2, "should schedule the transactions at the fixed rate")
+}
+
+func TestErrorKind(t *testing.T) {
+	assert.Equal(t, client.CodeNotAvailable, errorKind(&client.ContractError{Code: client.CodeNotAvailable}),
+		"should count contract errors by code")
+	assert.Equal(t, ErrorMVCCConflict, errorKind(errors.New("transaction invalidated with status (MVCC_READ_CONFLICT)")),
+		"should recognise MVCC conflicts")
+	assert.Equal(t, ErrorOther, errorKind(errors.New("connection refused")), "should count other errors")
+}
+
+func TestLatency(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, Latency{Min: 1, Avg: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, latency(latencies),
+		"should compute the percentiles by the nearest rank")
+	assert.Equal(t, Latency{}, latency(nil), "should report no latency without transactions")
+}
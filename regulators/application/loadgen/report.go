@@ -0,0 +1,219 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Report - Results of the rounds of a run.
+type Report struct {
+	Started  time.Time      `json:"started"`
+	Workload Workload       `json:"workload"`
+	Rounds   []*RoundResult `json:"rounds"`
+}
+
+// RoundResult - Results of a round, measured after its warm-up. Missed counts the transactions of a round at a fixed
+// rate which were due but not sent before the round ended, as the workers didn't keep up.
+type RoundResult struct {
+	Label       string            `json:"label"`
+	Rate        Rate              `json:"rate"`
+	Seconds     float64           `json:"seconds"`
+	Succeeded   int               `json:"succeeded"`
+	Failed      int               `json:"failed"`
+	Throughput  float64           `json:"throughput"`
+	Missed      int               `json:"missed,omitempty"`
+	IdleWorkers int               `json:"idleWorkers,omitempty"`
+	Functions   []*FunctionResult `json:"functions"`
+}
+
+// FunctionResult - Results of the transactions of a function in a round. Throughput counts the successful
+// transactions per second and the latency is theirs, in milliseconds.
+type FunctionResult struct {
+	Function   string         `json:"function"`
+	Succeeded  int            `json:"succeeded"`
+	Failed     int            `json:"failed"`
+	Errors     map[string]int `json:"errors,omitempty"`
+	Throughput float64        `json:"throughput"`
+	Latency    Latency        `json:"latencyMs"`
+}
+
+// Latency - Latency percentiles in milliseconds.
+type Latency struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// result - Returns the results of the round measured until it stopped.
+func (r *recorder) result(round Round, stopped time.Time) *RoundResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seconds := stopped.Sub(r.from).Seconds()
+	if seconds < 0 {
+		seconds = 0
+	}
+	result := &RoundResult{Label: round.Label, Rate: round.Rate, Seconds: seconds, Missed: r.missed,
+		IdleWorkers: r.idlers}
+	var names []string
+	for name := range r.samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := r.samples[name]
+		function := &FunctionResult{Function: name, Succeeded: len(s.latencies), Errors: s.errors,
+			Throughput: perSecond(len(s.latencies), seconds), Latency: latency(s.latencies)}
+		for _, count := range s.errors {
+			function.Failed += count
+		}
+		if len(function.Errors) == 0 {
+			function.Errors = nil
+		}
+		result.Functions = append(result.Functions, function)
+		result.Succeeded += function.Succeeded
+		result.Failed += function.Failed
+	}
+	result.Throughput = perSecond(result.Succeeded, seconds)
+	return result
+}
+
+func perSecond(count int, seconds float64) float64 {
+	if seconds == 0 {
+		return 0
+	}
+	return float64(count) / seconds
+}
+
+// latency - Returns the percentiles of the latencies by the nearest rank.
+func latency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		return milliseconds(sorted[rank-1])
+	}
+	return Latency{
+		Min: milliseconds(sorted[0]),
+		Avg: milliseconds(total / time.Duration(len(sorted))),
+		P50: percentile(50),
+		P90: percentile(90),
+		P95: percentile(95),
+		P99: percentile(99),
+		Max: milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// errorSummary - Returns the errors by kind, e.g. "MVCC_READ_CONFLICT: 3, NOT_AVAILABLE: 1".
+func errorSummary(errors map[string]int) string {
+	var kinds []string
+	for kind := range errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for i, kind := range kinds {
+		kinds[i] = fmt.Sprintf("%s: %d", kind, errors[kind])
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// WriteText - Writes a table of the results of every round.
+func (report *Report) WriteText(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Round\tFunction\tSucc\tFail\tTPS\tAvg (ms)\tP50\tP95\tP99\tMax\tErrors\t")
+	for _, round := range report.Rounds {
+		for _, f := range round.Functions {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%s\t\n", round.Label, f.Function,
+				f.Succeeded, f.Failed, f.Throughput, f.Latency.Avg, f.Latency.P50, f.Latency.P95, f.Latency.P99,
+				f.Latency.Max, errorSummary(f.Errors))
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%.1f\t\t\t\t\t\t%s\t\n", round.Label, "total", round.Succeeded, round.Failed,
+			round.Throughput, roundSummary(round))
+	}
+	return table.Flush()
+}
+
+// roundSummary - Returns the transactions the round missed and the workers which ran out of medicine, if any.
+func roundSummary(round *RoundResult) string {
+	var summary []string
+	if round.Missed > 0 {
+		summary = append(summary, fmt.Sprintf("%d transactions missed", round.Missed))
+	}
+	if round.IdleWorkers > 0 {
+		summary = append(summary, fmt.Sprintf("%d workers ran out of medicine", round.IdleWorkers))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// WriteJSON - Writes the report as indented JSON.
+func (report *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"errors":  errorSummary,
+	"summary": roundSummary,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Medical Supply load generator report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child, td.errors { text-align: left; }
+tr.total { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Medical Supply load generator report</h1>
+<p>Started {{.Started.Format "2006-01-02 15:04:05 MST"}} with {{.Workload.Workers}} workers, each issuing {{.Workload.Assets}} medicine.</p>
+{{range .Rounds}}
+<h2>{{.Label}}</h2>
+<p>{{.Rate.Type}}{{if .Rate.TPS}} at {{.Rate.TPS}} TPS{{end}}, measured for {{printf "%.1f" .Seconds}} seconds. {{summary .}}</p>
+<table>
+<tr><th>Function</th><th>Succeeded</th><th>Failed</th><th>TPS</th><th>Min (ms)</th><th>Avg</th><th>P50</th><th>P90</th><th>P95</th><th>P99</th><th>Max</th><th>Errors</th></tr>
+{{range .Functions}}<tr><td>{{.Function}}</td><td>{{.Succeeded}}</td><td>{{.Failed}}</td><td>{{printf "%.1f" .Throughput}}</td>
+<td>{{printf "%.1f" .Latency.Min}}</td><td>{{printf "%.1f" .Latency.Avg}}</td><td>{{printf "%.1f" .Latency.P50}}</td><td>{{printf "%.1f" .Latency.P90}}</td>
+<td>{{printf "%.1f" .Latency.P95}}</td><td>{{printf "%.1f" .Latency.P99}}</td><td>{{printf "%.1f" .Latency.Max}}</td><td class="errors">{{errors .Errors}}</td></tr>
+{{end}}<tr class="total"><td>total</td><td>{{.Succeeded}}</td><td>{{.Failed}}</td><td>{{printf "%.1f" .Throughput}}</td><td colspan="8"></td></tr>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML - Writes the report as an HTML page with a table per round.
+func (report *Report) WriteHTML(w io.Writer) error {
+	return htmlReport.Execute(w, report)
+}
@@ -0,0 +1,241 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"medical-supply/client"
+)
+
+// Names and diseases of the medicine issued by the workers.
+var (
+	medicineNames = []string{"aspirin", "vicodin", "synthroid", "delasone", "amoxil", "neurontin", "zestril", "lipitor", "glucophage", "zofran", "ibuprofen"}
+	diseases      = []string{"Pain management", "Thyroid deficiency", "Arthritis", "Bacterial infections", "Seizures", "Blood pressure", "High cholesterol", "Type 2 diabetes", "Fever"}
+)
+
+// state - State of a medicine of a worker, as its last transaction left it.
+type state int
+
+const (
+	available state = iota
+	requested
+	sent
+)
+
+var anyState = []state{available, requested, sent}
+
+// medicine - Medicine issued by a worker.
+type medicine struct {
+	name   string
+	number string
+	state  state
+}
+
+// transaction - Transaction of a mix. Transactions on medicine are invoked on a random medicine of the worker in one of
+// the states they require, the other transactions (requires is nil) on none.
+type transaction struct {
+	requires []state
+	invoke   func(ctx context.Context, w *worker, m *medicine) error
+}
+
+var transactions = map[string]transaction{
+	"Issue": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		return w.issue(ctx)
+	}},
+	"Request": {[]state{available}, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.Request(ctx, m.name, m.number)
+		return w.move(m, requested, err)
+	}},
+	"CancelRequest": {[]state{requested}, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.CancelRequest(ctx, m.name, m.number)
+		return w.move(m, available, err)
+	}},
+	"ApproveRequest": {[]state{requested}, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.ApproveRequest(ctx, m.name, m.number)
+		return w.move(m, sent, err)
+	}},
+	"RejectRequest": {[]state{requested}, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.RejectRequest(ctx, m.name, m.number)
+		return w.move(m, available, err)
+	}},
+	"ChangeStatus": {anyState, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.ChangeStatus(ctx, m.name, m.number, "available")
+		return w.move(m, available, err)
+	}},
+	"ChangeHolder": {anyState, func(ctx context.Context, w *worker, m *medicine) error {
+		_, err := w.medstore.ChangeHolder(ctx, m.name, m.number, "loadgen")
+		return err
+	}},
+	"Delete": {[]state{available, sent}, func(ctx context.Context, w *worker, m *medicine) error {
+		err := w.medstore.Delete(ctx, m.name, m.number)
+		if err == nil {
+			w.remove(m)
+		}
+		return err
+	}},
+	"SearchMedicineByName": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.SearchMedicineByName(ctx, medicineNames[w.random.Intn(len(medicineNames))])
+		return err
+	}},
+	"CheckAvailableMedicine": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.CheckAvailableMedicine(ctx)
+		return err
+	}},
+	"CheckRequestedMedicine": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.CheckRequestedMedicine(ctx)
+		return err
+	}},
+	"CheckHistory": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.CheckHistory(ctx)
+		return err
+	}},
+	"CheckUserHistory": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.CheckUserHistory(ctx)
+		return err
+	}},
+	"InventoryReport": {nil, func(ctx context.Context, w *worker, _ *medicine) error {
+		_, err := w.medstore.InventoryReport(ctx)
+		return err
+	}},
+}
+
+// transactionNames - Returns the names of the transactions the load generator supports in order.
+func transactionNames() []string {
+	var names []string
+	for name := range transactions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// worker - Invokes transactions one at a time on the medicine it issued. Workers share the client but not their medicine.
+type worker struct {
+	index     int
+	medstore  *client.Client
+	random    *rand.Rand
+	prefix    string
+	issued    int
+	medicines []*medicine
+	counts    map[state]int
+}
+
+func newWorker(index int, medstore *client.Client, prefix string) *worker {
+	return &worker{
+		index:    index,
+		medstore: medstore,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano() + int64(index))),
+		prefix:   prefix,
+		counts:   make(map[state]int),
+	}
+}
+
+// issue - Issues a new medicine, numbered by the run, the worker and its count so runs never reuse keys.
+func (w *worker) issue(ctx context.Context) error {
+	w.issued++
+	m := &medicine{
+		name:   medicineNames[(w.index+w.issued)%len(medicineNames)],
+		number: fmt.Sprintf("%s-%d-%d", w.prefix, w.index, w.issued),
+		state:  available,
+	}
+	_, err := w.medstore.Issue(ctx, client.IssueInput{
+		MedName:    m.name,
+		MedNumber:  m.number,
+		Disease:    diseases[(w.index+w.issued)%len(diseases)],
+		Expiration: time.Now().AddDate(1, 0, 0).Format("2006.01.02"),
+		Price:      fmt.Sprintf("$%d", 1+w.random.Intn(99)),
+	})
+	if err != nil {
+		return err
+	}
+	w.medicines = append(w.medicines, m)
+	w.counts[available]++
+	return nil
+}
+
+// move - Moves the medicine to the state when its transaction succeeded.
+func (w *worker) move(m *medicine, to state, err error) error {
+	if err == nil {
+		w.counts[m.state]--
+		w.counts[to]++
+		m.state = to
+	}
+	return err
+}
+
+// remove - Removes a deleted medicine.
+func (w *worker) remove(m *medicine) {
+	for i, other := range w.medicines {
+		if other == m {
+			w.medicines = append(w.medicines[:i], w.medicines[i+1:]...)
+			w.counts[m.state]--
+			return
+		}
+	}
+}
+
+// has - Returns whether the worker has medicine in one of the states.
+func (w *worker) has(states []state) bool {
+	for _, s := range states {
+		if w.counts[s] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pick - Returns a random medicine in one of the states.
+func (w *worker) pick(states []state) *medicine {
+	var picked *medicine
+	matches := 0
+	for _, m := range w.medicines {
+		for _, s := range states {
+			if m.state == s {
+				// Reservoir sampling picks every matching medicine with the same chance in a single pass.
+				matches++
+				if w.random.Intn(matches) == 0 {
+					picked = m
+				}
+			}
+		}
+	}
+	return picked
+}
+
+// choose - Chooses a transaction of the mix by weight among those the worker has medicine for. It returns an empty
+// name when the worker can't run any transaction of the mix, e.g. ApproveRequest once all medicine is approved.
+func (w *worker) choose(names []string, mix map[string]int) (string, *medicine) {
+	total := 0
+	for _, name := range names {
+		if w.runnable(name) {
+			total += mix[name]
+		}
+	}
+	if total == 0 {
+		return "", nil
+	}
+
+	n := w.random.Intn(total)
+	for _, name := range names {
+		if !w.runnable(name) {
+			continue
+		}
+		n -= mix[name]
+		if n < 0 {
+			requires := transactions[name].requires
+			if requires == nil {
+				return name, nil
+			}
+			return name, w.pick(requires)
+		}
+	}
+	return "", nil
+}
+
+func (w *worker) runnable(name string) bool {
+	requires := transactions[name].requires
+	return requires == nil || w.has(requires)
+}
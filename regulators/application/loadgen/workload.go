@@ -0,0 +1,132 @@
+// Package loadgen generates load on the medical-supply chaincode through the typed client, so the same workload runs
+// against the gateway of a network and against the offline simulator. A workload is a number of rounds, each running
+// a mix of transactions with a pool of workers for a fixed duration after a warm-up, at a fixed rate or a fixed load.
+//
+// Every worker issues its own medicine before the rounds and keeps track of their state, so transactions such as
+// ApproveRequest always find requested medicine and workers never contend for the same keys.
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rate controls.
+const (
+	// FixedLoad - Every worker sends its next transaction as soon as the previous one completes.
+	FixedLoad = "fixed-load"
+	// FixedRate - The workers together send TPS transactions per second. Transactions wait for a free worker when the
+	// workers don't keep up and those still waiting when the round ends are missed.
+	FixedRate = "fixed-rate"
+)
+
+// Workload - Rounds of transactions run one after the other by the same workers.
+type Workload struct {
+	Workers int     `yaml:"workers" json:"workers"`
+	Assets  int     `yaml:"assets" json:"assets"`
+	Cleanup bool    `yaml:"cleanup" json:"cleanup"`
+	Rounds  []Round `yaml:"rounds" json:"-"`
+}
+
+// Round - Mix of transactions, the weights set how often each transaction is chosen (e.g. Request: 3, ApproveRequest: 1).
+type Round struct {
+	Label    string         `yaml:"label"`
+	Warmup   time.Duration  `yaml:"warmup"`
+	Duration time.Duration  `yaml:"duration"`
+	Rate     Rate           `yaml:"rate"`
+	Mix      map[string]int `yaml:"mix"`
+}
+
+// Rate - Rate control of a round.
+type Rate struct {
+	Type string  `yaml:"type" json:"type"`
+	TPS  float64 `yaml:"tps" json:"tps,omitempty"`
+}
+
+// Load - Reads the workload from a YAML file and validates it.
+func Load(filename string) (*Workload, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	var w Workload
+	err = yaml.UnmarshalStrict(data, &w)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workload %s: %w", filename, err)
+	}
+	err = w.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid workload %s: %w", filename, err)
+	}
+	return &w, nil
+}
+
+// Validate - Checks the workers, the rate control and the transactions of every round.
+func (w *Workload) Validate() error {
+	var problems []string
+	if w.Workers < 1 {
+		problems = append(problems, "workers should be at least 1")
+	}
+	if w.Assets < 0 {
+		problems = append(problems, "assets should not be negative")
+	}
+	if len(w.Rounds) == 0 {
+		problems = append(problems, "no rounds defined")
+	}
+
+	for i, round := range w.Rounds {
+		name := round.Label
+		if name == "" {
+			name = fmt.Sprintf("round %d", i+1)
+		}
+		if round.Duration <= 0 {
+			problems = append(problems, name+": duration should be positive (e.g. 60s)")
+		}
+		if round.Warmup < 0 {
+			problems = append(problems, name+": warmup should not be negative")
+		}
+		switch round.Rate.Type {
+		case FixedLoad:
+		case FixedRate:
+			if round.Rate.TPS <= 0 {
+				problems = append(problems, name+": tps of fixed-rate should be positive")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: rate type should be %s or %s", name, FixedLoad, FixedRate))
+		}
+
+		if len(round.Mix) == 0 {
+			problems = append(problems, name+": mix has no transactions")
+		}
+		for _, function := range functions(round.Mix) {
+			if _, ok := transactions[function]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown transaction %s, choose one of %s", name, function,
+					strings.Join(transactionNames(), ", ")))
+			} else if round.Mix[function] < 1 {
+				problems = append(problems, fmt.Sprintf("%s: weight of %s should be at least 1", name, function))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// functions - Returns the transactions of the mix in order, for stable messages and reports.
+func functions(mix map[string]int) []string {
+	var names []string
+	for name := range mix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	medicalsupply "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/medical-supply"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/client"
+	"medical-supply/identity"
+	"medical-supply/profile"
+	"medical-supply/simulator"
+)
+
+// Returns the settings of the offline mode. The applications share the simulated ledger in the offline folder of the
+// profile, the wallet, tpm keys and audit log of each organisation are kept in a folder of its MSP next to it.
+func offlineProfile(settings *profile.Profile) *profile.Profile {
+	if settings.Offline == "" {
+		log.Fatalf("\nProfile %s has no offline folder, set offline or %sOFFLINE.", settings.Name, profile.EnvPrefix)
+	}
+	offline := *settings
+	offline.Wallet = filepath.Join(settings.Offline, settings.MSPID, "wallet")
+	offline.TPMKeys = filepath.Join(settings.Offline, settings.MSPID)
+	offline.Credentials = ""
+	if settings.AuditLog != "" {
+		offline.AuditLog = filepath.Join(settings.Offline, settings.MSPID, "audit.log")
+	}
+	return &offline
+}
+
+// Starts the simulator running the smart contracts of the chaincode in-process on the shared ledger.
+func startSimulator(settings *profile.Profile) *simulator.Simulator {
+	chaincode, err := contractapi.NewChaincode(medicalsupply.NewCustomerContract(), medicalsupply.NewRegulatorContract(), medicalsupply.NewAuthContract())
+	if err != nil {
+		log.Fatalf("\nError creating chaincode: %v", err)
+	}
+	sim, err := simulator.New(chaincode, filepath.Join(settings.Offline, "ledger.json"))
+	if err != nil {
+		log.Fatalf("\nFailed to start simulator: %v", err)
+	}
+	return sim
+}
+
+// Issues the identity of the user of the profile with a self-signed certificate, other users are enrolled with the
+// simulated CA or imported first as on the network.
+func enrollOfflineUser(wallet *gateway.Wallet, settings *profile.Profile, user string) {
+	if wallet.Exists(user) {
+		log.Println("============ Sucessfully populated wallet ============")
+		return
+	}
+	if user != settings.User {
+		log.Fatalf("\nIdentity %s is not in the offline wallet, enroll it with the simulated CA (-offline ca enroll) or import it (-offline wallet import).", user)
+	}
+
+	id, err := simulator.Issue(settings.MSPID, user, nil)
+	if err == nil {
+		err = wallet.Put(user, id)
+	}
+	if err != nil {
+		log.Fatalf("\nFailed to populate wallet contents: %v", err)
+	}
+}
+
+// Creates the client of the smart contracts running in the simulator, invoked with the identity of user.
+func connectToSimulator(sim *simulator.Simulator, wallet *gateway.Wallet, user string) *client.Client {
+	id, err := identity.Get(wallet, user)
+	if err != nil {
+		log.Fatalf("\n%v", err)
+	}
+
+	contract := func(name string) client.Transactor {
+		c, err := sim.Contract(name, id)
+		if err != nil {
+			log.Fatalf("\nFailed to get contract %s: %v", name, err)
+		}
+		return c
+	}
+	medstore := client.New(contract(client.CustomerContract), contract(client.RegulatorContract), contract(client.AuthContract), user)
+	medstore.SetSystemContract(contract(client.SystemContract))
+	return medstore
+}
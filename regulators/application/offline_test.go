@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"github.com/stretchr/testify/assert"
+	"medical-supply/audit"
+	"medical-supply/client"
+	"medical-supply/profile"
+	"medical-supply/simulator"
+)
+
+// Connects user to the smart contracts running offline, authenticated with a new TPM key.
+func offlineClient(t *testing.T, settings *profile.Profile, wallet *gateway.Wallet, user string) *client.Client {
+	medstore := connectToSimulator(startSimulator(settings), wallet, user)
+	tpmkey, err := medstore.TPMKeyGen(context.Background())
+	assert.Nil(t, err, "should generate a TPM key")
+	medstore.SetTPMKey(tpmkey)
+	return medstore
+}
+
+func TestOfflineMode(t *testing.T) {
+	ctx := context.Background()
+	settings := offlineProfile(&profile.Profile{Name: "test", MSPID: "Org2MSP", User: "bob", Offline: t.TempDir(),
+		AuditLog: "audit.log"})
+	wallet, err := gateway.NewFileSystemWallet(settings.Wallet)
+	assert.Nil(t, err, "should open the offline wallet")
+	enrollOfflineUser(wallet, settings, "bob")
+	assert.True(t, wallet.Exists("bob"), "should issue the identity of the user of the profile")
+	regulator := offlineClient(t, settings, wallet, "bob")
+	regulator.SetObserver(openAuditLog(settings, "bob"))
+	assert.Nil(t, regulator.Ping(ctx), "should answer the health checks")
+
+	_, err = regulator.Issue(ctx, client.IssueInput{MedName: "Aspirin", MedNumber: "00001", Disease: "Pain management",
+		Expiration: "2099.05.09", Price: "$10"})
+	assert.Nil(t, err, "should issue medicine")
+
+	// The customers application shares the ledger in the offline folder.
+	customerWallet := gateway.NewInMemoryWallet()
+	alice, err := simulator.Issue("Org1MSP", "alice", nil)
+	assert.Nil(t, err, "should issue an identity of the customers")
+	customerWallet.Put("alice", alice)
+	customer := offlineClient(t, settings, customerWallet, "alice")
+
+	_, err = customer.Request(ctx, "aspirin", "00001")
+	assert.Nil(t, err, "should request the medicine issued by the regulator")
+	_, err = customer.Issue(ctx, client.IssueInput{MedName: "Aspirin", MedNumber: "00002", Disease: "Pain management",
+		Expiration: "2099.05.09", Price: "$10"})
+	assert.Equal(t, client.CodeUnauthorizedOrg, client.ErrorCode(err), "should check the organisation of the identity")
+
+	approved, err := regulator.ApproveRequest(ctx, "aspirin", "00001")
+	assert.Nil(t, err, "should approve the request")
+	assert.Equal(t, "alice", approved.Holder, "should hand the medicine to the customer")
+
+	sent, err := regulator.QueryMedicines(ctx, `{"state":"SEND"}`)
+	assert.Nil(t, err, "should query the medicine without rich queries")
+	assert.Len(t, sent, 1, "should filter all medicine instead")
+
+	document, err := regulator.ExportEPCIS(ctx, client.EPCISInput{})
+	assert.Nil(t, err, "should export the history of the medicine")
+	var epcis struct {
+		EPCISBody struct {
+			EventList []json.RawMessage `json:"eventList"`
+		} `json:"epcisBody"`
+	}
+	assert.Nil(t, json.Unmarshal(document, &epcis), "should export an EPCIS document")
+	assert.Len(t, epcis.EPCISBody.EventList, 4, "should convert the history of the medicine into events")
+
+	head, err := audit.Verify(settings.AuditLog)
+	assert.Nil(t, err, "should keep the audit log in the offline folder")
+	assert.Equal(t, 2, head.Sequence, "should audit the transactions submitted by the regulator")
+}
@@ -0,0 +1,107 @@
+package profile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+default: dev
+profiles:
+  dev:
+    user: alice
+    connectionProfile: gateway/connection-org1.yaml
+    credentials: users/User1/msp
+  staging:
+    mspID: Org1MSP
+    user: pharmacy
+    channel: staging
+    chaincode: medicinecontract
+    connectionProfile: /etc/medstore/connection-staging.yaml
+    credentials: ""
+    wallet: /var/lib/medstore/wallet
+    ca:
+      url: https://ca.staging.example.com:7054
+`
+
+var testDefaults = Profile{MSPID: "Org1MSP", User: "alice", Channel: "mychannel", Chaincode: "medicinecontract",
+	ConnectionProfile: "connection-org1.yaml", Credentials: "test-network/msp", Wallet: "wallet", TPMKeys: ".",
+	CA: CA{Name: "ca-org1", Affiliation: "org1.department1"}}
+
+// writeConfig - Writes the configuration file next to the connection profile it refers to.
+func writeConfig(t *testing.T, config string) string {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "gateway"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "gateway", "connection-org1.yaml"), []byte("name: test"), 0600)
+	filename := filepath.Join(dir, "medstore.yaml")
+	ioutil.WriteFile(filename, []byte(config), 0600)
+	return filename
+}
+
+func TestLoadProfile(t *testing.T) {
+	filename := writeConfig(t, testConfig)
+	dir := filepath.Dir(filename)
+
+	p, err := Load(filename, "", true, testDefaults)
+	assert.Nil(t, err, "should load the default profile")
+	assert.Equal(t, &Profile{Name: "dev", MSPID: "Org1MSP", User: "alice", Channel: "mychannel", Chaincode: "medicinecontract",
+		ConnectionProfile: filepath.Join(dir, "gateway", "connection-org1.yaml"), Credentials: filepath.Join(dir, "users", "User1", "msp"),
+		Wallet: "wallet", TPMKeys: ".", CA: CA{Name: "ca-org1", Affiliation: "org1.department1"}}, p, "should resolve paths relative to the file and fall back to the defaults")
+
+	_, err = Load(filename, "staging", true, testDefaults)
+	assert.EqualError(t, err, "invalid profile staging: connectionProfile /etc/medstore/connection-staging.yaml does not exist",
+		"should select the profile by name and validate it")
+
+	os.Setenv("MEDSTORE_PROFILE", "staging")
+	os.Setenv("MEDSTORE_CONNECTION_PROFILE", filepath.Join(dir, "gateway", "connection-org1.yaml"))
+	os.Setenv("MEDSTORE_CA_URL", "https://ca.override.example.com")
+	defer os.Unsetenv("MEDSTORE_PROFILE")
+	defer os.Unsetenv("MEDSTORE_CONNECTION_PROFILE")
+	defer os.Unsetenv("MEDSTORE_CA_URL")
+	p, err = Load(filename, "", true, testDefaults)
+	assert.Nil(t, err, "should select the profile from the environment")
+	assert.Equal(t, "pharmacy", p.User, "should use the settings of the profile")
+	assert.Equal(t, "https://ca.override.example.com", p.CA.URL, "should override settings with the environment")
+	assert.Equal(t, "ca-org1", p.CA.Name, "should fall back to the defaults for missing nested settings")
+	assert.Equal(t, "/var/lib/medstore/wallet", p.Wallet, "should keep absolute paths")
+	assert.Equal(t, "", p.Credentials, "should not fall back to the defaults for empty settings")
+	assert.Equal(t, ".", p.TPMKeys, "should fall back to the defaults for missing settings")
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "connection-org1.yaml"), []byte("name: test"), 0600)
+	defaults := testDefaults
+	defaults.ConnectionProfile = filepath.Join(dir, "connection-org1.yaml")
+
+	p, err := Load(filepath.Join(dir, "medstore.yaml"), "", false, defaults)
+	assert.Nil(t, err, "should use the defaults without file")
+	assert.Equal(t, "default", p.Name, "should name the profile of the defaults")
+
+	_, err = Load(filepath.Join(dir, "medstore.yaml"), "", true, defaults)
+	assert.True(t, os.IsNotExist(err), "should require the file when set explicitly")
+	_, err = Load(filepath.Join(dir, "medstore.yaml"), "production", false, defaults)
+	assert.EqualError(t, err, "profile production not found, configuration file "+filepath.Join(dir, "medstore.yaml")+" does not exist",
+		"should not silently ignore the selected profile")
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := Load(writeConfig(t, testConfig), "production", true, testDefaults)
+	assert.Contains(t, err.Error(), "profile production not found, choose one of dev, staging", "should list the profiles")
+
+	_, err = Load(writeConfig(t, "profiles:\n  dev:\n    channnel: mychannel\n"), "", true, testDefaults)
+	assert.Contains(t, err.Error(), "field channnel not found", "should reject unknown settings")
+
+	_, err = Load(writeConfig(t, "profiles:\n  a:\n    user: alice\n  b:\n    user: bob\n"), "", true, testDefaults)
+	assert.Contains(t, err.Error(), "no profile selected and no default set, choose one of a, b", "should require a profile")
+
+	_, err = Load(writeConfig(t, "profiles:\n  dev:\n    connectionProfile: gateway/connection-org1.yaml\n    ca:\n      url: localhost:7054\n"), "", true, Profile{})
+	assert.EqualError(t, err, "invalid profile dev: mspID is required (MEDSTORE_MSP_ID), user is required (MEDSTORE_USER), "+
+		"channel is required (MEDSTORE_CHANNEL), chaincode is required (MEDSTORE_CHAINCODE), wallet is required (MEDSTORE_WALLET), "+
+		"tpmKeys is required (MEDSTORE_TPM_KEYS), "+
+		"ca.url localhost:7054 should be an http or https URL", "should list all problems")
+}
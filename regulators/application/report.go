@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"medical-supply/client"
+)
+
+var reportHeader = []string{"Medicine", "State", "Count", "Total value", "Unpriced", "Holders", "Earliest expiry", "Latest expiry"}
+
+// Returns the rows of the report, totals per state and the grand total are marked as TOTAL.
+func reportRows(report *client.InventoryReport) [][]string {
+	var rows [][]string
+	add := func(line *client.InventoryLine, medName string, state string) {
+		rows = append(rows, []string{medName, state, strconv.Itoa(line.Count), line.TotalValue, strconv.Itoa(line.Unpriced),
+			strconv.Itoa(line.DistinctHolders), line.EarliestExpiry, line.LatestExpiry})
+	}
+
+	for _, line := range report.Lines {
+		add(line, line.MedName, line.State)
+	}
+	for _, line := range report.StateTotals {
+		add(line, "TOTAL", line.State)
+	}
+	if report.Total != nil {
+		add(report.Total, "TOTAL", "ALL")
+	}
+	return rows
+}
+
+// Renders the inventory report as a table, CSV or JSON.
+func renderReport(w io.Writer, report *client.InventoryReport, format string) error {
+	switch strings.ToLower(format) {
+	case "", "table":
+		fmt.Fprintf(w, "Inventory report of %s\n\n", report.GeneratedAt)
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, strings.Join(reportHeader, "\t"))
+		for _, row := range reportRows(report) {
+			fmt.Fprintln(table, strings.Join(row, "\t"))
+		}
+		return table.Flush()
+	case "csv":
+		writer := csv.NewWriter(w)
+		err := writer.Write(reportHeader)
+		if err != nil {
+			return err
+		}
+		return writer.WriteAll(reportRows(report))
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return fmt.Errorf("unknown report format %s, expected table, csv or json", format)
+}
+
+// Handling regulators wanting an overview of the stock by medicine name and state.
+func report(medstore *client.Client, scanner *bufio.Scanner) {
+	log.Println("Output format (table, csv or json):")
+	scanner.Scan()
+	format := scanner.Text()
+
+	log.Println("--> Evaluate Transaction: InventoryReport, function shows the stock by medicine name and state.")
+	inventory, err := medstore.InventoryReport(context.Background())
+	if err != nil {
+		failTransaction(err)
+	}
+
+	err = renderReport(os.Stdout, inventory, format)
+	if err != nil {
+		log.Fatalf("\nFailed to render inventory report: %v", err)
+	}
+}
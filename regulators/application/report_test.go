@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"medical-supply/client"
+)
+
+var testReport = &client.InventoryReport{
+	GeneratedAt: "2022-02-21T09:00:00Z",
+	Lines:       []*client.InventoryLine{{MedName: "aspirin", State: "AVAILABLE", Count: 2, TotalValue: "$12.50", DistinctHolders: 1, EarliestExpiry: "2022.01.01", LatestExpiry: "2022.05.09"}},
+	StateTotals: []*client.InventoryLine{{State: "AVAILABLE", Count: 2, TotalValue: "$12.50", DistinctHolders: 1, EarliestExpiry: "2022.01.01", LatestExpiry: "2022.05.09"}},
+	Total:       &client.InventoryLine{Count: 2, TotalValue: "$12.50", DistinctHolders: 1, EarliestExpiry: "2022.01.01", LatestExpiry: "2022.05.09"},
+}
+
+func TestRenderReportCSV(t *testing.T) {
+	var out bytes.Buffer
+	err := renderReport(&out, testReport, "csv")
+	assert.Nil(t, err, "should render CSV")
+	assert.Equal(t, "Medicine,State,Count,Total value,Unpriced,Holders,Earliest expiry,Latest expiry\n"+
+		"aspirin,AVAILABLE,2,$12.50,0,1,2022.01.01,2022.05.09\n"+
+		"TOTAL,AVAILABLE,2,$12.50,0,1,2022.01.01,2022.05.09\n"+
+		"TOTAL,ALL,2,$12.50,0,1,2022.01.01,2022.05.09\n", out.String(), "should add a row per line and total")
+}
+
+func TestRenderReportTable(t *testing.T) {
+	var out bytes.Buffer
+	err := renderReport(&out, testReport, "table")
+	assert.Nil(t, err, "should render table")
+	assert.Contains(t, out.String(), "Inventory report of 2022-02-21T09:00:00Z", "should show when the report was generated")
+	assert.Contains(t, out.String(), "aspirin   AVAILABLE  2      $12.50", "should align columns")
+}
+
+func TestRenderReportJSON(t *testing.T) {
+	var out bytes.Buffer
+	err := renderReport(&out, testReport, "json")
+	assert.Nil(t, err, "should render JSON")
+	assert.Contains(t, out.String(), `"totalValue": "$12.50"`, "should indent the report")
+
+	err = renderReport(&out, testReport, "xml")
+	assert.EqualError(t, err, "unknown report format xml, expected table, csv or json", "should reject unknown formats")
+}
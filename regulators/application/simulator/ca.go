@@ -0,0 +1,149 @@
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/identity"
+)
+
+// Bootstrap identity of the simulated CA of every organisation, as in the test network.
+const (
+	bootstrapName   = "admin"
+	bootstrapSecret = "adminpw"
+)
+
+// attributesOID - Certificate extension of the attributes of Fabric CA identities.
+var attributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// registration - Identity registered with the simulated CA.
+type registration struct {
+	Secret     string               `json:"secret"`
+	Attributes []identity.Attribute `json:"attributes,omitempty"`
+}
+
+// CA - Simulated Fabric CA, which registers identities on the ledger of the simulator and enrolls them with
+// self-signed certificates. It has the same methods as the CA client of the identity package, but doesn't check
+// whether the registrar is allowed to register identities.
+type CA struct {
+	simulator *Simulator
+}
+
+// CA - Returns the simulated CA, the organisation of an identity is set by the MSP it is enrolled with.
+func (s *Simulator) CA() *CA {
+	return &CA{simulator: s}
+}
+
+// Register - Registers a new identity in the organisation of the registrar, returning its enrollment secret.
+func (ca *CA) Register(registrar *gateway.X509Identity, r identity.Registration) (string, error) {
+	s := ca.simulator
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.load()
+	if err != nil {
+		return "", err
+	}
+
+	key := registrar.MspID + "/" + r.Name
+	if _, ok := s.ledger.Registrations[key]; ok || r.Name == bootstrapName {
+		return "", fmt.Errorf("could not register %s: identity is already registered", r.Name)
+	}
+	if r.Secret == "" {
+		secret := make([]byte, 8)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return "", err
+		}
+		r.Secret = hex.EncodeToString(secret)
+	}
+	s.ledger.Registrations[key] = &registration{Secret: r.Secret, Attributes: r.Attributes}
+	return r.Secret, s.save()
+}
+
+// Enroll - Enrolls the registered identity with its secret, returning it as identity of the MSP. The requested
+// attributes are required to be registered, they are added to the certificate with the registered ECert attributes.
+func (ca *CA) Enroll(mspID string, name string, secret string, attributes ...string) (*gateway.X509Identity, error) {
+	s := ca.simulator
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	registered, ok := s.ledger.Registrations[mspID+"/"+name]
+	if !ok && name == bootstrapName {
+		registered, ok = &registration{Secret: bootstrapSecret}, true
+	}
+	if !ok || registered.Secret != secret {
+		return nil, fmt.Errorf("could not enroll %s: authentication failure", name)
+	}
+
+	values := map[string]string{}
+	for _, attribute := range registered.Attributes {
+		if attribute.ECert {
+			values[attribute.Name] = attribute.Value
+		}
+	}
+	for _, requested := range attributes {
+		found := false
+		for _, attribute := range registered.Attributes {
+			if attribute.Name == requested {
+				values[attribute.Name], found = attribute.Value, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("could not enroll %s: attribute %s is not registered", name, requested)
+		}
+	}
+	return Issue(mspID, name, values)
+}
+
+// Issue - Creates an identity of the MSP with a self-signed certificate holding the attributes, which the chaincode
+// accepts as the simulator doesn't verify certificates.
+func Issue(mspID string, name string, attributes map[string]string) (*gateway.X509Identity, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, OrganizationalUnit: []string{"client"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if len(attributes) > 0 {
+		value, err := json.Marshal(map[string]map[string]string{"attrs": attributes})
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: attributesOID, Value: value}}
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.NewX509Identity(mspID, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))), nil
+}
@@ -0,0 +1,210 @@
+// Package simulator runs the chaincode in-process on a simulated ledger, so the applications can be used without
+// a Fabric network, e.g. for demos, trainings and UI development.
+//
+// The world state, the history of its keys and the identities registered with the simulated CA are stored in a JSON
+// file after every submitted transaction. The file is read again before every transaction, so applications sharing
+// it see each other's transactions. Like a peer using LevelDB, the simulator doesn't support rich queries.
+package simulator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"medical-supply/client"
+)
+
+// Simulator - Runs the transactions of a chaincode on a simulated ledger.
+type Simulator struct {
+	mu        sync.Mutex
+	chaincode shim.Chaincode
+	filename  string
+	ledger    *ledger
+}
+
+// ledger - Simulated ledger, as stored in the file of the simulator.
+type ledger struct {
+	State         map[string][]byte         `json:"state"`
+	History       map[string][]modification `json:"history"`
+	Registrations map[string]*registration  `json:"registrations"`
+}
+
+// modification - Version of a key written by a transaction.
+type modification struct {
+	TxID      string    `json:"txID"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     []byte    `json:"value,omitempty"`
+	IsDelete  bool      `json:"isDelete,omitempty"`
+}
+
+// Contract - Contract of the chaincode invoked by a single identity, implements the Transactor of the client package.
+type Contract struct {
+	simulator *Simulator
+	name      string
+	creator   []byte
+}
+
+// New - Creates a simulator of the chaincode, storing its ledger in the file. Without file the ledger is only kept
+// in memory.
+func New(chaincode shim.Chaincode, filename string) (*Simulator, error) {
+	s := &Simulator{chaincode: chaincode, filename: filename, ledger: newLedger()}
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newLedger() *ledger {
+	return &ledger{State: map[string][]byte{}, History: map[string][]modification{}, Registrations: map[string]*registration{}}
+}
+
+// Contract - Returns the named contract of the chaincode, invoked by the identity as creator of its transactions.
+func (s *Simulator) Contract(name string, id *gateway.X509Identity) (*Contract, error) {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: id.MspID, IdBytes: []byte(id.Certificate())})
+	if err != nil {
+		return nil, err
+	}
+	return &Contract{simulator: s, name: name, creator: creator}, nil
+}
+
+// SubmitTransaction - Runs the transaction and commits its writes to the ledger when it succeeds.
+func (c *Contract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	result, _, err := c.simulator.invoke(c, name, args, true)
+	return result, err
+}
+
+// SubmitAndCommit - Submits the transaction, returning how it was committed like the commit event of a peer. The
+// commit is timed from the end of the endorsement until the ledger is saved. Transactions run one at a time, so they
+// never conflict.
+func (c *Contract) SubmitAndCommit(name string, args ...string) ([]byte, client.Commit, error) {
+	return c.simulator.invoke(c, name, args, true)
+}
+
+// EvaluateTransaction - Runs the transaction, its writes are discarded like those of a query on a peer.
+func (c *Contract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	result, _, err := c.simulator.invoke(c, name, args, false)
+	return result, err
+}
+
+// invoke - Runs a transaction of the contract on a copy of the world state, which replaces the ledger when committed.
+// It returns the result and how the transaction was committed, only its ID when it's not.
+func (s *Simulator) invoke(contract *Contract, name string, args []string, commit bool) ([]byte, client.Commit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.load()
+	if err != nil {
+		return nil, client.Commit{}, err
+	}
+
+	function := name
+	if contract.name != "" {
+		function = contract.name + ":" + name
+	}
+	txID, err := newTxID()
+	if err != nil {
+		return nil, client.Commit{}, err
+	}
+	stub := newStub(s.ledger, contract.creator, function, args)
+	stub.MockTransactionStart(txID)
+	response := s.chaincode.Invoke(stub)
+	stub.MockTransactionEnd(txID)
+
+	if response.Status >= shim.ERRORTHRESHOLD {
+		// The message holds the error of the contract, as in the errors of the gateway.
+		return nil, client.Commit{}, errors.New(response.Message)
+	}
+	if !commit {
+		return response.Payload, client.Commit{TxID: txID}, nil
+	}
+	committed := time.Now()
+	if len(stub.written) == 0 {
+		return response.Payload, client.Commit{TxID: txID, ValidationCode: client.ValidationValid, Duration: time.Since(committed)}, nil
+	}
+
+	// The history uses the timestamp of the transaction as the chaincode sees it.
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return nil, client.Commit{}, err
+	}
+	timestamp := time.Unix(ts.Seconds, int64(ts.Nanos)).UTC()
+	for _, key := range stub.written {
+		value, ok := stub.State[key]
+		if ok {
+			s.ledger.State[key] = value
+		} else {
+			delete(s.ledger.State, key)
+		}
+		s.ledger.History[key] = append(s.ledger.History[key], modification{TxID: txID, Timestamp: timestamp, Value: value, IsDelete: !ok})
+	}
+	err = s.save()
+	if err != nil {
+		return nil, client.Commit{}, err
+	}
+	return response.Payload, client.Commit{TxID: txID, ValidationCode: client.ValidationValid, Duration: time.Since(committed)}, nil
+}
+
+// load - Reads the ledger from the file, which is empty until the first transaction is committed.
+func (s *Simulator) load() error {
+	if s.filename == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filepath.Clean(s.filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	loaded := newLedger()
+	err = json.Unmarshal(data, loaded)
+	if err != nil {
+		return fmt.Errorf("invalid ledger file %s: %w", s.filename, err)
+	}
+	s.ledger = loaded
+	return nil
+}
+
+// save - Writes the ledger to the file, replacing it at once so other applications never read a partial ledger.
+func (s *Simulator) save() error {
+	if s.filename == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.ledger)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.filename), 0700)
+	if err != nil {
+		return err
+	}
+
+	temp := s.filename + ".tmp"
+	err = ioutil.WriteFile(temp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, s.filename)
+}
+
+// newTxID - Returns a random transaction ID, formatted like those of Fabric.
+func newTxID() (string, error) {
+	id := make([]byte, 32)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
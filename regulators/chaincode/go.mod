@@ -1,6 +1,6 @@
 module github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode
 
-go 1.17
+go 1.18
 
 require (
 	github.com/golang/protobuf v1.5.2
@@ -0,0 +1,27 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthAuthorize(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	identity := &fakeIdentity{mspID: CustomerMSP}
+	ctx.SetClientIdentity(identity)
+	c := NewAuthContract()
+
+	assert.Nil(t, c.authorize(ctx, "TPMKeyGen", []string{"customer"}), "should allow customers")
+	identity.mspID = RegulatorMSP
+	assert.Nil(t, c.authorize(ctx, "TPMKeyGen", []string{"regulator"}), "should allow regulators")
+
+	identity.mspID = "Org3MSP"
+	err := c.authorize(ctx, "TPMKeyGen", []string{"intruder"})
+	assert.Equal(t, CodeUnauthorizedOrg, ErrorCodeOf(err), "should reject other organisations")
+	err = c.authorize(ctx, "Unknown", nil)
+	assert.Equal(t, CodeInternal, ErrorCodeOf(err), "should fail closed for unknown transactions")
+}
@@ -0,0 +1,48 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChaincode(t *testing.T) {
+	chaincode, err := contractapi.NewChaincode(NewCustomerContract(), NewRegulatorContract(), NewAuthContract())
+	assert.Nil(t, err, "should accept the contracts and their hooks")
+	assert.Equal(t, CustomerContractName, chaincode.DefaultContract, "should use the customer contract by default")
+}
+
+func TestCustomerAuthorize(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	identity := &fakeIdentity{mspID: CustomerMSP}
+	ctx.SetClientIdentity(identity)
+	c := NewCustomerContract()
+
+	stub.MockTransactionStart("tx1")
+	customerKey, _ := NewAuthContract().TPMKeyGen(ctx, "Customer")
+	stub.MockTransactionEnd("tx1")
+
+	assert.Nil(t, c.authorize(ctx, "SearchMedicine", []string{"aspirin"}), "should allow public transactions")
+	assert.Nil(t, c.authorize(ctx, "Request", []string{"aspirin", "00001", "Customer", customerKey}), "should allow authenticated users")
+
+	err := c.authorize(ctx, "Request", []string{"aspirin", "00001", "customer", "wrong"})
+	assert.Equal(t, CodeUnauthenticated, ErrorCodeOf(err), "should reject a wrong tpm key")
+	err = c.authorize(ctx, "Request", []string{"aspirin", "00001", "unknown", customerKey})
+	assert.Equal(t, CodeUnauthenticated, ErrorCodeOf(err), "should reject unknown users")
+	err = c.authorize(ctx, "Request", []string{"aspirin", "00001", "cus:tomer", customerKey})
+	assert.Equal(t, "invalid Request: user should not contain ':' or control characters", err.(*ContractError).Message, "should validate the credentials first")
+	err = c.authorize(ctx, "Request", []string{"aspirin", "customer", customerKey})
+	assert.Equal(t, CodeInvalidArgument, ErrorCodeOf(err), "should reject missing arguments")
+	err = c.authorize(ctx, "Unknown", []string{"customer", customerKey})
+	assert.Equal(t, CodeInternal, ErrorCodeOf(err), "should fail closed for transactions without credentials")
+
+	args := []string{"RX1", "customer", "aspirin", "1", "0", "2022.01.01", "2022.12.31", "customer", customerKey}
+	err = c.authorize(ctx, "IssuePrescription", args)
+	assert.Equal(t, CodeMissingRole, ErrorCodeOf(err), "should require the prescriber role")
+	identity.role = "prescriber"
+	assert.Nil(t, c.authorize(ctx, "IssuePrescription", args), "should allow prescribers")
+}
@@ -0,0 +1,68 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDestructionKey(t *testing.T) {
+	assert.Equal(t, ledgerapi.MakeKey("Destruction", "vicodin", "00002"), CreateDestructionKey("vicodin", "00002"), "should return key comprised of passed values.")
+}
+
+func TestGetDestructionSplitKey(t *testing.T) {
+	cert := new(DestructionCertificate)
+	cert.MedName = "vicodin"
+	cert.MedNumber = "00002"
+
+	assert.Equal(t, []string{"Destruction", "vicodin", "00002"}, cert.GetSplitKey(), "should return medicine name and number as split key.")
+}
+
+func TestSerializeDestruction(t *testing.T) {
+	cert := new(DestructionCertificate)
+	cert.CertificateID = "tx1"
+	cert.MedName = "vicodin"
+	cert.MedNumber = "00002"
+	cert.CheckSum = "checksum"
+	cert.Reason = "recalled"
+	cert.Method = "incineration"
+	cert.Witnesses = []string{"carol", "dave"}
+	cert.DestructionDate = "2022.02.22"
+	cert.RecordedBy = "bob"
+	cert.RecordedAt = "2022-02-22T10:00:00Z"
+	correctJson := `{"certificateID":"tx1","medName":"vicodin","medNumber":"00002","checkSum":"checksum","reason":"recalled","method":"incineration","witnesses":["carol","dave"],"destructionDate":"2022.02.22","documentHash":"","recordedBy":"bob","recordedAt":"2022-02-22T10:00:00Z","class":"org.medstore.destruction","key":"Destruction:vicodin:00002","schemaVersion":2}`
+
+	bytes, err := cert.Serialize()
+	assert.Nil(t, err, "should not error on serialize")
+	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
+}
+
+func TestDeserializeDestruction(t *testing.T) {
+	var cert *DestructionCertificate
+	var err error
+
+	cert = new(DestructionCertificate)
+	correctJson := `{"certificateID":"tx1","medName":"vicodin","medNumber":"00002","checkSum":"checksum","reason":"recalled","method":"incineration","witnesses":["carol"],"destructionDate":"2022.02.22","documentHash":"ab12","recordedBy":"bob","recordedAt":"2022-02-22T10:00:00Z","class":"org.medstore.destruction","key":"Destruction:vicodin:00002"}`
+	err = DeserializeDestruction([]byte(correctJson), cert)
+	assert.Nil(t, err, "should not return error for deserialize")
+
+	expectedCert := new(DestructionCertificate)
+	expectedCert.CertificateID = "tx1"
+	expectedCert.MedName = "vicodin"
+	expectedCert.MedNumber = "00002"
+	expectedCert.CheckSum = "checksum"
+	expectedCert.Reason = "recalled"
+	expectedCert.Method = "incineration"
+	expectedCert.Witnesses = []string{"carol"}
+	expectedCert.DestructionDate = "2022.02.22"
+	expectedCert.DocumentHash = "ab12"
+	expectedCert.RecordedBy = "bob"
+	expectedCert.RecordedAt = "2022-02-22T10:00:00Z"
+	assert.Equal(t, expectedCert, cert, "should create expected certificate of destruction")
+
+	incorrectJson := `{"certificateID":"tx1","witnesses":"carol"}`
+	cert = new(DestructionCertificate)
+	err = DeserializeDestruction([]byte(incorrectJson), cert)
+	assert.EqualError(t, err, "error deserializing certificate of destruction. json: cannot unmarshal string into Go struct field jsonDestructionCertificate.witnesses of type []string", "should return error for bad data")
+}
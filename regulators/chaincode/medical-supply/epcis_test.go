@@ -0,0 +1,124 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func version(medicine MedicalSupply, state State) *MedicalSupply {
+	medicine.state = state
+	return &medicine
+}
+
+func TestEPC(t *testing.T) {
+	medicine := &MedicalSupply{MedName: "aspirin", MedNumber: "00001"}
+	assert.Equal(t, "urn:medstore:medicine:aspirin:00001", EPC(medicine), "should use medstore URN without GTIN.")
+
+	medicine.GTIN = "09506000134352"
+	medicine.SerialNumber = "00001"
+	assert.Equal(t, "https://id.gs1.org/01/09506000134352/21/00001", EPC(medicine), "should use GS1 Digital Link with GTIN.")
+}
+
+func TestEPCISEvents(t *testing.T) {
+	medicine := MedicalSupply{MedName: "aspirin", MedNumber: "00001", Expiration: "2024.05.31", Holder: "MedStore", LotNumber: "ABC123"}
+	requested := medicine
+	requested.Holder = "alice"
+	start := time.Date(2022, 2, 22, 10, 0, 0, 0, time.UTC)
+
+	records := []*MedicineRecord{
+		{TxID: "tx1", Timestamp: start, Medicine: version(medicine, AVAILABLE)},
+		{TxID: "tx2", Timestamp: start.Add(time.Hour), Medicine: version(requested, REQUESTED)},
+		{TxID: "tx3", Timestamp: start.Add(2 * time.Hour), Medicine: version(requested, SEND)},
+		{TxID: "tx4", Timestamp: start.Add(3 * time.Hour), Medicine: version(requested, RETURNED)},
+		{TxID: "tx5", Timestamp: start.Add(4 * time.Hour), Medicine: version(medicine, QUARANTINED)},
+		{TxID: "tx6", Timestamp: start.Add(5 * time.Hour), Medicine: version(medicine, DESTROYED)},
+	}
+	events := EPCISEvents(records)
+
+	var steps []string
+	for _, event := range events {
+		steps = append(steps, event.Type+" "+event.Action+" "+event.BizStep+" "+event.Disposition)
+	}
+	assert.Equal(t, []string{
+		"ObjectEvent ADD commissioning active",
+		"TransactionEvent ADD reserving reserved",
+		"TransactionEvent OBSERVE accepting reserved",
+		"ObjectEvent OBSERVE shipping in_transit",
+		"ObjectEvent OBSERVE shipping returned",
+		"ObjectEvent OBSERVE holding non_sellable_other",
+		"ObjectEvent DELETE destroying destroyed",
+	}, steps, "should convert issue, request, approval, shipping, return, quarantine and destruction into events")
+
+	assert.Equal(t, map[string]string{"cbvmda:itemExpirationDate": "2024-05-31", "cbvmda:lotNumber": "ABC123"}, events[0].Ilmd, "should add expiry and lot on commissioning")
+	assert.Equal(t, []BizTransaction{{Type: "po", BizTransaction: "urn:medstore:request:tx2"}}, events[2].BizTransactionList, "should refer approval to the request")
+	assert.Equal(t, []EPCISDestination{{Type: "owning_party", Destination: "urn:medstore:party:alice"}}, events[3].DestinationList, "should ship to the customer")
+	assert.Equal(t, "2022-02-22T12:00:00Z", events[3].EventTime, "should use the transaction time")
+}
+
+func TestEPCISEventsScheduled(t *testing.T) {
+	medicine := MedicalSupply{MedName: "vicodin", MedNumber: "00002", Holder: "alice", Schedule: "II"}
+	start := time.Date(2022, 2, 22, 10, 0, 0, 0, time.UTC)
+
+	records := []*MedicineRecord{
+		{TxID: "tx1", Timestamp: start, Medicine: version(medicine, AVAILABLE)},
+		{TxID: "tx2", Timestamp: start.Add(time.Hour), Medicine: version(medicine, REQUESTED)},
+		{TxID: "tx3", Timestamp: start.Add(2 * time.Hour), Medicine: version(medicine, PENDING_SECOND_APPROVAL)},
+		{TxID: "tx4", Timestamp: start.Add(3 * time.Hour), Medicine: version(medicine, SEND)},
+	}
+	events := EPCISEvents(records)
+
+	var steps []string
+	for _, event := range events {
+		steps = append(steps, event.BizStep)
+	}
+	assert.Equal(t, []string{"commissioning", "reserving", "accepting", "shipping"}, steps, "should accept scheduled medicine once")
+	assert.Equal(t, "urn:medstore:event:tx4:vicodin:00002:accepting", events[2].EventID, "should accept on the second approval")
+}
+
+func TestEPCISEventsReleasedAndDeleted(t *testing.T) {
+	medicine := MedicalSupply{MedName: "aspirin", MedNumber: "00001", OrderID: "ORD0001"}
+	start := time.Date(2022, 2, 22, 10, 0, 0, 0, time.UTC)
+
+	records := []*MedicineRecord{
+		{TxID: "tx1", Timestamp: start, Medicine: version(medicine, AVAILABLE)},
+		{TxID: "tx2", Timestamp: start.Add(time.Hour), Medicine: version(medicine, REQUESTED)},
+		{TxID: "tx3", Timestamp: start.Add(2 * time.Hour), Medicine: version(medicine, AVAILABLE)},
+		{TxID: "tx4", Timestamp: start.Add(3 * time.Hour), Medicine: version(medicine, AVAILABLE)},
+		{TxID: "tx5", Timestamp: start.Add(4 * time.Hour), IsDelete: true},
+	}
+	events := EPCISEvents(records)
+
+	assert.Len(t, events, 4, "should skip versions without change of state")
+	assert.Equal(t, []BizTransaction{{Type: "po", BizTransaction: "urn:medstore:order:ORD0001"}}, events[1].BizTransactionList, "should refer to the order")
+	assert.Equal(t, "DELETE", events[2].Action, "should release the reservation")
+	assert.Equal(t, "decommissioning", events[3].BizStep, "should decommission deleted medicine")
+	assert.Equal(t, []string{"urn:medstore:medicine:aspirin:00001"}, events[3].EPCList, "should use the last version for deletions")
+}
+
+func TestEPCISDocument(t *testing.T) {
+	medicine := MedicalSupply{MedName: "aspirin", MedNumber: "00001", Expiration: "2024.05.31"}
+	start := time.Date(2022, 2, 22, 10, 0, 0, 0, time.UTC)
+	first := EPCISEvents([]*MedicineRecord{{TxID: "tx2", Timestamp: start.Add(time.Hour), Medicine: version(medicine, AVAILABLE)}})
+	second := EPCISEvents([]*MedicineRecord{{TxID: "tx1", Timestamp: start, Medicine: version(medicine, AVAILABLE)}})
+
+	assert.True(t, first[0].InWindow(start, time.Time{}), "should be within a window open at the end")
+	assert.False(t, first[0].InWindow(time.Time{}, start), "should not be within a window ending before the event")
+
+	document := NewEPCISDocument(append(first, second...), start)
+	assert.Equal(t, "urn:medstore:event:tx1:aspirin:00001:commissioning", document.EPCISBody.EventList[0].EventID, "should order events by time")
+
+	bytes, err := json.Marshal(document)
+	assert.Nil(t, err, "should not error on marshal")
+	var raw map[string]interface{}
+	assert.Nil(t, json.Unmarshal(bytes, &raw), "should be valid JSON")
+	assert.Equal(t, []interface{}{EPCISContext}, raw["@context"], "should carry the JSON-LD context")
+	assert.Equal(t, "EPCISDocument", raw["type"], "should be an EPCIS document")
+	assert.Equal(t, "2.0", raw["schemaVersion"], "should be EPCIS 2.0")
+
+	empty, err := json.Marshal(NewEPCISDocument(nil, start))
+	assert.Nil(t, err, "should not error on marshal")
+	assert.Contains(t, string(empty), `"eventList":[]`, "should have an empty event list without events")
+}
@@ -0,0 +1,106 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+)
+
+// ErrorCode - Stable, machine-readable reason of a failed transaction, clients branch on it instead of the message.
+type ErrorCode string
+
+// Error codes returned by the transactions.
+const (
+	CodeInvalidArgument      ErrorCode = "INVALID_ARGUMENT"
+	CodeUnauthenticated      ErrorCode = "UNAUTHENTICATED"
+	CodeUnauthorizedOrg      ErrorCode = "UNAUTHORIZED_ORG"
+	CodeMissingRole          ErrorCode = "MISSING_ROLE"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeAlreadyExists        ErrorCode = "ALREADY_EXISTS"
+	CodeAlreadyRequested     ErrorCode = "ALREADY_REQUESTED"
+	CodeNotAvailable         ErrorCode = "NOT_AVAILABLE"
+	CodeInvalidState         ErrorCode = "INVALID_STATE"
+	CodeChecksumMismatch     ErrorCode = "CHECKSUM_MISMATCH"
+	CodeLotMismatch          ErrorCode = "LOT_MISMATCH"
+	CodePrescriptionRequired ErrorCode = "PRESCRIPTION_REQUIRED"
+	CodeQuotaExceeded        ErrorCode = "QUOTA_EXCEEDED"
+	CodeInsufficientStock    ErrorCode = "INSUFFICIENT_STOCK"
+	CodeSecondApproval       ErrorCode = "SECOND_APPROVAL_REQUIRED"
+	CodeLedger               ErrorCode = "LEDGER_ERROR"
+	CodeInternal             ErrorCode = "INTERNAL"
+)
+
+// ContractError - Defines the error of a failed transaction. It is serialized as JSON into the chaincode error
+// payload, details hold the values the error is about and fields the invalid arguments.
+type ContractError struct {
+	Code    ErrorCode         `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+	Fields  []*FieldError     `json:"fields,omitempty"`
+}
+
+// Error - Returns the error as JSON, which is the message Fabric passes on to the client.
+func (ce *ContractError) Error() string {
+	data, err := json.Marshal(ce)
+	if err != nil {
+		return ce.Message
+	}
+	return string(data)
+}
+
+// with - Adds a detail to the error.
+func (ce *ContractError) with(key string, value string) *ContractError {
+	if ce.Details == nil {
+		ce.Details = make(map[string]string)
+	}
+	ce.Details[key] = value
+	return ce
+}
+
+// withMedicine - Adds the name, number and state of the medicine the error is about.
+func (ce *ContractError) withMedicine(medicine *MedicalSupply) *ContractError {
+	return ce.with("medName", medicine.MedName).with("medNumber", medicine.MedNumber).with("state", medicine.GetState().String())
+}
+
+// newError - Creates an error with the code and a formatted message.
+func newError(code ErrorCode, format string, args ...interface{}) *ContractError {
+	return &ContractError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// wrapError - Creates an error with the code, whose message is the formatted message followed by the cause.
+// Errors which already have a code keep it.
+func wrapError(code ErrorCode, err error, format string, args ...interface{}) *ContractError {
+	var ce *ContractError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	return newError(code, "%s: %s", fmt.Sprintf(format, args...), err)
+}
+
+// ledgerError - Creates the error of a failed read or write, which is NOT_FOUND if the state does not exist.
+func ledgerError(err error, format string, args ...interface{}) *ContractError {
+	if errors.Is(err, ledgerapi.ErrStateNotFound) {
+		return wrapError(CodeNotFound, err, format, args...)
+	}
+	return wrapError(CodeLedger, err, format, args...)
+}
+
+// verifyChecksum - Verifies the checksum of the medicine, a mismatch means it has been tampered with.
+func verifyChecksum(medicine *MedicalSupply) error {
+	err := medicine.VerifyChecksum()
+	if err != nil {
+		return newError(CodeChecksumMismatch, "%s", err).withMedicine(medicine)
+	}
+	return nil
+}
+
+// ErrorCodeOf - Returns the code of the error, INTERNAL for errors without code.
+func ErrorCodeOf(err error) ErrorCode {
+	var ce *ContractError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	return CodeInternal
+}
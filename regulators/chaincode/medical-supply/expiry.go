@@ -0,0 +1,72 @@
+package medicalsupply
+
+import (
+	"time"
+)
+
+// ExpiringMedicine - Defines a single available medicine which expires soon, DaysLeft is negative for expired medicine.
+type ExpiringMedicine struct {
+	MedNumber  string `json:"medNumber"`
+	Expiration string `json:"expiration"`
+	DaysLeft   int    `json:"daysLeft"`
+}
+
+// ExpiryGroup - Defines the available medicine of a single name which expires soon, sorted by expiry.
+type ExpiryGroup struct {
+	MedName        string              `json:"medName"`
+	Count          int                 `json:"count"`
+	EarliestExpiry string              `json:"earliestExpiry"`
+	Medicines      []*ExpiringMedicine `json:"medicines"`
+}
+
+// ExpiryAlert - Defines an alert raised when the amount of stock expiring soon crosses a threshold.
+type ExpiryAlert struct {
+	MedName        string `json:"medName"`
+	Count          int    `json:"count"`
+	Threshold      int    `json:"threshold"`
+	Days           int    `json:"days"`
+	EarliestExpiry string `json:"earliestExpiry"`
+}
+
+// NewExpiryForecast - Groups the available medicine expiring within days of now by name,
+// groups are sorted by their earliest expiry and medicine within a group by expiry.
+func NewExpiryForecast(medicines []*MedicalSupply, now time.Time, days int) []*ExpiryGroup {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	until := today.AddDate(0, 0, days)
+
+	groups := make(map[string]*ExpiryGroup)
+	for _, ms := range medicines {
+		if !ms.IsAvailable() {
+			continue
+		}
+		expiration, err := time.Parse(DateLayout, ms.Expiration)
+		if err != nil || expiration.After(until) {
+			continue
+		}
+
+		if groups[ms.MedName] == nil {
+			groups[ms.MedName] = &ExpiryGroup{MedName: ms.MedName}
+		}
+		group := groups[ms.MedName]
+		group.Count++
+		group.Medicines = append(group.Medicines, &ExpiringMedicine{
+			MedNumber:  ms.MedNumber,
+			Expiration: ms.Expiration,
+			DaysLeft:   int(expiration.Sub(today).Hours() / 24),
+		})
+	}
+
+	// Dates in the ledger layout sort chronologically as strings.
+	forecast := []*ExpiryGroup{}
+	for _, group := range groups {
+		sortResults(group.Medicines,
+			ascending(func(medicine *ExpiringMedicine) string { return medicine.Expiration }),
+			ascending(func(medicine *ExpiringMedicine) string { return medicine.MedNumber }))
+		group.EarliestExpiry = group.Medicines[0].Expiration
+		forecast = append(forecast, group)
+	}
+	sortResults(forecast,
+		ascending(func(group *ExpiryGroup) string { return group.EarliestExpiry }),
+		ascending(func(group *ExpiryGroup) string { return group.MedName }))
+	return forecast
+}
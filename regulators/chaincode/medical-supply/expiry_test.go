@@ -0,0 +1,38 @@
+package medicalsupply
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExpiryForecast(t *testing.T) {
+	medicine := func(name string, number string, expiration string, state State) *MedicalSupply {
+		ms := &MedicalSupply{MedName: name, MedNumber: number, Expiration: expiration}
+		ms.state = state
+		return ms
+	}
+	medicines := []*MedicalSupply{
+		medicine("aspirin", "00003", "2022.03.10", AVAILABLE),
+		medicine("aspirin", "00001", "2022.03.01", AVAILABLE),
+		medicine("vicodin", "00002", "2022.02.20", AVAILABLE),
+		medicine("vicodin", "00004", "2022.02.21", SEND),
+		medicine("lipitor", "00005", "2022.06.01", AVAILABLE),
+		medicine("zofran", "00006", "invalid", AVAILABLE),
+	}
+
+	forecast := NewExpiryForecast(medicines, time.Date(2022, 2, 21, 15, 0, 0, 0, time.UTC), 30)
+	assert.Equal(t, []*ExpiryGroup{
+		{MedName: "vicodin", Count: 1, EarliestExpiry: "2022.02.20", Medicines: []*ExpiringMedicine{
+			{MedNumber: "00002", Expiration: "2022.02.20", DaysLeft: -1},
+		}},
+		{MedName: "aspirin", Count: 2, EarliestExpiry: "2022.03.01", Medicines: []*ExpiringMedicine{
+			{MedNumber: "00001", Expiration: "2022.03.01", DaysLeft: 8},
+			{MedNumber: "00003", Expiration: "2022.03.10", DaysLeft: 17},
+		}},
+	}, forecast, "should group available medicine expiring within the window by name and sort by expiry")
+
+	assert.Len(t, NewExpiryForecast(medicines, time.Date(2022, 2, 21, 15, 0, 0, 0, time.UTC), 0), 1, "should only include expired medicine without days")
+	assert.Empty(t, NewExpiryForecast(nil, time.Now(), 30), "should be empty without medicine")
+}
@@ -0,0 +1,192 @@
+package medicalsupply
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GS - The ASCII group separator scanners send for the FNC1 separator between variable length element strings.
+const GS = "\x1d"
+
+// GS1Data - Defines the element strings read from a GS1 DataMatrix on a medicine pack.
+type GS1Data struct {
+	GTIN       string `json:"gtin"`
+	Serial     string `json:"serial"`
+	Lot        string `json:"lot"`
+	Expiration string `json:"expiration"`
+}
+
+// gs1Lengths - Fixed lengths of the supported application identifiers, 0 means variable length (at most 20 characters).
+var gs1Lengths = map[string]int{
+	"01": 14, // GTIN
+	"17": 6,  // Expiration date (YYMMDD)
+	"10": 0,  // Batch or lot number
+	"21": 0,  // Serial number
+}
+
+// ValidGTIN - Returns true if the GTIN has a valid length and check digit.
+func ValidGTIN(gtin string) bool {
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	if strings.Trim(gtin, "0123456789") != "" {
+		return false
+	}
+
+	// Weights alternate 3 and 1 starting from the digit left of the check digit.
+	sum := 0
+	for i := len(gtin) - 2; i >= 0; i-- {
+		digit := int(gtin[i] - '0')
+		if (len(gtin)-2-i)%2 == 0 {
+			sum += 3 * digit
+		} else {
+			sum += digit
+		}
+	}
+	check := int(gtin[len(gtin)-1] - '0')
+	return check == (10-sum%10)%10
+}
+
+// ParseGS1 - Parses the element strings AI 01, 21, 10 and 17 of a scanned GS1 DataMatrix.
+// Accepts raw scanner input with FNC1 (GS) separators and an optional symbology identifier (e.g. ]d2),
+// as well as the human readable form with application identifiers in brackets (e.g. (01)...(21)...).
+func ParseGS1(raw string) (*GS1Data, error) {
+	raw = strings.TrimSpace(raw)
+	for _, symbology := range []string{"]d2", "]C1", "]Q3", "]e0"} {
+		raw = strings.TrimPrefix(raw, symbology)
+	}
+	raw = strings.TrimPrefix(raw, GS)
+
+	elements := make(map[string]string)
+	var err error
+	if strings.HasPrefix(raw, "(") {
+		err = parseBracketed(raw, elements)
+	} else {
+		err = parseRaw(raw, elements)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &GS1Data{GTIN: elements["01"], Serial: elements["21"], Lot: elements["10"]}
+	if data.GTIN == "" || data.Serial == "" {
+		return nil, fmt.Errorf("GS1 element string should contain at least a GTIN (01) and serial number (21)")
+	}
+	if !ValidGTIN(data.GTIN) {
+		return nil, fmt.Errorf("GTIN %s has an invalid check digit", data.GTIN)
+	}
+	if expiration, ok := elements["17"]; ok {
+		data.Expiration, err = gs1Date(expiration)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// parseRaw - Parses concatenated element strings separated by FNC1 after variable length values.
+func parseRaw(raw string, elements map[string]string) error {
+	for len(raw) > 0 {
+		if len(raw) < 2 {
+			return fmt.Errorf("incomplete application identifier %q", raw)
+		}
+		ai := raw[:2]
+		length, ok := gs1Lengths[ai]
+		if !ok {
+			return fmt.Errorf("unsupported application identifier %s", ai)
+		}
+		raw = raw[2:]
+
+		var value string
+		if length > 0 {
+			if len(raw) < length {
+				return fmt.Errorf("value of application identifier %s should be %d characters", ai, length)
+			}
+			value, raw = raw[:length], raw[length:]
+		} else if end := strings.Index(raw, GS); end >= 0 {
+			value, raw = raw[:end], raw[end:]
+		} else {
+			value, raw = raw, ""
+		}
+		// A separator may follow any element string.
+		raw = strings.TrimPrefix(raw, GS)
+
+		err := addElement(ai, value, elements)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseBracketed - Parses element strings in the human readable form, e.g. (01)09506000134352(21)12345.
+func parseBracketed(raw string, elements map[string]string) error {
+	for len(raw) > 0 {
+		end := strings.Index(raw, ")")
+		if !strings.HasPrefix(raw, "(") || end < 0 {
+			return fmt.Errorf("malformed GS1 element string %q", raw)
+		}
+		ai := raw[1:end]
+		length, ok := gs1Lengths[ai]
+		if !ok {
+			return fmt.Errorf("unsupported application identifier %s", ai)
+		}
+		raw = raw[end+1:]
+
+		next := strings.Index(raw, "(")
+		if next < 0 {
+			next = len(raw)
+		}
+		value := raw[:next]
+		raw = raw[next:]
+		if length > 0 && len(value) != length {
+			return fmt.Errorf("value of application identifier %s should be %d characters", ai, length)
+		}
+
+		err := addElement(ai, value, elements)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addElement - Validates and stores the value of a single element string.
+func addElement(ai string, value string, elements map[string]string) error {
+	if _, exists := elements[ai]; exists {
+		return fmt.Errorf("application identifier %s occurs more than once", ai)
+	}
+	if value == "" || len(value) > 20 {
+		return fmt.Errorf("value of application identifier %s should be between 1 and 20 characters", ai)
+	}
+	elements[ai] = value
+	return nil
+}
+
+// gs1Date - Converts a GS1 YYMMDD date to the ledger date layout, a day of 00 means the last day of the month.
+// Years are interpreted as 20YY.
+func gs1Date(value string) (string, error) {
+	if len(value) != 6 || strings.Trim(value, "0123456789") != "" {
+		return "", fmt.Errorf("invalid GS1 date %s, expected YYMMDD", value)
+	}
+	year, _ := strconv.Atoi(value[:2])
+	month, _ := strconv.Atoi(value[2:4])
+	day, _ := strconv.Atoi(value[4:])
+	if month < 1 || month > 12 {
+		return "", fmt.Errorf("invalid GS1 date %s, expected YYMMDD", value)
+	}
+
+	// Day 0 of the next month normalises to the last day of this month.
+	if day == 0 {
+		return time.Date(2000+year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Format(DateLayout), nil
+	}
+	date := time.Date(2000+year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return "", fmt.Errorf("invalid GS1 date %s, expected YYMMDD", value)
+	}
+	return date.Format(DateLayout), nil
+}
@@ -16,18 +16,48 @@ const (
 	REQUESTED
 	// SEND state for when a medicine is send.
 	SEND
+	// RETURNED state for when a customer has sent a medicine back and it awaits inspection.
+	RETURNED
+	// DESTROYED state for when a medicine has been destroyed.
+	DESTROYED
+	// QUARANTINED state for when a medicine has been taken out of stock awaiting destruction (e.g. recalled, damaged or expired).
+	QUARANTINED
+	// PENDING_SECOND_APPROVAL state for when a requested scheduled medicine has been approved by one regulator.
+	PENDING_SECOND_APPROVAL
 )
 
 // String - Changes state enum to string.
 func (state State) String() string {
-	names := []string{"AVAILABLE", "REQUESTED", "SEND"}
+	names := []string{"AVAILABLE", "REQUESTED", "SEND", "RETURNED", "DESTROYED", "QUARANTINED", "PENDING_SECOND_APPROVAL"}
 
-	if state < AVAILABLE || state > SEND {
+	if state < AVAILABLE || state > PENDING_SECOND_APPROVAL {
 		return "UNKNOWN"
 	}
 	return names[state-1]
 }
 
+// Schedules - Drug schedules of controlled substances, an empty schedule means the medicine is not controlled.
+var Schedules = []string{"I", "II", "III", "IV", "V"}
+
+// IsSchedule - Returns true if the schedule is empty or one of the known drug schedules.
+func IsSchedule(schedule string) bool {
+	if schedule == "" {
+		return true
+	}
+	for _, known := range Schedules {
+		if schedule == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DateLayout - Layout of the dates stored on the ledger (e.g. 2022.05.09).
+const DateLayout = "2006.01.02"
+
+// MedicineClass - Class of the medical supply states.
+const MedicineClass = "org.medstore.medicalsupply"
+
 // CreateMedicalKey - Creates a key for the medical supply (e.g. MedStore:Aspirin:00001).
 func CreateMedicalKey(medName string, medNumber string) string {
 	return ledgerapi.MakeKey("MedStore", medName, medNumber)
@@ -37,30 +67,48 @@ func CreateMedicalKey(medName string, medNumber string) string {
 type medicalSupplyAlias MedicalSupply
 type jsonMedicalSupply struct {
 	*medicalSupplyAlias
-	State State  `json:"currentState"`
-	Class string `json:"class"`
-	Key   string `json:"key"`
+	State         State  `json:"currentState"`
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
 }
 
 // MedicalSupply - Defines a medicine.
+// RxOnly marks a prescription-only medicine, PrescriptionID refers to the prescription used for requesting it
+// and OrderID to the order it has been reserved for. QuarantineNote holds why a medicine has been quarantined.
+// RequestDate holds the timestamp of the transaction in which the current holder requested the medicine.
+// Schedule holds the drug schedule of a controlled substance, which needs the approval of two regulators,
+// FirstApprover holds the identity of the regulator that gave the first approval.
+// GTIN, SerialNumber and LotNumber hold the GS1 identifiers of a pack issued from its DataMatrix.
 type MedicalSupply struct {
-	CheckSum   string `json:"checkSum"`
-	MedName    string `json:"medName"`
-	MedNumber  string `json:"medNumber"`
-	Disease    string `json:"disease"`
-	Expiration string `json:"expiration"`
-	Price      string `json:"price"`
-	Holder     string `json:"holder"`
-	state      State  `metadata:"currentState"`
-	class      string `metadata:"class"`
-	key        string `metadata:"key"`
+	CheckSum       string `json:"checkSum"`
+	MedName        string `json:"medName"`
+	MedNumber      string `json:"medNumber"`
+	Disease        string `json:"disease"`
+	Expiration     string `json:"expiration"`
+	Price          string `json:"price"`
+	Holder         string `json:"holder"`
+	RxOnly         bool   `json:"rxOnly,omitempty" metadata:",optional"`
+	Schedule       string `json:"schedule,omitempty" metadata:",optional"`
+	FirstApprover  string `json:"firstApprover,omitempty" metadata:",optional"`
+	PrescriptionID string `json:"prescriptionID,omitempty" metadata:",optional"`
+	OrderID        string `json:"orderID,omitempty" metadata:",optional"`
+	QuarantineNote string `json:"quarantineNote,omitempty" metadata:",optional"`
+	RequestDate    string `json:"requestDate,omitempty" metadata:",optional"`
+	GTIN           string `json:"gtin,omitempty" metadata:",optional"`
+	SerialNumber   string `json:"serialNumber,omitempty" metadata:",optional"`
+	LotNumber      string `json:"lotNumber,omitempty" metadata:",optional"`
+	state          State  `metadata:"currentState"`
+	class          string `metadata:"class"`
+	key            string `metadata:"key"`
+	schemaVersion  int    `metadata:"schemaVersion"`
 }
 
 //-------------------------------------------------------//
 
 // MarshalJSON - Special handler for managing JSON marshalling.
 func (ms MedicalSupply) MarshalJSON() ([]byte, error) {
-	jms := jsonMedicalSupply{medicalSupplyAlias: (*medicalSupplyAlias)(&ms), State: ms.state, Class: "org.medstore.medicalsupply", Key: CreateMedicalKey(ms.MedName, ms.MedNumber)}
+	jms := jsonMedicalSupply{medicalSupplyAlias: (*medicalSupplyAlias)(&ms), State: ms.state, Class: MedicineClass, Key: CreateMedicalKey(ms.MedName, ms.MedNumber), SchemaVersion: schemaVersion(MedicineClass)}
 
 	return json.Marshal(&jms)
 }
@@ -100,6 +148,26 @@ func (ms *MedicalSupply) SetSend() {
 	ms.state = SEND
 }
 
+// SetReturned - Returns the state to RETURNED.
+func (ms *MedicalSupply) SetReturned() {
+	ms.state = RETURNED
+}
+
+// SetDestroyed - Returns the state to DESTROYED.
+func (ms *MedicalSupply) SetDestroyed() {
+	ms.state = DESTROYED
+}
+
+// SetQuarantined - Returns the state to QUARANTINED.
+func (ms *MedicalSupply) SetQuarantined() {
+	ms.state = QUARANTINED
+}
+
+// SetPendingSecondApproval - Returns the state to PENDING_SECOND_APPROVAL.
+func (ms *MedicalSupply) SetPendingSecondApproval() {
+	ms.state = PENDING_SECOND_APPROVAL
+}
+
 // IsAvailable - Returns true if state is AVAILABLE.
 func (ms *MedicalSupply) IsAvailable() bool {
 	return ms.state == AVAILABLE
@@ -115,6 +183,31 @@ func (ms *MedicalSupply) IsSend() bool {
 	return ms.state == SEND
 }
 
+// IsReturned - Returns true if state is RETURNED.
+func (ms *MedicalSupply) IsReturned() bool {
+	return ms.state == RETURNED
+}
+
+// IsDestroyed - Returns true if state is DESTROYED.
+func (ms *MedicalSupply) IsDestroyed() bool {
+	return ms.state == DESTROYED
+}
+
+// IsQuarantined - Returns true if state is QUARANTINED.
+func (ms *MedicalSupply) IsQuarantined() bool {
+	return ms.state == QUARANTINED
+}
+
+// IsPendingSecondApproval - Returns true if state is PENDING_SECOND_APPROVAL.
+func (ms *MedicalSupply) IsPendingSecondApproval() bool {
+	return ms.state == PENDING_SECOND_APPROVAL
+}
+
+// IsScheduled - Returns true if the medicine is a scheduled (controlled) substance.
+func (ms *MedicalSupply) IsScheduled() bool {
+	return ms.Schedule != ""
+}
+
 //-------------------------------------------------------//
 
 // GetSplitKey - Returns values which should be used to form key.
@@ -122,10 +215,25 @@ func (ms *MedicalSupply) GetSplitKey() []string {
 	return []string{"MedStore", ms.MedName, ms.MedNumber}
 }
 
+// checkSumString - Returns the fields covered by the checksum as a single string.
+// The prescription-only marker and schedule are only appended when set so checksums of existing medicine stay valid.
+func (ms *MedicalSupply) checkSumString() string {
+	checkSumStr := fmt.Sprintf("%s%s%s%s%s", ms.MedName, ms.MedNumber, ms.Disease, ms.Expiration, ms.Price)
+	if ms.RxOnly {
+		checkSumStr += "rxonly"
+	}
+	if ms.Schedule != "" {
+		checkSumStr += "schedule" + ms.Schedule
+	}
+	if ms.GTIN != "" {
+		checkSumStr += "gtin" + ms.GTIN + "serial" + ms.SerialNumber + "lot" + ms.LotNumber
+	}
+	return checkSumStr
+}
+
 // InitialiseChecksum - Initialise the checksum value of the MedicalSupply using tpm hashing.
 func (ms *MedicalSupply) InitialiseChecksum() error {
-	checkSumStr := fmt.Sprintf("%s%s%s%s%s", ms.MedName, ms.MedNumber, ms.Disease, ms.Expiration, ms.Price)
-	checksum, tpmError := tpmHash(checkSumStr)
+	checksum, tpmError := tpmHash(ms.checkSumString())
 
 	if tpmError != nil {
 		return fmt.Errorf("tpm error occurred: %s", tpmError)
@@ -136,8 +244,7 @@ func (ms *MedicalSupply) InitialiseChecksum() error {
 
 // VerifyChecksum - Returns true if the checksum stored on the Medicine object still is the same as after recalculating the checksum.
 func (ms *MedicalSupply) VerifyChecksum() error {
-	checkSumStr := fmt.Sprintf("%s%s%s%s%s", ms.MedName, ms.MedNumber, ms.Disease, ms.Expiration, ms.Price)
-	checksum, tpmError := tpmHash(checkSumStr)
+	checksum, tpmError := tpmHash(ms.checkSumString())
 
 	if tpmError != nil {
 		return fmt.Errorf("tpm error occurred: %s", tpmError)
@@ -155,7 +262,11 @@ func (ms *MedicalSupply) Serialize() ([]byte, error) {
 
 // Deserialize - Formats the commercial paper from JSON bytes.
 func DeserializeJSON(bytes []byte, ms *MedicalSupply) error {
-	err := json.Unmarshal(bytes, ms)
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(MedicineClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, ms)
+	}
 
 	if err != nil {
 		return fmt.Errorf("Error deserializing medical supply. %s", err.Error())
@@ -11,7 +11,11 @@ func TestString(t *testing.T) {
 	assert.Equal(t, "AVAILABLE", AVAILABLE.String(), "should return string for available.")
 	assert.Equal(t, "REQUESTED", REQUESTED.String(), "should return string for requested.")
 	assert.Equal(t, "SEND", SEND.String(), "should return string for send.")
-	assert.Equal(t, "UNKNOWN", State(SEND+1).String(), "should return unknown when not one of constants.")
+	assert.Equal(t, "RETURNED", RETURNED.String(), "should return string for returned.")
+	assert.Equal(t, "DESTROYED", DESTROYED.String(), "should return string for destroyed.")
+	assert.Equal(t, "QUARANTINED", QUARANTINED.String(), "should return string for quarantined.")
+	assert.Equal(t, "PENDING_SECOND_APPROVAL", PENDING_SECOND_APPROVAL.String(), "should return string for pending second approval.")
+	assert.Equal(t, "UNKNOWN", State(PENDING_SECOND_APPROVAL+1).String(), "should return unknown when not one of constants.")
 }
 
 func TestCreateMedicalKey(t *testing.T) {
@@ -73,6 +77,57 @@ func TestIsSend(t *testing.T) {
 	assert.False(t, medicine.IsSend(), "should be false when status not set to send.")
 }
 
+func TestIsReturned(t *testing.T) {
+	medicine := new(MedicalSupply)
+
+	medicine.SetReturned()
+	assert.True(t, medicine.IsReturned(), "should be true when status set to returned.")
+
+	medicine.SetSend()
+	assert.False(t, medicine.IsReturned(), "should be false when status not set to returned.")
+}
+
+func TestIsDestroyed(t *testing.T) {
+	medicine := new(MedicalSupply)
+
+	medicine.SetDestroyed()
+	assert.True(t, medicine.IsDestroyed(), "should be true when status set to destroyed.")
+
+	medicine.SetReturned()
+	assert.False(t, medicine.IsDestroyed(), "should be false when status not set to destroyed.")
+}
+
+func TestIsQuarantined(t *testing.T) {
+	medicine := new(MedicalSupply)
+
+	medicine.SetQuarantined()
+	assert.True(t, medicine.IsQuarantined(), "should be true when status set to quarantined.")
+
+	medicine.SetDestroyed()
+	assert.False(t, medicine.IsQuarantined(), "should be false when status not set to quarantined.")
+}
+
+func TestIsPendingSecondApproval(t *testing.T) {
+	medicine := new(MedicalSupply)
+
+	medicine.SetPendingSecondApproval()
+	assert.True(t, medicine.IsPendingSecondApproval(), "should be true when status set to pending second approval.")
+
+	medicine.SetRequested()
+	assert.False(t, medicine.IsPendingSecondApproval(), "should be false when status not set to pending second approval.")
+}
+
+func TestIsSchedule(t *testing.T) {
+	assert.True(t, IsSchedule(""), "should accept medicine which is not controlled.")
+	assert.True(t, IsSchedule("II"), "should accept known schedules.")
+	assert.False(t, IsSchedule("VI"), "should reject unknown schedules.")
+
+	medicine := new(MedicalSupply)
+	assert.False(t, medicine.IsScheduled(), "should be false without schedule.")
+	medicine.Schedule = "II"
+	assert.True(t, medicine.IsScheduled(), "should be true with schedule.")
+}
+
 func TestGetSplitKey(t *testing.T) {
 	medicine := new(MedicalSupply)
 	medicine.MedName = "medicinename"
@@ -92,13 +147,32 @@ func TestSerialize(t *testing.T) {
 	medicine.Holder = "alice"
 	medicine.SetAvailable()
 
-	correctJson := `{"checkSum":"","medName":"aspirin","medNumber":"00001","disease":"pain","expiration":"2022.02.22","price":"$10","holder":"alice","currentState":1,"class":"org.medstore.medicalsupply","key":"MedStore:aspirin:00001"}`
+	correctJson := `{"checkSum":"","medName":"aspirin","medNumber":"00001","disease":"pain","expiration":"2022.02.22","price":"$10","holder":"alice","currentState":1,"class":"org.medstore.medicalsupply","key":"MedStore:aspirin:00001","schemaVersion":2}`
 
 	bytes, err := medicine.Serialize()
 	assert.Nil(t, err, "should not error on serialize")
 	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
 }
 
+func TestChecksumRxOnly(t *testing.T) {
+	medicine := new(MedicalSupply)
+	medicine.MedName = "vicodin"
+	medicine.MedNumber = "00002"
+	assert.Nil(t, medicine.InitialiseChecksum(), "should not error on checksum")
+	assert.Nil(t, medicine.VerifyChecksum(), "should verify its own checksum")
+
+	medicine.RxOnly = true
+	assert.NotNil(t, medicine.VerifyChecksum(), "should fail checksum when prescription-only marker is changed")
+
+	medicine.RxOnly = false
+	medicine.Schedule = "II"
+	assert.NotNil(t, medicine.VerifyChecksum(), "should fail checksum when schedule is changed")
+
+	medicine.Schedule = ""
+	medicine.GTIN = "09506000134352"
+	assert.NotNil(t, medicine.VerifyChecksum(), "should fail checksum when GTIN is changed")
+}
+
 func TestDeserialize(t *testing.T) {
 	var medicine *MedicalSupply
 	var err error
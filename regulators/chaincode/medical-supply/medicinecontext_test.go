@@ -14,14 +14,14 @@ func TestGetMedicineList(t *testing.T) {
 	tc = new(TransactionContext)
 	expectedMedicineList = newList(tc)
 	actualList := tc.GetMedicineList().(*list)
-	assert.Equal(t, expectedMedicineList.statelist.(*ledgerapi.StateList).Name, actualList.statelist.(*ledgerapi.StateList).Name, "should configure medicine list when one not already configured")
+	assert.Equal(t, expectedMedicineList.medicines.(*ledgerapi.StateList[*MedicalSupply]).Name, actualList.medicines.(*ledgerapi.StateList[*MedicalSupply]).Name, "should configure medicine list when one not already configured")
 
 	tc = new(TransactionContext)
 	expectedMedicineList = new(list)
-	expectedStateList := new(ledgerapi.StateList)
+	expectedStateList := new(ledgerapi.StateList[*MedicalSupply])
 	expectedStateList.Ctx = tc
 	expectedStateList.Name = "existing medicine list"
-	expectedMedicineList.statelist = expectedStateList
+	expectedMedicineList.medicines = expectedStateList
 	tc.medicineList = expectedMedicineList
 	assert.Equal(t, expectedMedicineList, tc.GetMedicineList(), "should return set medicine list when already set")
 }
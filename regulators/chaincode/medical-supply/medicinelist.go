@@ -1,7 +1,6 @@
 package medicalsupply
 
 import (
-	"encoding/json"
 	"strings"
 
 	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
@@ -11,37 +10,79 @@ import (
 type ListInterface interface {
 	AddMedicine(*MedicalSupply) error
 	GetMedicine(string, string) (*MedicalSupply, error)
+	ExistsMedicine(string, string) (bool, error)
 	GetAllMedicineByName(string) ([]*MedicalSupply, error)
 	GetAllMedicine() ([]*MedicalSupply, error)
+	QueryMedicines(string) ([]*MedicalSupply, error)
 	UpdateMedicine(*MedicalSupply) error
+	GetMedicineHistory(string, string) ([]*MedicineRecord, error)
 	DeleteMedicine(string, string) error
+	GetSearchTerms() ([]string, error)
+	GetSearchEntries(string) ([]*SearchEntry, error)
+	IndexMedicine(*MedicalSupply) error
 	AddTPMAuth(*TPMAuth) error
 	ExistsTPMAuth(string) bool
 	VerifyTPMAuth(string, string) (bool, error)
+	AddPrescription(*Prescription) error
+	GetPrescription(string, string, string) (*Prescription, error)
+	ExistsPrescription(string, string, string) (bool, error)
+	GetPrescriptionsByPatient(string, string) ([]*Prescription, error)
+	GetAllPrescriptions() ([]*Prescription, error)
+	UpdatePrescription(*Prescription) error
+	AddOrder(*Order) error
+	GetOrder(string) (*Order, error)
+	ExistsOrder(string) (bool, error)
+	GetAllOrders() ([]*Order, error)
+	UpdateOrder(*Order) error
+	AddReturn(*MedicineReturn) error
+	GetReturn(string) (*MedicineReturn, error)
+	ExistsReturn(string) (bool, error)
+	GetAllReturns() ([]*MedicineReturn, error)
+	UpdateReturn(*MedicineReturn) error
+	AddDestruction(*DestructionCertificate) error
+	GetDestruction(string, string) (*DestructionCertificate, error)
+	GetAllDestructions() ([]*DestructionCertificate, error)
+	UpdateQuota(*QuotaRule) error
+	GetQuota(string) (*QuotaRule, error)
+	GetAllQuotas() ([]*QuotaRule, error)
+	DeleteQuota(string) error
+	MigrateStates(string, int) (*MigrationProgress, error)
 }
 
+// list - Typed state lists of every class, all stored under the same list name.
 type list struct {
-	statelist ledgerapi.StateListInterface
+	medicines     ledgerapi.StateListInterface[*MedicalSupply]
+	tpmAuths      ledgerapi.StateListInterface[*TPMAuth]
+	prescriptions ledgerapi.StateListInterface[*Prescription]
+	orders        ledgerapi.StateListInterface[*Order]
+	returns       ledgerapi.StateListInterface[*MedicineReturn]
+	destructions  ledgerapi.StateListInterface[*DestructionCertificate]
+	quotas        ledgerapi.StateListInterface[*QuotaRule]
+	searchTerms   ledgerapi.StateListInterface[*SearchTerm]
+	searchEntries ledgerapi.StateListInterface[*SearchEntry]
 }
 
 // AddMedicine - Adding medicine to the statelist.
 func (msl *list) AddMedicine(medicine *MedicalSupply) error {
-	return msl.statelist.AddState(medicine)
+	err := msl.medicines.AddState(medicine)
+	if err != nil {
+		return err
+	}
+	return msl.IndexMedicine(medicine)
 }
 
 // GetMedicine - Retrieves medicine from the statelist.
 func (msl *list) GetMedicine(medName string, medNumber string) (*MedicalSupply, error) {
-	ms := new(MedicalSupply)
-
 	// Set to lower case
 	medName = strings.ToLower(medName)
 
 	// Use composite key to retrieve the medicine.
-	err := msl.statelist.GetState(CreateMedicalKey(medName, medNumber), ms, "medicalsupply")
-	if err != nil {
-		return nil, err
-	}
-	return ms, nil
+	return msl.medicines.GetState(CreateMedicalKey(medName, medNumber))
+}
+
+// ExistsMedicine - Returns true if the medicine is on the ledger, whatever its state.
+func (msl *list) ExistsMedicine(medName string, medNumber string) (bool, error) {
+	return msl.medicines.ExistsState(CreateMedicalKey(strings.ToLower(medName), medNumber))
 }
 
 // GetAllMedicineByName - Retrieves all medicine matching the medicine name from the statelist.
@@ -49,92 +90,126 @@ func (msl *list) GetAllMedicineByName(medName string) ([]*MedicalSupply, error)
 	// Set to lower case
 	medName = strings.ToLower(medName)
 
-	// GetAllStatesByPartialKey returns an iterator
-	data, err := msl.statelist.GetAllStatesByPartialKey(medName)
-	if err != nil {
-		return nil, err
-	}
-	defer data.Close()
-
-	// Use iterator to loop and return an array of all MedicalSupply objects.
-	var medicines []*MedicalSupply
-	for data.HasNext() {
-		queryResponse, err := data.Next()
-		if err != nil {
-			return nil, err
-		}
-
-		var med MedicalSupply
-		err = json.Unmarshal(queryResponse.Value, &med)
-		if err != nil {
-			return nil, err
-		}
-		medicines = append(medicines, &med)
-	}
-	return medicines, nil
+	return msl.medicines.GetStatesByKeyParts("MedStore", medName)
 }
 
 // GetAllMedicine - Retrieves all medicine from the statelist.
 func (msl *list) GetAllMedicine() ([]*MedicalSupply, error) {
-	// GetAllStates returns an iterator
-	data, err := msl.statelist.GetAllStates()
+	return msl.medicines.GetStatesByKeyParts("MedStore")
+}
+
+// QueryMedicines - Retrieves all medicine matching the CouchDB rich query from the statelist.
+func (msl *list) QueryMedicines(query string) ([]*MedicalSupply, error) {
+	return msl.medicines.QueryStates(query)
+}
+
+// GetMedicineHistory - Retrieves every version of a medicine from the statelist, oldest first.
+func (msl *list) GetMedicineHistory(medName string, medNumber string) ([]*MedicineRecord, error) {
+	// Set to lower case
+	medName = strings.ToLower(medName)
+
+	history, err := msl.medicines.GetStateHistory(CreateMedicalKey(medName, medNumber))
 	if err != nil {
 		return nil, err
 	}
-	defer data.Close()
 
-	// Use iterator to loop and return an array of all MedicalSupply objects.
-	var medicines []*MedicalSupply
-	for data.HasNext() {
-		queryResponse, err := data.Next()
-		if err != nil {
-			return nil, err
-		}
-
-		var med MedicalSupply
-		err = json.Unmarshal(queryResponse.Value, &med)
-		if err != nil {
-			return nil, err
-		}
-		medicines = append(medicines, &med)
+	var records []*MedicineRecord
+	for _, version := range history {
+		records = append(records, &MedicineRecord{TxID: version.TxID, Timestamp: version.Timestamp, IsDelete: version.IsDelete, Medicine: version.State})
 	}
-
-	return medicines, nil
+	return records, nil
 }
 
 // UpdateMedicine - Update medicine (MedicalSupply object) on the statelist.
 func (msl *list) UpdateMedicine(medicine *MedicalSupply) error {
-	return msl.statelist.UpdateState(medicine)
+	err := msl.medicines.UpdateState(medicine)
+	if err != nil {
+		return err
+	}
+	return msl.IndexMedicine(medicine)
 }
 
 // GetMedicine - Retrieves medicine from the statelist.
 func (msl *list) DeleteMedicine(medName string, medNumber string) error {
 	// Set to lower case
 	medName = strings.ToLower(medName)
-	return msl.statelist.DeleteState(CreateMedicalKey(medName, medNumber))
+
+	// Remove the medicine from the search index before it is gone.
+	medicine, err := msl.GetMedicine(medName, medNumber)
+	if err == nil {
+		for _, entry := range NewSearchEntries(medicine) {
+			err = msl.searchEntries.DeleteState(CreateSearchEntryKey(entry.Token, entry.Field, entry.MedName, entry.MedNumber))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return msl.medicines.DeleteState(CreateMedicalKey(medName, medNumber))
+}
+
+//-------------------------------------------------------//
+
+// IndexMedicine - Adds available medicine to the search index and removes all other medicine from it.
+// Terms are never removed, a term without entries simply matches nothing.
+func (msl *list) IndexMedicine(medicine *MedicalSupply) error {
+	for _, entry := range NewSearchEntries(medicine) {
+		if !medicine.IsAvailable() {
+			err := msl.searchEntries.DeleteState(CreateSearchEntryKey(entry.Token, entry.Field, entry.MedName, entry.MedNumber))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := msl.searchTerms.AddState(&SearchTerm{Token: entry.Token})
+		if err != nil {
+			return err
+		}
+		err = msl.searchEntries.AddState(entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSearchTerms - Retrieves all terms of the search index from the statelist.
+func (msl *list) GetSearchTerms() ([]string, error) {
+	searchTerms, err := msl.searchTerms.GetStatesByKeyParts("SearchTerm")
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []string
+	for _, term := range searchTerms {
+		terms = append(terms, term.Token)
+	}
+	return terms, nil
+}
+
+// GetSearchEntries - Retrieves all entries of a term of the search index from the statelist.
+func (msl *list) GetSearchEntries(token string) ([]*SearchEntry, error) {
+	return msl.searchEntries.GetStatesByKeyParts("Search", token)
 }
 
 //-------------------------------------------------------//
 
 // AddTPMAuth - Add tpm authentication to the ledger.
 func (msl *list) AddTPMAuth(auth *TPMAuth) error {
-	return msl.statelist.AddState(auth)
+	return msl.tpmAuths.AddState(auth)
 }
 
 // GetTPMAuth - Check if TPM auth exists on the ledger.
 func (msl *list) ExistsTPMAuth(holder string) bool {
-	auth := new(TPMAuth)
-
 	// Use composite key to retrieve the medicine.
-	err := msl.statelist.GetState(createTPMledgerKey(holder), auth, "tpmauth")
+	_, err := msl.tpmAuths.GetState(createTPMledgerKey(holder))
 	return err != nil
 }
 
 // VerifyTPMAuth - Check if TPM auth exists and verify the provided tpm key matches.
 func (msl *list) VerifyTPMAuth(holder string, tpmkey string) (bool, error) {
-	auth := new(TPMAuth)
 	// Use composite key to retrieve the medicine.
-	err := msl.statelist.GetState(createTPMledgerKey(holder), auth, "tpmauth")
+	auth, err := msl.tpmAuths.GetState(createTPMledgerKey(holder))
 	if err != nil {
 		return false, err
 	}
@@ -145,18 +220,203 @@ func (msl *list) VerifyTPMAuth(holder string, tpmkey string) (bool, error) {
 
 //-------------------------------------------------------//
 
+// AddPrescription - Add prescription to the ledger.
+func (msl *list) AddPrescription(prescription *Prescription) error {
+	return msl.prescriptions.AddState(prescription)
+}
+
+// GetPrescription - Retrieves prescription from the statelist.
+func (msl *list) GetPrescription(patient string, medName string, prescriptionID string) (*Prescription, error) {
+	// Set to lower case
+	medName = strings.ToLower(medName)
+
+	// Use composite key to retrieve the prescription.
+	return msl.prescriptions.GetState(CreatePrescriptionKey(patient, medName, prescriptionID))
+}
+
+// ExistsPrescription - Returns true if the prescription is on the ledger.
+func (msl *list) ExistsPrescription(patient string, medName string, prescriptionID string) (bool, error) {
+	return msl.prescriptions.ExistsState(CreatePrescriptionKey(patient, strings.ToLower(medName), prescriptionID))
+}
+
+// GetPrescriptionsByPatient - Retrieves all prescriptions of a patient for the given medicine name.
+func (msl *list) GetPrescriptionsByPatient(patient string, medName string) ([]*Prescription, error) {
+	// Set to lower case
+	medName = strings.ToLower(medName)
+
+	return msl.prescriptions.GetStatesByKeyParts("Prescription", patient, medName)
+}
+
+// GetAllPrescriptions - Retrieves all prescriptions from the statelist.
+func (msl *list) GetAllPrescriptions() ([]*Prescription, error) {
+	return msl.prescriptions.GetStatesByKeyParts("Prescription")
+}
+
+// UpdatePrescription - Update prescription on the statelist.
+func (msl *list) UpdatePrescription(prescription *Prescription) error {
+	return msl.prescriptions.UpdateState(prescription)
+}
+
+//-------------------------------------------------------//
+
+// AddOrder - Add order to the ledger.
+func (msl *list) AddOrder(order *Order) error {
+	return msl.orders.AddState(order)
+}
+
+// GetOrder - Retrieves order from the statelist.
+func (msl *list) GetOrder(orderID string) (*Order, error) {
+	// Use composite key to retrieve the order.
+	return msl.orders.GetState(CreateOrderKey(orderID))
+}
+
+// ExistsOrder - Returns true if the order is on the ledger.
+func (msl *list) ExistsOrder(orderID string) (bool, error) {
+	return msl.orders.ExistsState(CreateOrderKey(orderID))
+}
+
+// GetAllOrders - Retrieves all orders from the statelist.
+func (msl *list) GetAllOrders() ([]*Order, error) {
+	return msl.orders.GetStatesByKeyParts("Order")
+}
+
+// UpdateOrder - Update order on the statelist.
+func (msl *list) UpdateOrder(order *Order) error {
+	return msl.orders.UpdateState(order)
+}
+
+//-------------------------------------------------------//
+
+// AddReturn - Add return to the ledger.
+func (msl *list) AddReturn(medicineReturn *MedicineReturn) error {
+	return msl.returns.AddState(medicineReturn)
+}
+
+// GetReturn - Retrieves return from the statelist.
+func (msl *list) GetReturn(returnID string) (*MedicineReturn, error) {
+	// Use composite key to retrieve the return.
+	return msl.returns.GetState(CreateReturnKey(returnID))
+}
+
+// ExistsReturn - Returns true if the return is on the ledger.
+func (msl *list) ExistsReturn(returnID string) (bool, error) {
+	return msl.returns.ExistsState(CreateReturnKey(returnID))
+}
+
+// GetAllReturns - Retrieves all returns from the statelist.
+func (msl *list) GetAllReturns() ([]*MedicineReturn, error) {
+	return msl.returns.GetStatesByKeyParts("Return")
+}
+
+// UpdateReturn - Update return on the statelist.
+func (msl *list) UpdateReturn(medicineReturn *MedicineReturn) error {
+	return msl.returns.UpdateState(medicineReturn)
+}
+
+//-------------------------------------------------------//
+
+// AddDestruction - Add certificate of destruction to the ledger, which never replaces the certificate of a medicine
+// destroyed before as it is the evidence of that destruction.
+func (msl *list) AddDestruction(cert *DestructionCertificate) error {
+	exists, err := msl.destructions.ExistsState(CreateDestructionKey(cert.MedName, cert.MedNumber))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return newError(CodeAlreadyExists, "medicine %s:%s already has a certificate of destruction", cert.MedName, cert.MedNumber)
+	}
+	return msl.destructions.AddState(cert)
+}
+
+// GetDestruction - Retrieves the certificate of destruction of a medicine from the statelist.
+func (msl *list) GetDestruction(medName string, medNumber string) (*DestructionCertificate, error) {
+	// Set to lower case
+	medName = strings.ToLower(medName)
+
+	// Use composite key to retrieve the certificate.
+	return msl.destructions.GetState(CreateDestructionKey(medName, medNumber))
+}
+
+// GetAllDestructions - Retrieves all certificates of destruction from the statelist.
+func (msl *list) GetAllDestructions() ([]*DestructionCertificate, error) {
+	return msl.destructions.GetStatesByKeyParts("Destruction")
+}
+
+//-------------------------------------------------------//
+
+// UpdateQuota - Add or update quota rule on the statelist.
+func (msl *list) UpdateQuota(rule *QuotaRule) error {
+	return msl.quotas.UpdateState(rule)
+}
+
+// GetQuota - Retrieves quota rule from the statelist.
+func (msl *list) GetQuota(ruleID string) (*QuotaRule, error) {
+	// Use composite key to retrieve the quota rule.
+	return msl.quotas.GetState(CreateQuotaKey(ruleID))
+}
+
+// GetAllQuotas - Retrieves all quota rules from the statelist.
+func (msl *list) GetAllQuotas() ([]*QuotaRule, error) {
+	return msl.quotas.GetStatesByKeyParts("Quota")
+}
+
+// DeleteQuota - Removes quota rule from the statelist.
+func (msl *list) DeleteQuota(ruleID string) error {
+	return msl.quotas.DeleteState(CreateQuotaKey(ruleID))
+}
+
+//-------------------------------------------------------//
+
+// MigrateStates - Rewrites a page of states on the statelist to the latest schema versions.
+func (msl *list) MigrateStates(bookmark string, pageSize int) (*MigrationProgress, error) {
+	// Every class shares the list name, so any of the lists pages through all states.
+	return migrateStates(msl.medicines, bookmark, pageSize)
+}
+
+//-------------------------------------------------------//
+
+// newFactory - Creates the factory of a state from its Deserialize function.
+func newFactory[S any, T interface {
+	*S
+	ledgerapi.StateInterface
+}](deserialize func([]byte, T) error) ledgerapi.Factory[T] {
+	return func(data []byte) (T, error) {
+		state := T(new(S))
+		err := deserialize(data, state)
+		if err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+}
+
+// Register the factories of the classes stored on the medicine list.
+func init() {
+	ledgerapi.RegisterFactory(MedicineClass, newFactory(DeserializeJSON))
+	ledgerapi.RegisterFactory(TPMAuthClass, newFactory(DeserializeTPM))
+	ledgerapi.RegisterFactory(PrescriptionClass, newFactory(DeserializePrescription))
+	ledgerapi.RegisterFactory(OrderClass, newFactory(DeserializeOrder))
+	ledgerapi.RegisterFactory(ReturnClass, newFactory(DeserializeReturn))
+	ledgerapi.RegisterFactory(DestructionClass, newFactory(DeserializeDestruction))
+	ledgerapi.RegisterFactory(QuotaClass, newFactory(DeserializeQuota))
+	ledgerapi.RegisterFactory(SearchTermClass, newFactory(DeserializeSearchTerm))
+	ledgerapi.RegisterFactory(SearchEntryClass, newFactory(DeserializeSearchEntry))
+}
+
+// ListName - Name of the statelist all states are stored under.
+const ListName = "org.medstore.medicalsupplylist"
+
 // newList - Create new statelist.
 func newList(ctx TransactionContextInterface) *list {
-	statelist := new(ledgerapi.StateList)
-	statelist.Ctx = ctx
-	statelist.Name = "org.medstore.medicalsupplylist"
-	statelist.DeserializeJSON = func(bytes []byte, state ledgerapi.StateInterface) error {
-		return DeserializeJSON(bytes, state.(*MedicalSupply))
-	}
-	statelist.DeserializeTPM = func(bytes []byte, state ledgerapi.StateInterface) error {
-		return DeserializeTPM(bytes, state.(*TPMAuth))
+	return &list{
+		medicines:     ledgerapi.NewStateList[*MedicalSupply](ctx, ListName, MedicineClass),
+		tpmAuths:      ledgerapi.NewStateList[*TPMAuth](ctx, ListName, TPMAuthClass),
+		prescriptions: ledgerapi.NewStateList[*Prescription](ctx, ListName, PrescriptionClass),
+		orders:        ledgerapi.NewStateList[*Order](ctx, ListName, OrderClass),
+		returns:       ledgerapi.NewStateList[*MedicineReturn](ctx, ListName, ReturnClass),
+		destructions:  ledgerapi.NewStateList[*DestructionCertificate](ctx, ListName, DestructionClass),
+		quotas:        ledgerapi.NewStateList[*QuotaRule](ctx, ListName, QuotaClass),
+		searchTerms:   ledgerapi.NewStateList[*SearchTerm](ctx, ListName, SearchTermClass),
+		searchEntries: ledgerapi.NewStateList[*SearchEntry](ctx, ListName, SearchEntryClass),
 	}
-	list := new(list)
-	list.statelist = statelist
-	return list
 }
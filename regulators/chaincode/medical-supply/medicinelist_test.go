@@ -12,16 +12,45 @@ type MockStateList struct {
 	mock.Mock
 }
 
-func TestNewStateList(t *testing.T) {
-	ctx := new(TransactionContext)
-	list := newList(ctx)
-	stateList, ok := list.statelist.(*ledgerapi.StateList)
+// assertStateList - Checks that a typed list is configured with the context, the list name and the factory of its class.
+func assertStateList[T ledgerapi.StateInterface](t *testing.T, ctx *TransactionContext, statelist ledgerapi.StateListInterface[T], class string, deserialize func([]byte, T) error, state T) {
+	stateList, ok := statelist.(*ledgerapi.StateList[T])
 
 	assert.True(t, ok, "should make statelist of type ledgerapi.StateList")
 	assert.Equal(t, ctx, stateList.Ctx, "should set the context to passed context")
 	assert.Equal(t, "org.medstore.medicalsupplylist", stateList.Name, "should set the name for the list")
+	assert.Equal(t, class, stateList.Class, "should set the class of the list")
+
+	expectedErr := deserialize([]byte("bad json"), state)
+	_, err := stateList.Factory([]byte("bad json"))
+	assert.EqualError(t, err, expectedErr.Error(), "should deserialize using the factory registered for the class")
+}
+
+func TestNewStateList(t *testing.T) {
+	ctx := new(TransactionContext)
+	list := newList(ctx)
+
+	assertStateList(t, ctx, list.medicines, MedicineClass, DeserializeJSON, new(MedicalSupply))
+	assertStateList(t, ctx, list.tpmAuths, TPMAuthClass, DeserializeTPM, new(TPMAuth))
+	assertStateList(t, ctx, list.prescriptions, PrescriptionClass, DeserializePrescription, new(Prescription))
+	assertStateList(t, ctx, list.orders, OrderClass, DeserializeOrder, new(Order))
+	assertStateList(t, ctx, list.returns, ReturnClass, DeserializeReturn, new(MedicineReturn))
+	assertStateList(t, ctx, list.destructions, DestructionClass, DeserializeDestruction, new(DestructionCertificate))
+	assertStateList(t, ctx, list.quotas, QuotaClass, DeserializeQuota, new(QuotaRule))
+	assertStateList(t, ctx, list.searchTerms, SearchTermClass, DeserializeSearchTerm, new(SearchTerm))
+	assertStateList(t, ctx, list.searchEntries, SearchEntryClass, DeserializeSearchEntry, new(SearchEntry))
+}
+
+func TestRegisteredFactories(t *testing.T) {
+	factory, err := ledgerapi.GetFactory[*MedicalSupply](MedicineClass)
+	assert.Nil(t, err, "should register a factory for medicine")
+	ms, err := factory([]byte(`{"medName":"aspirin","medNumber":"00001","currentState":2,"class":"org.medstore.medicalsupply"}`))
+	assert.Nil(t, err, "should create medicine from JSON")
+	assert.Equal(t, REQUESTED, ms.GetState(), "should deserialize the state")
 
-	expectedErr := DeserializeJSON([]byte("bad json"), new(MedicalSupply))
-	err := stateList.DeserializeJSON([]byte("bad json"), new(MedicalSupply))
-	assert.EqualError(t, err, expectedErr.Error(), "should call Deserialize when stateList.Deserialize called")
+	_, err = ledgerapi.GetFactory[*TPMAuth](MedicineClass)
+	assert.EqualError(t, err, "factory registered for class org.medstore.medicalsupply does not create *medicalsupply.TPMAuth", "should reject a factory of another type")
+	_, err = ledgerapi.GetFactory[*TPMAuth]("org.medstore.unknown")
+	assert.EqualError(t, err, "no factory registered for class org.medstore.unknown", "should reject unknown classes")
+	assert.Panics(t, func() { ledgerapi.NewStateList[*TPMAuth](new(TransactionContext), ListName, "org.medstore.unknown") }, "should not create a list without factory")
 }
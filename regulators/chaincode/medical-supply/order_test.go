@@ -0,0 +1,81 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStateString(t *testing.T) {
+	assert.Equal(t, "PENDING", PENDING.String(), "should return string for pending.")
+	assert.Equal(t, "APPROVED", APPROVED.String(), "should return string for approved.")
+	assert.Equal(t, "REJECTED", REJECTED.String(), "should return string for rejected.")
+	assert.Equal(t, "CANCELLED", CANCELLED.String(), "should return string for cancelled.")
+	assert.Equal(t, "UNKNOWN", OrderState(CANCELLED+1).String(), "should return unknown when not one of constants.")
+}
+
+func TestCreateOrderKey(t *testing.T) {
+	assert.Equal(t, ledgerapi.MakeKey("Order", "ORD0001"), CreateOrderKey("ORD0001"), "should return key comprised of passed values.")
+}
+
+func TestOrderStates(t *testing.T) {
+	order := new(Order)
+
+	order.SetPending()
+	assert.True(t, order.IsPending(), "should be true when status set to pending.")
+
+	order.SetApproved()
+	assert.Equal(t, APPROVED, order.GetState(), "should set state to approved.")
+	assert.False(t, order.IsPending(), "should be false when status not set to pending.")
+
+	order.SetRejected()
+	assert.Equal(t, REJECTED, order.GetState(), "should set state to rejected.")
+
+	order.SetCancelled()
+	assert.Equal(t, CANCELLED, order.GetState(), "should set state to cancelled.")
+}
+
+func TestGetOrderSplitKey(t *testing.T) {
+	order := new(Order)
+	order.OrderID = "ORD0001"
+
+	assert.Equal(t, []string{"Order", "ORD0001"}, order.GetSplitKey(), "should return order id as split key.")
+}
+
+func TestSerializeOrder(t *testing.T) {
+	order := new(Order)
+	order.OrderID = "ORD0001"
+	order.Customer = "alice"
+	order.OrderDate = "2022.02.22"
+	order.Lines = []OrderLine{{MedName: "aspirin", Quantity: 2, MedNumbers: []string{"00001", "00012"}}}
+	order.SetPending()
+	correctJson := `{"orderID":"ORD0001","customer":"alice","orderDate":"2022.02.22","lines":[{"medName":"aspirin","quantity":2,"medNumbers":["00001","00012"]}],"currentState":1,"class":"org.medstore.order","key":"Order:ORD0001","schemaVersion":2}`
+
+	bytes, err := order.Serialize()
+	assert.Nil(t, err, "should not error on serialize")
+	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
+}
+
+func TestDeserializeOrder(t *testing.T) {
+	var order *Order
+	var err error
+
+	order = new(Order)
+	correctJson := `{"orderID":"ORD0001","customer":"alice","orderDate":"2022.02.22","lines":[{"medName":"aspirin","quantity":1,"medNumbers":["00001"]}],"currentState":2,"class":"org.medstore.order","key":"Order:ORD0001"}`
+	err = DeserializeOrder([]byte(correctJson), order)
+	assert.Nil(t, err, "should not return error for deserialize")
+
+	expectedOrder := new(Order)
+	expectedOrder.OrderID = "ORD0001"
+	expectedOrder.Customer = "alice"
+	expectedOrder.OrderDate = "2022.02.22"
+	expectedOrder.Lines = []OrderLine{{MedName: "aspirin", Quantity: 1, MedNumbers: []string{"00001"}}}
+	expectedOrder.SetApproved()
+	assert.Equal(t, expectedOrder, order, "should create expected order")
+
+	incorrectJson := `{"orderID":"ORD0001","lines":"aspirin"}`
+	order = new(Order)
+	err = DeserializeOrder([]byte(incorrectJson), order)
+	assert.EqualError(t, err, "error deserializing order. json: cannot unmarshal string into Go struct field jsonOrder.lines of type []medicalsupply.OrderLine", "should return error for bad data")
+}
@@ -0,0 +1,130 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+)
+
+// PrescriptionClass - Class of the prescription states.
+const PrescriptionClass = "org.medstore.prescription"
+
+// CreatePrescriptionKey - Creates a key for the prescription (e.g. Prescription:alice:vicodin:RX0001).
+func CreatePrescriptionKey(patient string, medName string, prescriptionID string) string {
+	return ledgerapi.MakeKey("Prescription", patient, medName, prescriptionID)
+}
+
+type prescriptionAlias Prescription
+type jsonPrescription struct {
+	*prescriptionAlias
+	Class         string `json:"class"`
+	Key           string `json:"key"`
+	SchemaVersion int    `json:"schemaVersion"`
+}
+
+// Prescription - Defines a prescription which allows a patient to request a prescription-only medicine.
+// Quantity is the amount per fill, Refills the amount of additional fills allowed after the first one.
+type Prescription struct {
+	PrescriptionID string `json:"prescriptionID"`
+	Prescriber     string `json:"prescriber"`
+	Patient        string `json:"patient"`
+	MedName        string `json:"medName"`
+	Quantity       int    `json:"quantity"`
+	Refills        int    `json:"refills"`
+	ValidFrom      string `json:"validFrom"`
+	ValidUntil     string `json:"validUntil"`
+	Dispensed      int    `json:"dispensed"`
+	class          string `metadata:"class"`
+	key            string `metadata:"key"`
+	schemaVersion  int    `metadata:"schemaVersion"`
+}
+
+//-------------------------------------------------------//
+
+// MarshalJSON - Special handler for managing JSON marshalling.
+func (rx Prescription) MarshalJSON() ([]byte, error) {
+	jrx := jsonPrescription{prescriptionAlias: (*prescriptionAlias)(&rx), Class: PrescriptionClass, Key: CreatePrescriptionKey(rx.Patient, rx.MedName, rx.PrescriptionID), SchemaVersion: schemaVersion(PrescriptionClass)}
+	return json.Marshal(&jrx)
+}
+
+// UnmarshalJSON - Special handler for managing JSON marshalling.
+func (rx *Prescription) UnmarshalJSON(data []byte) error {
+	jrx := jsonPrescription{prescriptionAlias: (*prescriptionAlias)(rx)}
+
+	err := json.Unmarshal(data, &jrx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+//-------------------------------------------------------//
+
+// Allowance - Returns the total amount of units the prescription allows to be dispensed.
+func (rx *Prescription) Allowance() int {
+	return rx.Quantity * (rx.Refills + 1)
+}
+
+// Remaining - Returns the amount of units that can still be dispensed.
+func (rx *Prescription) Remaining() int {
+	return rx.Allowance() - rx.Dispensed
+}
+
+// IsValidAt - Returns true if the given time lies within the validity window of the prescription.
+// ValidUntil is inclusive, so a prescription valid until 2022.05.09 can still be used on that day.
+func (rx *Prescription) IsValidAt(t time.Time) bool {
+	validFrom, err := time.Parse(DateLayout, rx.ValidFrom)
+	if err != nil {
+		return false
+	}
+	validUntil, err := time.Parse(DateLayout, rx.ValidUntil)
+	if err != nil {
+		return false
+	}
+	return !t.Before(validFrom) && t.Before(validUntil.AddDate(0, 0, 1))
+}
+
+// Dispense - Registers a single dispensed unit on the prescription.
+func (rx *Prescription) Dispense() error {
+	if rx.Remaining() <= 0 {
+		return fmt.Errorf("prescription %s has no remaining units", rx.PrescriptionID)
+	}
+	rx.Dispensed++
+	return nil
+}
+
+// Restore - Returns a single dispensed unit to the prescription (e.g. when a request is cancelled).
+func (rx *Prescription) Restore() {
+	if rx.Dispensed > 0 {
+		rx.Dispensed--
+	}
+}
+
+//-------------------------------------------------------//
+
+// GetSplitKey - Returns values which should be used to form key.
+func (rx *Prescription) GetSplitKey() []string {
+	return []string{"Prescription", rx.Patient, rx.MedName, rx.PrescriptionID}
+}
+
+// Serialize - Formats the prescription as JSON bytes.
+func (rx *Prescription) Serialize() ([]byte, error) {
+	return json.Marshal(rx)
+}
+
+// DeserializePrescription - Formats the prescription from JSON bytes.
+func DeserializePrescription(bytes []byte, rx *Prescription) error {
+	// Upcast states written by older versions of the chaincode.
+	data, err := upcast(PrescriptionClass, bytes)
+	if err == nil {
+		err = json.Unmarshal(data, rx)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error deserializing prescription. %s", err.Error())
+	}
+
+	return nil
+}
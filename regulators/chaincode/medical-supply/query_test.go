@@ -0,0 +1,132 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func queryTestMedicine() []*MedicalSupply {
+	medicine := func(name string, number string, disease string, expiration string, price string, holder string, state State) *MedicalSupply {
+		ms := &MedicalSupply{MedName: name, MedNumber: number, Disease: disease, Expiration: expiration, Price: price, Holder: holder}
+		ms.state = state
+		return ms
+	}
+	return []*MedicalSupply{
+		medicine("vicodin", "00002", "pain", "2022.06.01", "$25", "MedStore", AVAILABLE),
+		medicine("aspirin", "00003", "headache", "2022.03.10", "$2.50", "MedStore", AVAILABLE),
+		medicine("aspirin", "00001", "headache", "2022.03.01", "$2", "alicehash", SEND),
+		medicine("lipitor", "00004", "cholesterol", "2023.01.01", "$15", "MedStore", AVAILABLE),
+		medicine("aspirin", "00005", "headache", "2022.03.01", "free", "MedStore", AVAILABLE),
+	}
+}
+
+func TestParseMedicineFilter(t *testing.T) {
+	filter, err := ParseMedicineFilter(`{"state":"available","disease":"Headache","priceMax":"$3","sort":"-expiration"}`)
+	assert.Nil(t, err, "should accept a valid filter")
+	assert.Equal(t, AVAILABLE, filter.state, "should parse the state name regardless of case")
+	assert.Equal(t, "headache", filter.Disease, "should match the lower case disease stored on the ledger")
+
+	filter, err = ParseMedicineFilter("")
+	assert.Nil(t, err, "should accept an empty filter")
+	assert.Len(t, filter.Apply(queryTestMedicine()), 5, "should match all medicine without filter")
+
+	_, err = ParseMedicineFilter(`{"medName":"aspirin"}`)
+	assert.Error(t, err, "should reject unknown fields")
+	_, err = ParseMedicineFilter(`{"state":"LOST"}`)
+	assert.EqualError(t, err, "invalid filter: unknown state LOST", "should reject unknown states")
+	_, err = ParseMedicineFilter(`{"expiryFrom":"2022-03-01"}`)
+	assert.EqualError(t, err, "invalid filter: expiry date 2022-03-01 should be formatted as 2006.01.02", "should reject other date layouts")
+	_, err = ParseMedicineFilter(`{"priceMin":"ten"}`)
+	assert.EqualError(t, err, "invalid filter: invalid price ten, expected e.g. $10 or $2.50", "should reject invalid prices")
+	_, err = ParseMedicineFilter(`{"sort":"price"}`)
+	assert.EqualError(t, err, "invalid filter: can't sort on price, expected medName or expiration", "should only sort on indexed fields")
+	_, err = ParseMedicineFilter(`{"selector":{"$where":"1"}}`)
+	assert.Error(t, err, "should not accept raw selectors")
+}
+
+func TestMedicineFilterSelector(t *testing.T) {
+	filter, _ := ParseMedicineFilter(`{"state":"AVAILABLE","holder":"MedStore","disease":"headache","expiryFrom":"2022.03.01","expiryTo":"2022.12.31","priceMin":"$1","sort":"-medName"}`)
+	selector, err := filter.Selector()
+	assert.Nil(t, err, "should not error on a valid filter")
+
+	var query map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(selector), &query), "should be valid JSON")
+	assert.Equal(t, map[string]interface{}{
+		"class":        "org.medstore.medicalsupply",
+		"currentState": float64(AVAILABLE),
+		"holder":       "MedStore",
+		"disease":      "headache",
+		"expiration":   map[string]interface{}{"$gte": "2022.03.01", "$lte": "2022.12.31"},
+	}, query["selector"], "should translate the filter into a Mango selector without the price range")
+	assert.Equal(t, []interface{}{map[string]interface{}{"class": "desc"}, map[string]interface{}{"medName": "desc"}}, query["sort"], "should sort on the index fields")
+	assert.Equal(t, []interface{}{"_design/indexMedNameDoc", "indexMedName"}, query["use_index"], "should use the shipped index")
+
+	filter, _ = ParseMedicineFilter(`{}`)
+	selector, _ = filter.Selector()
+	assert.Equal(t, `{"selector":{"class":"org.medstore.medicalsupply"}}`, selector, "should only select medicine without filter")
+}
+
+func TestMedicineFilterApply(t *testing.T) {
+	filter, _ := ParseMedicineFilter(`{"state":"AVAILABLE","disease":"headache"}`)
+	result := filter.Apply(queryTestMedicine())
+	assert.Len(t, result, 2, "should filter on state and disease")
+	assert.Equal(t, "00003", result[0].MedNumber, "should order by key without sort")
+
+	filter, _ = ParseMedicineFilter(`{"priceMin":"$2","priceMax":"$20"}`)
+	result = filter.Apply(queryTestMedicine())
+	assert.Len(t, result, 3, "should filter on price and skip medicine without a valid price")
+
+	filter, _ = ParseMedicineFilter(`{"expiryFrom":"2022.03.01","expiryTo":"2022.06.01","sort":"-expiration"}`)
+	result = filter.Apply(queryTestMedicine())
+	var numbers []string
+	for _, ms := range result {
+		numbers = append(numbers, ms.MedNumber)
+	}
+	assert.Equal(t, []string{"00002", "00003", "00001", "00005"}, numbers, "should include the bounds, sort descending and break ties by key")
+
+	filter, _ = ParseMedicineFilter(`{"holder":"bobhash"}`)
+	assert.Empty(t, filter.Apply(queryTestMedicine()), "should be empty when nothing matches")
+}
+
+// queryStub - Stub failing rich queries with the error, like peers using LevelDB or failing CouchDB peers.
+type queryStub struct {
+	*shimtest.MockStub
+	err error
+}
+
+func (stub *queryStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, stub.err
+}
+
+func TestQueryMedicinesFallback(t *testing.T) {
+	stub := &queryStub{MockStub: shimtest.NewMockStub("medicalsupply", nil), err: errors.New(richQueryNotSupported)}
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+
+	stub.MockTransactionStart("tx1")
+	for _, ms := range queryTestMedicine() {
+		assert.Nil(t, ctx.GetMedicineList().AddMedicine(ms), "should add medicine to the stub")
+	}
+	stub.MockTransactionEnd("tx1")
+
+	_, err := ctx.GetMedicineList().QueryMedicines(`{"selector":{}}`)
+	assert.Error(t, err, "should not support rich queries")
+
+	filter, _ := ParseMedicineFilter(`{"state":"AVAILABLE","sort":"medName"}`)
+	result, err := queryMedicines(ctx.GetMedicineList(), filter)
+	assert.Nil(t, err, "should fall back to filtering all medicine")
+	var keys []string
+	for _, ms := range result {
+		keys = append(keys, CreateMedicalKey(ms.MedName, ms.MedNumber))
+	}
+	assert.Equal(t, []string{"MedStore:aspirin:00003", "MedStore:aspirin:00005", "MedStore:lipitor:00004", "MedStore:vicodin:00002"}, keys, "should return the matching medicine sorted by name")
+
+	stub.err = errors.New("Error handling CouchDB request. Error:no_usable_index")
+	_, err = queryMedicines(ctx.GetMedicineList(), filter)
+	assert.EqualError(t, err, "could not query medicine from ledger: Error handling CouchDB request. Error:no_usable_index", "should return other errors of the rich query")
+}
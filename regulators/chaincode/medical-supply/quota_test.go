@@ -0,0 +1,77 @@
+package medicalsupply
+
+import (
+	"testing"
+	"time"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateQuotaKey(t *testing.T) {
+	assert.Equal(t, ledgerapi.MakeKey("Quota", "Q0001"), CreateQuotaKey("Q0001"), "should return key comprised of passed values.")
+}
+
+func TestQuotaMatches(t *testing.T) {
+	medicine := new(MedicalSupply)
+	medicine.MedName = "vicodin"
+	medicine.Disease = "pain management"
+
+	rule := &QuotaRule{Scope: "medicine", Target: "vicodin"}
+	assert.True(t, rule.Matches(medicine), "should match on medicine name.")
+
+	rule = &QuotaRule{Scope: "category", Target: "pain management"}
+	assert.True(t, rule.Matches(medicine), "should match on disease.")
+
+	medicine.Schedule = "II"
+	rule = &QuotaRule{Scope: "schedule", Target: "II"}
+	assert.True(t, rule.Matches(medicine), "should match on schedule.")
+
+	rule = &QuotaRule{Scope: "medicine", Target: "aspirin"}
+	assert.False(t, rule.Matches(medicine), "should not match other medicine.")
+
+	rule = &QuotaRule{Scope: "holder", Target: "vicodin"}
+	assert.False(t, rule.Matches(medicine), "should not match unknown scopes.")
+}
+
+func TestQuotaInPeriod(t *testing.T) {
+	now := time.Date(2022, 3, 31, 12, 0, 0, 0, time.UTC)
+	rule := &QuotaRule{PeriodDays: 30}
+
+	assert.True(t, rule.InPeriod("2022-03-15T08:00:00Z", now), "should be true within the period.")
+	assert.False(t, rule.InPeriod("2022-03-01T12:00:00Z", now), "should be false at the start of the period.")
+	assert.False(t, rule.InPeriod("2022-04-01T12:00:00Z", now), "should be false after now.")
+	assert.False(t, rule.InPeriod("", now), "should be false without request date.")
+}
+
+func TestQuotaExplain(t *testing.T) {
+	rule := &QuotaRule{RuleID: "Q0001", Scope: "medicine", Target: "vicodin", MaxUnits: 2, PeriodDays: 30}
+
+	assert.Equal(t, "quota Q0001 allows at most 2 unit(s) of medicine vicodin per 30 day(s), 2 unit(s) requested in the last 30 day(s) and 1 more requested now",
+		rule.Explain(2, 1), "should explain the exceeded quota.")
+}
+
+func TestSerializeQuota(t *testing.T) {
+	rule := &QuotaRule{RuleID: "Q0001", Scope: "category", Target: "pain management", MaxUnits: 10, PeriodDays: 30}
+	correctJson := `{"ruleID":"Q0001","scope":"category","target":"pain management","maxUnits":10,"periodDays":30,"class":"org.medstore.quota","key":"Quota:Q0001","schemaVersion":2}`
+
+	bytes, err := rule.Serialize()
+	assert.Nil(t, err, "should not error on serialize")
+	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
+}
+
+func TestDeserializeQuota(t *testing.T) {
+	var rule *QuotaRule
+	var err error
+
+	rule = new(QuotaRule)
+	correctJson := `{"ruleID":"Q0001","scope":"medicine","target":"vicodin","maxUnits":2,"periodDays":30,"class":"org.medstore.quota","key":"Quota:Q0001"}`
+	err = DeserializeQuota([]byte(correctJson), rule)
+	assert.Nil(t, err, "should not return error for deserialize")
+	assert.Equal(t, &QuotaRule{RuleID: "Q0001", Scope: "medicine", Target: "vicodin", MaxUnits: 2, PeriodDays: 30}, rule, "should create expected quota rule")
+
+	incorrectJson := `{"ruleID":"Q0001","maxUnits":"two"}`
+	rule = new(QuotaRule)
+	err = DeserializeQuota([]byte(incorrectJson), rule)
+	assert.EqualError(t, err, "error deserializing quota rule. json: cannot unmarshal string into Go struct field jsonQuotaRule.maxUnits of type int", "should return error for bad data")
+}
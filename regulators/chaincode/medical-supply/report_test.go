@@ -0,0 +1,82 @@
+package medicalsupply
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrice(t *testing.T) {
+	for _, tc := range []struct {
+		price string
+		cents int64
+		valid bool
+	}{
+		{"$10", 1000, true},
+		{"$2.5", 250, true},
+		{"12.05", 1205, true},
+		{" $0.50 ", 50, true},
+		{"€7", 0, false},
+		{"£7", 0, false},
+		{"ten dollars", 0, false},
+		{"$1.005", 0, false},
+		{"abc10", 0, false},
+		{"-$5", 0, false},
+		{"$-5", 0, false},
+		{"+5", 0, false},
+		{".50", 0, false},
+		{"$.50", 0, false},
+		{"$$10", 0, false},
+		{"$ 10", 0, false},
+		{"10$", 0, false},
+		{"$", 0, false},
+		{"", 0, false},
+	} {
+		cents, err := ParsePrice(tc.price)
+		if tc.valid {
+			assert.Nil(t, err, "should parse %q", tc.price)
+			assert.Equal(t, tc.cents, cents, "should return the cents of %q", tc.price)
+		} else {
+			assert.EqualError(t, err, "invalid price "+tc.price+", expected e.g. $10 or $2.50", "should reject %q", tc.price)
+		}
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	assert.Equal(t, "$2.50", FormatPrice(250), "should format cents as price")
+	assert.Equal(t, "$0.05", FormatPrice(5), "should pad cents")
+}
+
+func TestNewInventoryReport(t *testing.T) {
+	medicine := func(name string, price string, expiration string, holder string, state State) *MedicalSupply {
+		ms := &MedicalSupply{MedName: name, Price: price, Expiration: expiration, Holder: holder}
+		ms.state = state
+		return ms
+	}
+	medicines := []*MedicalSupply{
+		medicine("vicodin", "$14", "2022.07.01", "MedStore", AVAILABLE),
+		medicine("aspirin", "$10", "2022.05.09", "MedStore", AVAILABLE),
+		medicine("aspirin", "$2.50", "2022.01.01", "MedStore", AVAILABLE),
+		medicine("aspirin", "$10", "2023.01.01", "alice", SEND),
+		medicine("aspirin", "free", "2022.03.01", "bob", SEND),
+	}
+
+	report := NewInventoryReport(medicines, time.Date(2022, 2, 21, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2022-02-21T09:00:00Z", report.GeneratedAt, "should use the passed time")
+	assert.Equal(t, []*InventoryLine{
+		{MedName: "aspirin", State: "AVAILABLE", Count: 2, TotalValue: "$12.50", DistinctHolders: 1, EarliestExpiry: "2022.01.01", LatestExpiry: "2022.05.09"},
+		{MedName: "aspirin", State: "SEND", Count: 2, TotalValue: "$10.00", Unpriced: 1, DistinctHolders: 2, EarliestExpiry: "2022.03.01", LatestExpiry: "2023.01.01"},
+		{MedName: "vicodin", State: "AVAILABLE", Count: 1, TotalValue: "$14.00", DistinctHolders: 1, EarliestExpiry: "2022.07.01", LatestExpiry: "2022.07.01"},
+	}, report.Lines, "should aggregate by medicine name and state")
+	assert.Equal(t, []*InventoryLine{
+		{State: "AVAILABLE", Count: 3, TotalValue: "$26.50", DistinctHolders: 1, EarliestExpiry: "2022.01.01", LatestExpiry: "2022.07.01"},
+		{State: "SEND", Count: 2, TotalValue: "$10.00", Unpriced: 1, DistinctHolders: 2, EarliestExpiry: "2022.03.01", LatestExpiry: "2023.01.01"},
+	}, report.StateTotals, "should aggregate by state")
+	assert.Equal(t, &InventoryLine{Count: 5, TotalValue: "$36.50", Unpriced: 1, DistinctHolders: 3, EarliestExpiry: "2022.01.01", LatestExpiry: "2023.01.01"},
+		report.Total, "should aggregate all medicine")
+
+	empty := NewInventoryReport(nil, time.Date(2022, 2, 21, 9, 0, 0, 0, time.UTC))
+	assert.Empty(t, empty.Lines, "should have no lines without medicine")
+	assert.Equal(t, "$0.00", empty.Total.TotalValue, "should have no value without medicine")
+}
@@ -0,0 +1,95 @@
+package medicalsupply
+
+import (
+	"testing"
+
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnStateString(t *testing.T) {
+	assert.Equal(t, "FILED", FILED.String(), "should return string for filed.")
+	assert.Equal(t, "RESTOCKED", RESTOCKED.String(), "should return string for restocked.")
+	assert.Equal(t, "DISCARDED", DISCARDED.String(), "should return string for discarded.")
+	assert.Equal(t, "UNKNOWN", ReturnState(DISCARDED+1).String(), "should return unknown when not one of constants.")
+}
+
+func TestCreateReturnKey(t *testing.T) {
+	assert.Equal(t, ledgerapi.MakeKey("Return", "RET0001"), CreateReturnKey("RET0001"), "should return key comprised of passed values.")
+}
+
+func TestReturnStates(t *testing.T) {
+	medicineReturn := new(MedicineReturn)
+
+	medicineReturn.SetFiled()
+	assert.True(t, medicineReturn.IsFiled(), "should be true when status set to filed.")
+
+	medicineReturn.SetRestocked()
+	assert.Equal(t, RESTOCKED, medicineReturn.GetState(), "should set state to restocked.")
+	assert.False(t, medicineReturn.IsFiled(), "should be false when status not set to filed.")
+
+	medicineReturn.SetDiscarded()
+	assert.Equal(t, DISCARDED, medicineReturn.GetState(), "should set state to discarded.")
+}
+
+func TestReturnAddEvent(t *testing.T) {
+	medicineReturn := new(MedicineReturn)
+	medicineReturn.AddEvent("RequestReturn", "alice", "2022-02-22T10:00:00Z", "tx1", "damaged package")
+	medicineReturn.AddEvent("InspectReturn", "bob", "2022-02-23T10:00:00Z", "tx2", "seal intact")
+
+	assert.Equal(t, []ReturnEvent{
+		{Step: "RequestReturn", Actor: "alice", Date: "2022-02-22T10:00:00Z", TxID: "tx1", Notes: "damaged package"},
+		{Step: "InspectReturn", Actor: "bob", Date: "2022-02-23T10:00:00Z", TxID: "tx2", Notes: "seal intact"},
+	}, medicineReturn.Events, "should append events in order.")
+}
+
+func TestGetReturnSplitKey(t *testing.T) {
+	medicineReturn := new(MedicineReturn)
+	medicineReturn.ReturnID = "RET0001"
+
+	assert.Equal(t, []string{"Return", "RET0001"}, medicineReturn.GetSplitKey(), "should return return id as split key.")
+}
+
+func TestSerializeReturn(t *testing.T) {
+	medicineReturn := new(MedicineReturn)
+	medicineReturn.ReturnID = "RET0001"
+	medicineReturn.MedName = "aspirin"
+	medicineReturn.MedNumber = "00001"
+	medicineReturn.Customer = "alice"
+	medicineReturn.Reason = "damaged package"
+	medicineReturn.PricePaid = "$10"
+	medicineReturn.AddEvent("RequestReturn", "alice", "2022-02-22T10:00:00Z", "tx1", "damaged package")
+	medicineReturn.SetFiled()
+	correctJson := `{"returnID":"RET0001","medName":"aspirin","medNumber":"00001","customer":"alice","reason":"damaged package","pricePaid":"$10","refundAmount":"","events":[{"step":"RequestReturn","actor":"alice","date":"2022-02-22T10:00:00Z","txID":"tx1","notes":"damaged package"}],"currentState":1,"class":"org.medstore.return","key":"Return:RET0001","schemaVersion":2}`
+
+	bytes, err := medicineReturn.Serialize()
+	assert.Nil(t, err, "should not error on serialize")
+	assert.Equal(t, correctJson, string(bytes), "should return JSON formatted value")
+}
+
+func TestDeserializeReturn(t *testing.T) {
+	var medicineReturn *MedicineReturn
+	var err error
+
+	medicineReturn = new(MedicineReturn)
+	correctJson := `{"returnID":"RET0001","medName":"aspirin","medNumber":"00001","customer":"alice","reason":"damaged package","pricePaid":"$10","refundAmount":"$10","events":[],"currentState":2,"class":"org.medstore.return","key":"Return:RET0001"}`
+	err = DeserializeReturn([]byte(correctJson), medicineReturn)
+	assert.Nil(t, err, "should not return error for deserialize")
+
+	expectedReturn := new(MedicineReturn)
+	expectedReturn.ReturnID = "RET0001"
+	expectedReturn.MedName = "aspirin"
+	expectedReturn.MedNumber = "00001"
+	expectedReturn.Customer = "alice"
+	expectedReturn.Reason = "damaged package"
+	expectedReturn.PricePaid = "$10"
+	expectedReturn.RefundAmount = "$10"
+	expectedReturn.Events = []ReturnEvent{}
+	expectedReturn.SetRestocked()
+	assert.Equal(t, expectedReturn, medicineReturn, "should create expected return")
+
+	incorrectJson := `{"returnID":"RET0001","reason":404}`
+	medicineReturn = new(MedicineReturn)
+	err = DeserializeReturn([]byte(incorrectJson), medicineReturn)
+	assert.EqualError(t, err, "error deserializing return. json: cannot unmarshal number into Go struct field jsonMedicineReturn.reason of type string", "should return error for bad data")
+}
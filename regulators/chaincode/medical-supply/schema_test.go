@@ -0,0 +1,126 @@
+package medicalsupply
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	ledgerapi "github.com/hyperledger/fabric-samples/medical-supply/regulators/chaincode/ledger-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// A medicine as written before schema versions were stored.
+const unversionedMedicine = `{"checkSum":"","medName":"aspirin","medNumber":"00001","disease":"fever","expiration":"2022.05.09","price":"$10","holder":"MedStore","currentState":1,"class":"org.medstore.medicalsupply","key":"MedStore:aspirin:00001"}`
+
+func TestSchemaUpcast(t *testing.T) {
+	schema := ledgerapi.Schema{Upcasters: []ledgerapi.Upcaster{
+		unversioned,
+		func(fields map[string]interface{}) error {
+			fields["price"] = "EUR " + fields["price"].(string)
+			return nil
+		},
+	}}
+	assert.Equal(t, 3, schema.Version(), "should have a version after every upcaster")
+
+	data, version, err := schema.Upcast([]byte(unversionedMedicine))
+	assert.Nil(t, err, "should upcast an unversioned state")
+	assert.Equal(t, 1, version, "should read states without schema version as version 1")
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	assert.Equal(t, "EUR $10", fields["price"], "should apply every upcaster in order")
+	assert.Equal(t, float64(3), fields["schemaVersion"], "should store the latest version")
+
+	upcasted, version, err := schema.Upcast(data)
+	assert.Nil(t, err, "should accept the latest version")
+	assert.Equal(t, 3, version, "should return the stored version")
+	assert.Equal(t, data, upcasted, "should return the latest version as is")
+
+	_, _, err = ledgerapi.Schema{}.Upcast(data)
+	assert.EqualError(t, err, "schema version 3 is newer than the supported version 1", "should reject states of a newer chaincode")
+
+	failing := ledgerapi.Schema{Upcasters: []ledgerapi.Upcaster{func(map[string]interface{}) error { return errors.New("missing price") }}}
+	_, _, err = failing.Upcast([]byte(unversionedMedicine))
+	assert.EqualError(t, err, "could not upcast schema version 1: missing price", "should return the error of an upcaster")
+}
+
+func TestDeserializeUnversioned(t *testing.T) {
+	ms := new(MedicalSupply)
+	err := DeserializeJSON([]byte(unversionedMedicine), ms)
+	assert.Nil(t, err, "should deserialize medicine written before schema versions")
+	assert.Equal(t, "aspirin", ms.MedName, "should read the fields")
+	assert.Equal(t, AVAILABLE, ms.GetState(), "should read the state")
+
+	data, _ := json.Marshal(ms)
+	version, _ := ledgerapi.SchemaVersionOf(data)
+	assert.Equal(t, schemaVersion("org.medstore.medicalsupply"), version, "should write the latest schema version")
+
+	auth := new(TPMAuth)
+	err = DeserializeTPM([]byte(`{"holder":"alice","tpmkey":"key","class":"org.medstore.tpmauth","key":"TPMAUTH:alice"}`), auth)
+	assert.Nil(t, err, "should deserialize tpm authentication written before schema versions")
+	assert.Equal(t, "key", auth.TPMKey, "should read the fields")
+
+	err = DeserializeJSON([]byte(`{"medName":"aspirin","schemaVersion":99}`), ms)
+	assert.EqualError(t, err, "Error deserializing medical supply. schema version 99 is newer than the supported version 2", "should reject states of a newer chaincode")
+}
+
+func TestMigrateStates(t *testing.T) {
+	stub := shimtest.NewMockStub("medicalsupply", nil)
+	ctx := new(TransactionContext)
+	ctx.SetStub(stub)
+	list := ctx.GetMedicineList()
+
+	stub.MockTransactionStart("tx1")
+	for _, number := range []string{"00001", "00002", "00003"} {
+		key, _ := stub.CreateCompositeKey("org.medstore.medicalsupplylist", []string{"MedStore", "aspirin", number})
+		stub.PutState(key, []byte(`{"medName":"aspirin","medNumber":"`+number+`","currentState":1,"class":"org.medstore.medicalsupply"}`))
+	}
+	key, _ := stub.CreateCompositeKey("org.medstore.medicalsupplylist", []string{"Unknown", "00001"})
+	stub.PutState(key, []byte(`{"class":"org.medstore.unknown"}`))
+	assert.Nil(t, list.UpdateQuota(&QuotaRule{RuleID: "Q0001", Scope: "medicine", Target: "aspirin", MaxUnits: 1, PeriodDays: 1}), "should add quota")
+	stub.MockTransactionEnd("tx1")
+
+	_, err := list.MigrateStates("", 0)
+	assert.EqualError(t, err, "page size should be between 1 and 1000", "should reject invalid page sizes")
+	_, _, err = ledgerapi.NewStateList[*MedicalSupply](ctx, ListName, MedicineClass).GetStatesPage("", 0)
+	assert.EqualError(t, err, "page size should be at least 1, got 0", "should reject empty pages in the ledger api")
+	_, err = list.MigrateStates("not base64!", 2)
+	assert.EqualError(t, err, "invalid bookmark not base64!", "should reject invalid bookmarks")
+
+	var pages []*MigrationProgress
+	bookmark := ""
+	for {
+		stub.MockTransactionStart("migrate")
+		progress, err := list.MigrateStates(bookmark, 2)
+		stub.MockTransactionEnd("migrate")
+		assert.Nil(t, err, "should migrate a page")
+		pages = append(pages, progress)
+		if progress.Done || len(pages) > 5 {
+			break
+		}
+		bookmark = progress.Bookmark
+	}
+
+	assert.Len(t, pages, 3, "should migrate the states in pages")
+	scanned, migrated, skipped := 0, 0, 0
+	for _, page := range pages {
+		scanned += page.Scanned
+		migrated += page.Migrated
+		skipped += page.Skipped
+	}
+	assert.Equal(t, 5, scanned, "should scan every state of the list")
+	assert.Equal(t, 3, migrated, "should only rewrite unversioned states")
+	assert.Equal(t, 1, skipped, "should skip states of an unknown class")
+	assert.Equal(t, "", pages[2].Bookmark, "should clear the bookmark when done")
+
+	medKey, _ := stub.CreateCompositeKey("org.medstore.medicalsupplylist", []string{"MedStore", "aspirin", "00002"})
+	data, _ := stub.GetState(medKey)
+	version, _ := ledgerapi.SchemaVersionOf(data)
+	assert.Equal(t, 2, version, "should store the latest schema version")
+
+	stub.MockTransactionStart("again")
+	progress, _ := list.MigrateStates("", MaxMigrationPageSize)
+	stub.MockTransactionEnd("again")
+	assert.Equal(t, 0, progress.Migrated, "should not rewrite migrated states again")
+	assert.True(t, progress.Done, "should be done in a single large page")
+}
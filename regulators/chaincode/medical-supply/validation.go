@@ -0,0 +1,352 @@
+package medicalsupply
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Length limits of the transaction arguments, counted in characters.
+const (
+	MaxKeyPartLength  = 128
+	MaxTextLength     = 1024
+	MaxDocumentLength = 65536
+)
+
+// FieldError - Defines why the value of a single transaction argument is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// fieldsError - Creates an error for the invalid arguments of a transaction, e.g. invalid Issue: medName is required.
+func fieldsError(code ErrorCode, transaction string, fields []*FieldError) *ContractError {
+	messages := make([]string, len(fields))
+	for i, fe := range fields {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	ce := newError(code, "invalid %s: %s", transaction, strings.Join(messages, "; "))
+	ce.Fields = fields
+	return ce
+}
+
+// fieldError - Creates an error for a single argument, e.g. when its value refers to an existing state.
+func fieldError(code ErrorCode, transaction string, field string, message string) *ContractError {
+	return fieldsError(code, transaction, []*FieldError{{Field: field, Message: message}})
+}
+
+// Rule - Returns why the value is invalid, or an empty string if it is valid.
+type Rule func(value interface{}) string
+
+// FieldRules - Defines the rules of a transaction argument, which are applied in order until one fails.
+type FieldRules struct {
+	Field string
+	Rules []Rule
+}
+
+// textRule - Creates a rule for text arguments.
+func textRule(check func(string) string) Rule {
+	return func(value interface{}) string {
+		text, ok := value.(string)
+		if !ok {
+			return "should be text"
+		}
+		return check(text)
+	}
+}
+
+// numberRule - Creates a rule for number arguments.
+func numberRule(check func(int) string) Rule {
+	return func(value interface{}) string {
+		number, ok := value.(int)
+		if !ok {
+			return "should be a number"
+		}
+		return check(number)
+	}
+}
+
+// required - Rejects empty and blank text.
+var required = textRule(func(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "is required"
+	}
+	return ""
+})
+
+// keyPart - Rejects text which can't be used as part of a ledger key, as colons separate the parts and Fabric uses U+0000.
+var keyPart = textRule(func(text string) string {
+	if !utf8.ValidString(text) || strings.IndexFunc(text, func(r rune) bool { return r == ':' || unicode.IsControl(r) }) >= 0 {
+		return "should not contain ':' or control characters"
+	}
+	return ""
+})
+
+// plainText - Rejects invalid UTF-8 and null characters, which the state database can't store.
+var plainText = textRule(func(text string) string {
+	if !utf8.ValidString(text) || strings.ContainsRune(text, 0) {
+		return "should be valid UTF-8 without null characters"
+	}
+	return ""
+})
+
+// maxLength - Rejects text longer than max characters.
+func maxLength(max int) Rule {
+	return textRule(func(text string) string {
+		if utf8.RuneCountInString(text) > max {
+			return fmt.Sprintf("should be at most %d characters", max)
+		}
+		return ""
+	})
+}
+
+// isDate - Rejects text which isn't a date in the ledger layout, empty text is left to required.
+var isDate = textRule(func(text string) string {
+	if _, err := time.Parse(DateLayout, text); text != "" && err != nil {
+		return fmt.Sprintf("should be a date formatted as %s", DateLayout)
+	}
+	return ""
+})
+
+// isTimestamp - Rejects text which isn't an RFC3339 timestamp, empty text is left to required.
+var isTimestamp = textRule(func(text string) string {
+	if _, err := time.Parse(time.RFC3339, text); text != "" && err != nil {
+		return "should be a timestamp formatted as RFC3339 (e.g. 2022-02-22T00:00:00Z)"
+	}
+	return ""
+})
+
+// isPrice - Rejects text which isn't a price, empty text is left to required.
+var isPrice = textRule(func(text string) string {
+	if _, err := ParsePrice(text); text != "" && err != nil {
+		return "should be a price, e.g. $10 or $2.50"
+	}
+	return ""
+})
+
+// isHex - Rejects text which isn't hex encoded, empty text is left to required.
+var isHex = textRule(func(text string) string {
+	if _, err := hex.DecodeString(text); err != nil {
+		return "should be hex encoded"
+	}
+	return ""
+})
+
+// isBase64 - Rejects text which isn't base64 encoded, empty text is left to required.
+var isBase64 = textRule(func(text string) string {
+	if _, err := base64.StdEncoding.DecodeString(text); err != nil {
+		return "should be base64 encoded"
+	}
+	return ""
+})
+
+// isJSON - Rejects text which isn't valid JSON, empty text is left to required.
+var isJSON = textRule(func(text string) string {
+	if strings.TrimSpace(text) != "" && !json.Valid([]byte(text)) {
+		return "should be valid JSON"
+	}
+	return ""
+})
+
+// oneOf - Rejects text which isn't one of the values ignoring case, empty text is left to required.
+func oneOf(values ...string) Rule {
+	return textRule(func(text string) string {
+		if text == "" {
+			return ""
+		}
+		for _, value := range values {
+			if strings.EqualFold(text, value) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("should be one of %s", strings.Join(values, ", "))
+	})
+}
+
+// atLeast - Rejects numbers below min.
+func atLeast(min int) Rule {
+	return numberRule(func(number int) string {
+		if number < min {
+			return fmt.Sprintf("should be at least %d", min)
+		}
+		return ""
+	})
+}
+
+// between - Rejects numbers outside of min and max.
+func between(min int, max int) Rule {
+	return numberRule(func(number int) string {
+		if number < min || number > max {
+			return fmt.Sprintf("should be between %d and %d", min, max)
+		}
+		return ""
+	})
+}
+
+// field - Creates the rules of a transaction argument.
+func field(name string, rules ...Rule) FieldRules {
+	return FieldRules{Field: name, Rules: rules}
+}
+
+// Rules shared by the arguments of several transactions.
+var (
+	keyRules          = []Rule{required, keyPart, maxLength(MaxKeyPartLength)}
+	textRules         = []Rule{plainText, maxLength(MaxTextLength)}
+	requiredTextRules = []Rule{required, plainText, maxLength(MaxTextLength)}
+	documentRules     = []Rule{plainText, maxLength(MaxDocumentLength), isJSON}
+
+	medNameField   = field("medName", keyRules...)
+	medNumberField = field("medNumber", keyRules...)
+	userField      = field("user", keyRules...)
+	tpmkeyField    = field("tpmkey", requiredTextRules...)
+)
+
+// ruleSets - Rules of the arguments of every transaction in argument order, keyed by transaction name.
+var ruleSets = map[string][]FieldRules{
+	"TPMKeyGen":  {userField},
+	"InitLedger": {userField, tpmkeyField},
+	"Issue": {
+		medNameField, medNumberField,
+		field("disease", requiredTextRules...),
+		field("expiration", required, isDate),
+		field("price", required, isPrice),
+		field("rxOnly"),
+		field("schedule", oneOf(Schedules...)),
+		userField, tpmkeyField,
+	},
+	"IssueFromGS1": {
+		field("elementString", requiredTextRules...),
+		medNameField,
+		field("disease", requiredTextRules...),
+		field("price", required, isPrice),
+		field("rxOnly"),
+		field("schedule", oneOf(Schedules...)),
+		userField, tpmkeyField,
+	},
+	"Delete":                 {medNameField, medNumberField, userField, tpmkeyField},
+	"Request":                {medNameField, medNumberField, userField, tpmkeyField},
+	"CancelRequest":          {medNameField, medNumberField, userField, tpmkeyField},
+	"SearchMedicineByName":   {medNameField},
+	"SearchMedicine":         {field("query", requiredTextRules...)},
+	"SearchMedicineByGS1":    {field("elementString", requiredTextRules...)},
+	"CheckHistory":           {userField, tpmkeyField},
+	"CheckAvailableMedicine": {},
+	"CheckRequestedMedicine": {userField, tpmkeyField},
+	"CheckUserHistory":       {userField, tpmkeyField},
+	"ApproveRequest":         {medNameField, medNumberField, userField, tpmkeyField},
+	"RejectRequest":          {medNameField, medNumberField, userField, tpmkeyField},
+	"ChangeStatus":           {medNameField, medNumberField, field("status", required, oneOf("available", "requested", "send")), userField, tpmkeyField},
+	"ChangeHolder":           {medNameField, medNumberField, field("customer", keyRules...), userField, tpmkeyField},
+	"IssuePrescription": {
+		field("prescriptionID", keyRules...),
+		field("patient", keyRules...),
+		medNameField,
+		field("quantity", atLeast(1)),
+		field("refills", atLeast(0)),
+		field("validFrom", required, isDate),
+		field("validUntil", required, isDate),
+		userField, tpmkeyField,
+	},
+	"CheckPrescriptions": {userField, tpmkeyField},
+	"PlaceOrder":         {field("orderID", keyRules...), field("lines", append([]Rule{required}, documentRules...)...), userField, tpmkeyField},
+	"CancelOrder":        {field("orderID", keyRules...), userField, tpmkeyField},
+	"CheckUserOrders":    {userField, tpmkeyField},
+	"CheckOrders":        {userField, tpmkeyField},
+	"ApproveOrder":       {field("orderID", keyRules...), userField, tpmkeyField},
+	"RejectOrder":        {field("orderID", keyRules...), userField, tpmkeyField},
+	"RequestReturn": {
+		field("returnID", keyRules...),
+		medNameField, medNumberField,
+		field("reason", requiredTextRules...),
+		userField, tpmkeyField,
+	},
+	"InspectReturn": {
+		field("returnID", keyRules...),
+		field("outcome", required, oneOf("restock", "destroy")),
+		field("refund", required, isPrice),
+		field("notes", textRules...),
+		userField, tpmkeyField,
+	},
+	"CheckUserReturns": {userField, tpmkeyField},
+	"CheckReturns":     {userField, tpmkeyField},
+	"Quarantine":       {medNameField, medNumberField, field("note", textRules...), userField, tpmkeyField},
+	"Destroy": {
+		medNameField, medNumberField,
+		field("method", requiredTextRules...),
+		field("witnesses", requiredTextRules...),
+		field("date", required, isDate),
+		field("documentHash", isHex, maxLength(MaxKeyPartLength)),
+		userField, tpmkeyField,
+	},
+	"GetDestructionCertificate": {medNameField, medNumberField, userField, tpmkeyField},
+	"CheckDestructions":         {userField, tpmkeyField},
+	"SetQuotaRule": {
+		field("ruleID", keyRules...),
+		field("scope", required, oneOf("medicine", "category", "schedule")),
+		field("target", requiredTextRules...),
+		field("maxUnits", atLeast(0)),
+		field("periodDays", atLeast(1)),
+		userField, tpmkeyField,
+	},
+	"RemoveQuotaRule":    {field("ruleID", keyRules...), userField, tpmkeyField},
+	"CheckQuotaRules":    {userField, tpmkeyField},
+	"CheckQuotaUsage":    {field("threshold", between(0, 100)), userField, tpmkeyField},
+	"ExportEPCIS":        {field("from", isTimestamp), field("to", isTimestamp), field("product", textRules...), userField, tpmkeyField},
+	"InventoryReport":    {userField, tpmkeyField},
+	"ExpiryForecast":     {field("days", atLeast(0)), userField, tpmkeyField},
+	"RaiseExpiryAlert":   {field("alert", append([]Rule{required}, documentRules...)...), userField, tpmkeyField},
+	"QueryMedicines":     {field("filter", documentRules...), userField, tpmkeyField},
+	"RebuildSearchIndex": {userField, tpmkeyField},
+	"MigrateStates":      {field("bookmark", isBase64, maxLength(MaxTextLength)), field("pageSize", between(1, MaxMigrationPageSize)), userField, tpmkeyField},
+}
+
+// validate - Checks the arguments of the transaction against its rule set, before anything is read from the ledger.
+// Returns an INVALID_ARGUMENT error listing every invalid argument.
+func validate(transaction string, args ...interface{}) error {
+	ruleSet, ok := ruleSets[transaction]
+	if !ok || len(ruleSet) != len(args) {
+		return newError(CodeInternal, "no rule set for the %d arguments of %s", len(args), transaction)
+	}
+	return validateFields(transaction, ruleSet, args...)
+}
+
+// validateFields - Checks the arguments against the rules of the fields in the same order.
+func validateFields(transaction string, ruleSet []FieldRules, args ...interface{}) error {
+	var fields []*FieldError
+	for i, fr := range ruleSet {
+		for _, rule := range fr.Rules {
+			if message := rule(args[i]); message != "" {
+				fields = append(fields, &FieldError{Field: fr.Field, Message: message})
+				break
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return fieldsError(CodeInvalidArgument, transaction, fields)
+	}
+	return nil
+}
+
+// gs1KeyRules - Rules of the serial number and lot of a scanned pack, the serial number becomes the medicine number.
+var gs1KeyRules = []FieldRules{
+	field("serial (21)", keyRules...),
+	field("lot (10)", keyPart, maxLength(MaxKeyPartLength)),
+}
+
+// validateGS1 - Checks the serial number and lot of the element string of a transaction against the rules of keys.
+func validateGS1(transaction string, data *GS1Data) error {
+	values := []string{data.Serial, data.Lot}
+	for i, fr := range gs1KeyRules {
+		for _, rule := range fr.Rules {
+			if message := rule(values[i]); message != "" {
+				return fieldError(CodeInvalidArgument, transaction, "elementString", fr.Field+" "+message)
+			}
+		}
+	}
+	return nil
+}